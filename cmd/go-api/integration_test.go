@@ -12,6 +12,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 	"github.com/testcontainers/testcontainers-go"
@@ -43,9 +44,10 @@ func TestGoAPIOption_Run_GET_Sample(t *testing.T) {
 	appCtx := context.Background()
 	setup(context.Background(), appCtx, t)
 	type testcase struct {
-		url      string
-		want     string
-		wantCode int
+		url         string
+		ifNoneMatch string
+		want        string
+		wantCode    int
 	}
 	tests := map[string]testcase{
 		"existing sample id": {
@@ -53,13 +55,24 @@ func TestGoAPIOption_Run_GET_Sample(t *testing.T) {
 			want:     SampleJSON_0 + "\n",
 			wantCode: http.StatusOK,
 		},
+		"existing sample id with matching If-None-Match": {
+			url:         "http://localhost:8080/sample?id=00000000-0000-0000-0000-000000000000",
+			ifNoneMatch: `"1"`,
+			wantCode:    http.StatusNotModified,
+		},
+		"existing sample id with stale If-None-Match": {
+			url:         "http://localhost:8080/sample?id=00000000-0000-0000-0000-000000000000",
+			ifNoneMatch: `"0"`,
+			want:        SampleJSON_0 + "\n",
+			wantCode:    http.StatusOK,
+		},
 		"deleted sample id": {
 			url:      "http://localhost:8080/sample?id=00000000-0000-0000-0000-000000000001",
-			wantCode: http.StatusInternalServerError,
+			wantCode: http.StatusNotFound,
 		},
 		"non-existing sample id": {
 			url:      "http://localhost:8080/sample?id=00000000-0000-0000-0000-000000000010",
-			wantCode: http.StatusInternalServerError,
+			wantCode: http.StatusNotFound,
 		},
 		"no id": {
 			url:      "http://localhost:8080/sample",
@@ -74,6 +87,9 @@ func TestGoAPIOption_Run_GET_Sample(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			req, err := http.NewRequest(http.MethodGet, tt.url, nil)
 			assert.NoError(t, err)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
 			doWithAssert(req, tt.want, tt.wantCode, t)
 		})
 	}
@@ -107,6 +123,41 @@ func TestGoAPIOption_Run_GET_Samples(t *testing.T) {
 			want:      []string{SampleJSON_3},
 			wantTotal: 1,
 		},
+		"name=test-&match=prefix": {
+			url:       "http://localhost:8080/samples?name=test-&match=prefix",
+			want:      []string{SampleJSON_0, SampleJSON_3, SampleJSON_4},
+			wantTotal: 3,
+		},
+		"name=ninja&match=exact": {
+			url:       "http://localhost:8080/samples?name=ninja&match=exact",
+			want:      []string{},
+			wantTotal: 0,
+		},
+		"name=test_&match=prefix": {
+			url:       "http://localhost:8080/samples?name=test_&match=prefix",
+			want:      []string{},
+			wantTotal: 0,
+		},
+		"is_japanese=true&birth_month=12": {
+			url:       "http://localhost:8080/samples?is_japanese=true&birth_month=12",
+			want:      []string{SampleJSON_4},
+			wantTotal: 1,
+		},
+		"born_after=1994-09-14&born_before=1994-12-12": {
+			url:       "http://localhost:8080/samples?born_after=1994-09-14&born_before=1994-12-12",
+			want:      []string{SampleJSON_3},
+			wantTotal: 1,
+		},
+		"sort=-birthday,name": {
+			url:       "http://localhost:8080/samples?sort=-birthday,name",
+			want:      []string{SampleJSON_4, SampleJSON_3, SampleJSON_0},
+			wantTotal: 3,
+		},
+		"fields=id,name": {
+			url:       "http://localhost:8080/samples?fields=id,name&limit=1",
+			want:      []string{`{"ID":"00000000-0000-0000-0000-000000000000","Name":"test-japanese"}`},
+			wantTotal: 3,
+		},
 		"name=deleted": {
 			url:       "http://localhost:8080/samples?name=deleted",
 			want:      []string{},
@@ -128,6 +179,197 @@ func TestGoAPIOption_Run_GET_Samples(t *testing.T) {
 	}
 }
 
+func TestGoAPIOption_Run_GET_SamplesSearch(t *testing.T) {
+	appCtx := context.Background()
+	setup(context.Background(), appCtx, t)
+	type testcase struct {
+		url           string
+		wantCode      int
+		wantTotal     int
+		wantTop       string
+		wantHighlight string
+	}
+	tests := map[string]testcase{
+		"q=ninja": {
+			url:           "http://localhost:8080/samples/search?q=ninja",
+			wantCode:      http.StatusOK,
+			wantTotal:     3,
+			wantTop:       "00000000-0000-0000-0000-000000000004",
+			wantHighlight: "test-<em>ninja</em>",
+		},
+		"q=NINJA&limit=1": {
+			url:           "http://localhost:8080/samples/search?q=%EF%BC%AE%EF%BC%A9%EF%BC%AE%EF%BC%AA%EF%BC%A1&limit=1",
+			wantCode:      http.StatusOK,
+			wantTotal:     3,
+			wantTop:       "00000000-0000-0000-0000-000000000004",
+			wantHighlight: "test-<em>ninja</em>",
+		},
+		"q=does-not-exist": {
+			url:      "http://localhost:8080/samples/search?q=zzz",
+			wantCode: http.StatusOK,
+		},
+		"empty q": {
+			url:      "http://localhost:8080/samples/search?q=",
+			wantCode: http.StatusBadRequest,
+		},
+		"no q": {
+			url:      "http://localhost:8080/samples/search",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			resp, err := httpClient.Get(tt.url)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.wantCode, resp.StatusCode)
+			if !is2xx(resp.StatusCode) {
+				return
+			}
+			var got model.ScoredSamples
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, tt.wantTotal, got.Total)
+			if tt.wantTop == "" {
+				assert.Empty(t, got.Hits)
+				return
+			}
+			assert.Equal(t, tt.wantTop, got.Hits[0].Sample.ID.String())
+			assert.Equal(t, tt.wantHighlight, got.Hits[0].Highlight)
+		})
+	}
+}
+
+func TestGoAPIOption_Run_Restore_Sample(t *testing.T) {
+	appCtx := context.Background()
+	setup(context.Background(), appCtx, t)
+
+	req, err := http.NewRequest(http.MethodGet, "http://localhost:8080/samples/deleted", nil)
+	assert.NoError(t, err)
+	doWithAssert(req, `{"Total":2,"Samples":[`+
+		strings.TrimSuffix(SampleJSON_2, "}")+`,"DeletedAt":"2004-11-08T00:00:00+09:00"},`+
+		strings.TrimSuffix(SampleJSON_1, "}")+`,"DeletedAt":"2004-10-12T00:00:00+09:00"}]}`+"\n", http.StatusOK, t)
+
+	type testcase struct {
+		url         string
+		want        string
+		wantCode    int
+		assertAfter assertByIDFunc
+	}
+	tests := []testcase{
+		{
+			url:         "http://localhost:8080/samples/00000000-0000-0000-0000-000000000001/restore",
+			want:        SampleJSON_1 + "\n",
+			wantCode:    http.StatusOK,
+			assertAfter: getAndAssertWith(SampleJSON_1+"\n", http.StatusOK),
+		},
+		{
+			url:         "http://localhost:8080/samples/00000000-0000-0000-0000-000000000001/restore",
+			wantCode:    http.StatusNotFound,
+			assertAfter: nopAssertByID(),
+		},
+		{
+			url:         "http://localhost:8080/samples/00000000-0000-0000-0000-000000000010/restore",
+			wantCode:    http.StatusNotFound,
+			assertAfter: nopAssertByID(),
+		},
+		{
+			url:         "http://localhost:8080/samples/invalid-id/restore",
+			wantCode:    http.StatusBadRequest,
+			assertAfter: nopAssertByID(),
+		},
+	}
+	// restore cases depend on each other so that they run in order.
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodPost, tt.url, nil)
+		assert.NoError(t, err)
+		doWithAssert(req, tt.want, tt.wantCode, t)
+		tt.assertAfter("00000000-0000-0000-0000-000000000001", t)
+	}
+}
+
+func TestGoAPIOption_Run_Auth(t *testing.T) {
+	appCtx := context.Background()
+	setup(context.Background(), appCtx, t)
+
+	type testcase struct {
+		url      string
+		body     string
+		wantCode int
+	}
+	tests := []testcase{
+		{
+			url:      "http://localhost:8080/auth/register",
+			body:     `{"user_name":"kawamura","password":"password"}`,
+			wantCode: http.StatusCreated,
+		},
+		{
+			url:      "http://localhost:8080/auth/register",
+			body:     `{"user_name":"kawamura","password":"password"}`,
+			wantCode: http.StatusConflict,
+		},
+		{
+			url:      "http://localhost:8080/auth/register",
+			body:     `{"user_name":"short","password":"pass"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			url:      "http://localhost:8080/auth/login",
+			body:     `{"user_name":"kawamura","password":"wrong-password"}`,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			url:      "http://localhost:8080/auth/login",
+			body:     `{"user_name":"unknown","password":"password"}`,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			url:      "http://localhost:8080/auth/login",
+			body:     `{"user_name":"kawamura","password":"password"}`,
+			wantCode: http.StatusOK,
+		},
+	}
+	// login cases depend on the registered user so that they run in order.
+	var login tokenJSON
+	for _, tt := range tests {
+		login = postAndAssert(tt.url, tt.body, tt.wantCode, t)
+	}
+
+	refreshBody := func(token tokenJSON) string {
+		return `{"refresh_token":"` + token.RefreshToken + `"}`
+	}
+	rotated := postAndAssert("http://localhost:8080/auth/refresh", refreshBody(login), http.StatusOK, t)
+	assert.NotEqual(t, login.RefreshToken, rotated.RefreshToken)
+	// reusing the rotated token revokes the new token as well.
+	postAndAssert("http://localhost:8080/auth/refresh", refreshBody(login), http.StatusUnauthorized, t)
+	postAndAssert("http://localhost:8080/auth/refresh", refreshBody(rotated), http.StatusUnauthorized, t)
+
+	login = postAndAssert("http://localhost:8080/auth/login", `{"user_name":"kawamura","password":"password"}`, http.StatusOK, t)
+	postAndAssert("http://localhost:8080/auth/logout", refreshBody(login), http.StatusNoContent, t)
+	postAndAssert("http://localhost:8080/auth/refresh", refreshBody(login), http.StatusUnauthorized, t)
+}
+
+type tokenJSON struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// postAndAssert posts body as JSON to url, asserts the status code and returns the token in the response if it is 200.
+func postAndAssert(url, body string, wantCode int, t *testing.T) tokenJSON {
+	resp, err := httpClient.Post(url, "application/json", strings.NewReader(body))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, wantCode, resp.StatusCode, url+" "+body)
+	var token tokenJSON
+	if resp.StatusCode == http.StatusOK {
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&token))
+		assert.NotEmpty(t, token.AccessToken)
+		assert.NotEmpty(t, token.RefreshToken)
+		assert.Equal(t, "Bearer", token.TokenType)
+	}
+	return token
+}
+
 func TestGoAPIOption_Run_PUT_Sample(t *testing.T) {
 	type testcase struct {
 		url         string
@@ -199,6 +441,7 @@ func TestGoAPIOption_Run_POST_Sample(t *testing.T) {
 	type testcase struct {
 		id             string
 		url            string
+		ifMatch        string
 		wantCode       int
 		assertBefore   assertByIDFunc
 		wantBefore     string
@@ -229,12 +472,43 @@ func TestGoAPIOption_Run_POST_Sample(t *testing.T) {
 			assertBefore: getAndAssertWith(SampleJSON_4+"\n", http.StatusOK),
 			assertAfter:  getAndAssertWith(SampleJSON_4+"\n", http.StatusOK),
 		},
+		"existing sample id with matching If-Match": {
+			id:           "00000000-0000-0000-0000-000000000004",
+			url:          "http://localhost:8080/sample?id=00000000-0000-0000-0000-000000000004&name=post-test",
+			ifMatch:      `"1"`,
+			wantCode:     http.StatusOK,
+			assertBefore: getAndAssertWith(SampleJSON_4+"\n", http.StatusOK),
+			assertAfter:  getAndAssertWith(`{"ID":"00000000-0000-0000-0000-000000000004","Name":"post-test","Birthday":"1994-12-12T00:00:00+09:00","IsJapanese":true}`+"\n", http.StatusOK),
+		},
+		"existing sample id with stale If-Match": {
+			id:           "00000000-0000-0000-0000-000000000004",
+			url:          "http://localhost:8080/sample?id=00000000-0000-0000-0000-000000000004&name=post-test",
+			ifMatch:      `"2"`,
+			wantCode:     http.StatusPreconditionFailed,
+			assertBefore: getAndAssertWith(SampleJSON_4+"\n", http.StatusOK),
+			assertAfter:  getAndAssertWith(SampleJSON_4+"\n", http.StatusOK),
+		},
+		"existing sample id with malformed If-Match": {
+			id:           "00000000-0000-0000-0000-000000000004",
+			url:          "http://localhost:8080/sample?id=00000000-0000-0000-0000-000000000004&name=post-test",
+			ifMatch:      "1",
+			wantCode:     http.StatusBadRequest,
+			assertBefore: getAndAssertWith(SampleJSON_4+"\n", http.StatusOK),
+			assertAfter:  getAndAssertWith(SampleJSON_4+"\n", http.StatusOK),
+		},
+		"deleted sample id": {
+			id:           "00000000-0000-0000-0000-000000000001",
+			url:          "http://localhost:8080/sample?id=00000000-0000-0000-0000-000000000001&name=post-test",
+			wantCode:     http.StatusNotFound,
+			assertBefore: getAndAssertWith("", http.StatusNotFound),
+			assertAfter:  getAndAssertWith("", http.StatusNotFound),
+		},
 		"non-existing sample id": {
 			id:           "00000000-0000-0000-0000-000000000010",
 			url:          "http://localhost:8080/sample?id=00000000-0000-0000-0000-000000000010&name=post-test",
-			wantCode:     http.StatusOK,
-			assertBefore: getAndAssertWith("", http.StatusInternalServerError),
-			assertAfter:  getAndAssertWith("", http.StatusInternalServerError),
+			wantCode:     http.StatusNotFound,
+			assertBefore: getAndAssertWith("", http.StatusNotFound),
+			assertAfter:  getAndAssertWith("", http.StatusNotFound),
 		},
 	}
 	for name, tt := range tests {
@@ -245,6 +519,9 @@ func TestGoAPIOption_Run_POST_Sample(t *testing.T) {
 			// run
 			req, err := http.NewRequest(http.MethodPost, tt.url, nil)
 			assert.NoError(t, err)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
 			want := fmt.Sprintf(`{"id":%q}`+"\n", tt.id)
 			doWithAssert(req, want, tt.wantCode, t)
 			// check after state
@@ -253,10 +530,74 @@ func TestGoAPIOption_Run_POST_Sample(t *testing.T) {
 	}
 }
 
+func TestGoAPIOption_Run_PATCH_Sample(t *testing.T) {
+	type testcase struct {
+		url         string
+		contentType string
+		patch       string
+		wantCode    int
+		want        string
+		assertAfter assertByIDFunc
+	}
+	const id = "00000000-0000-0000-0000-000000000004"
+	tests := map[string]testcase{
+		"merge patch": {
+			url:         "http://localhost:8080/samples/" + id,
+			contentType: "application/merge-patch+json",
+			patch:       `{"Name":"","IsJapanese":false}`,
+			wantCode:    http.StatusOK,
+			want:        sampleJSON(id, "", "1994-12-12T00:00:00+09:00", false) + "\n",
+			assertAfter: getAndAssertWith(sampleJSON(id, "", "1994-12-12T00:00:00+09:00", false)+"\n", http.StatusOK),
+		},
+		"json patch": {
+			url:         "http://localhost:8080/samples/" + id,
+			contentType: "application/json-patch+json",
+			patch:       `[{"op":"replace","path":"/Name","value":"patch-test"}]`,
+			wantCode:    http.StatusOK,
+			want:        sampleJSON(id, "patch-test", "1994-12-12T00:00:00+09:00", true) + "\n",
+			assertAfter: getAndAssertWith(sampleJSON(id, "patch-test", "1994-12-12T00:00:00+09:00", true)+"\n", http.StatusOK),
+		},
+		"changing id": {
+			url:         "http://localhost:8080/samples/" + id,
+			contentType: "application/merge-patch+json",
+			patch:       `{"ID":"00000000-0000-0000-0000-000000000000"}`,
+			wantCode:    http.StatusUnprocessableEntity,
+			assertAfter: getAndAssertWith(SampleJSON_4+"\n", http.StatusOK),
+		},
+		"unsupported content type": {
+			url:         "http://localhost:8080/samples/" + id,
+			contentType: "application/json",
+			patch:       `{"Name":"patch-test"}`,
+			wantCode:    http.StatusUnsupportedMediaType,
+			assertAfter: getAndAssertWith(SampleJSON_4+"\n", http.StatusOK),
+		},
+		"non-existing sample id": {
+			url:         "http://localhost:8080/samples/00000000-0000-0000-0000-000000000010",
+			contentType: "application/merge-patch+json",
+			patch:       `{"Name":"patch-test"}`,
+			wantCode:    http.StatusNotFound,
+			assertAfter: nopAssertByID(),
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			setup(context.Background(), context.Background(), t)
+			// run
+			req, err := http.NewRequest(http.MethodPatch, tt.url, strings.NewReader(tt.patch))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", tt.contentType)
+			doWithAssert(req, tt.want, tt.wantCode, t)
+			// check after state
+			tt.assertAfter(id, t)
+		})
+	}
+}
+
 func TestGoAPIOption_Run_DELETE_Sample(t *testing.T) {
 	type testcase struct {
 		id           string
 		url          string
+		ifMatch      string
 		assertBefore assertByIDFunc
 		wantCode     int
 		assertAfter  assertByIDFunc
@@ -273,15 +614,52 @@ func TestGoAPIOption_Run_DELETE_Sample(t *testing.T) {
 			id:           "00000000-0000-0000-0000-000000000004",
 			url:          "http://localhost:8080/sample?id=00000000-0000-0000-0000-000000000004",
 			assertBefore: getAndAssertWith(SampleJSON_4+"\n", http.StatusOK),
-			wantCode:     http.StatusOK,
-			assertAfter:  getAndAssertWith("", http.StatusInternalServerError),
+			wantCode:     http.StatusNoContent,
+			assertAfter:  getAndAssertWith("", http.StatusNotFound),
+		},
+		"hard delete existing sample id": {
+			id:           "00000000-0000-0000-0000-000000000004",
+			url:          "http://localhost:8080/sample?id=00000000-0000-0000-0000-000000000004&hard=true",
+			assertBefore: getAndAssertWith(SampleJSON_4+"\n", http.StatusOK),
+			wantCode:     http.StatusNoContent,
+			assertAfter:  getAndAssertWith("", http.StatusNotFound),
+		},
+		"hard delete deleted sample id": {
+			id:           "00000000-0000-0000-0000-000000000001",
+			url:          "http://localhost:8080/sample?id=00000000-0000-0000-0000-000000000001&hard=true",
+			assertBefore: getAndAssertWith("", http.StatusNotFound),
+			wantCode:     http.StatusNoContent,
+			assertAfter:  getAndAssertWith("", http.StatusNotFound),
+		},
+		"already deleted sample id": {
+			id:           "00000000-0000-0000-0000-000000000001",
+			url:          "http://localhost:8080/sample?id=00000000-0000-0000-0000-000000000001",
+			assertBefore: getAndAssertWith("", http.StatusNotFound),
+			wantCode:     http.StatusNotFound,
+			assertAfter:  getAndAssertWith("", http.StatusNotFound),
+		},
+		"existing sample id with stale If-Match": {
+			id:           "00000000-0000-0000-0000-000000000004",
+			url:          "http://localhost:8080/sample?id=00000000-0000-0000-0000-000000000004",
+			ifMatch:      `"2"`,
+			assertBefore: getAndAssertWith(SampleJSON_4+"\n", http.StatusOK),
+			wantCode:     http.StatusPreconditionFailed,
+			assertAfter:  getAndAssertWith(SampleJSON_4+"\n", http.StatusOK),
 		},
 		"non-existing sample id": {
 			id:           "00000000-0000-0000-0000-000000000010",
 			url:          "http://localhost:8080/sample?id=00000000-0000-0000-0000-000000000010",
-			assertBefore: getAndAssertWith("", http.StatusInternalServerError),
-			wantCode:     http.StatusOK,
-			assertAfter:  getAndAssertWith("", http.StatusInternalServerError),
+			assertBefore: getAndAssertWith("", http.StatusNotFound),
+			wantCode:     http.StatusNotFound,
+			assertAfter:  getAndAssertWith("", http.StatusNotFound),
+		},
+		"non-existing sample id with If-Match any": {
+			id:           "00000000-0000-0000-0000-000000000010",
+			url:          "http://localhost:8080/sample?id=00000000-0000-0000-0000-000000000010",
+			ifMatch:      "*",
+			assertBefore: getAndAssertWith("", http.StatusNotFound),
+			wantCode:     http.StatusPreconditionFailed,
+			assertAfter:  getAndAssertWith("", http.StatusNotFound),
 		},
 	}
 	for name, tt := range tests {
@@ -292,6 +670,9 @@ func TestGoAPIOption_Run_DELETE_Sample(t *testing.T) {
 			// run
 			req, err := http.NewRequest(http.MethodDelete, tt.url, nil)
 			assert.NoError(t, err)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
 			doWithAssert(req, "", tt.wantCode, t)
 			// check delete
 			tt.assertAfter(tt.id, t)
@@ -372,6 +753,10 @@ func setup(containerCtx, appCtx context.Context, t *testing.T) {
 			MySQL:  MySQLOption{Table: SAMPLE_TABLE, DSN: dsn},
 			Server: ServerOption{Port: "8080"},
 			Log:    LogOption{SlogLevel{slog.LevelError}},
+			Auth:   AuthOption{NoRBAC: true},
+			Cursor: CursorOption{Secret: "secret"},
+			// the expectations of search are ranked by the memory index, which is safe in the single process.
+			Search: SearchOption{Index: SearchIndexMemory},
 		}).Run(appCtx))
 	}()
 }
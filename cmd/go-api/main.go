@@ -2,30 +2,74 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
+	"database/sql"
+	"errors"
+	"expvar"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"log/slog"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"regexp"
+	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
+	"github.com/Accel-Hack/go-api/internal/app/infra/audit"
+	"github.com/Accel-Hack/go-api/internal/app/infra/jwks"
+	"github.com/Accel-Hack/go-api/internal/app/infra/notify"
 	"github.com/Accel-Hack/go-api/internal/app/infra/repository"
+	"github.com/Accel-Hack/go-api/internal/app/infra/search"
+	"github.com/Accel-Hack/go-api/internal/app/job"
 	"github.com/Accel-Hack/go-api/internal/app/server"
+	"github.com/Accel-Hack/go-api/internal/app/usercase/auth"
 	"github.com/Accel-Hack/go-api/internal/app/usercase/sample"
+	"github.com/Accel-Hack/go-api/internal/app/usercase/transaction"
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
 	"github.com/go-sql-driver/mysql"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	_ "github.com/lib/pq"
 	"xorm.io/xorm"
 )
 
 type GoAPICmd struct {
-	flags  *flag.FlagSet
-	MySQL  MySQLOption
-	Server ServerOption
-	Log    LogOption
+	flags    *flag.FlagSet
+	DB       DBOption
+	MySQL    MySQLOption
+	Postgres PostgresOption
+	Server   ServerOption
+	Cursor   CursorOption
+	Search   SearchOption
+	Purge    PurgeOption
+	Auth     AuthOption
+	APIKey   APIKeyOption
+	Log      LogOption
+}
+
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+)
+
+type DBOption struct {
+	Driver    string
+	Isolation string
+}
+
+var isolationLevels = map[string]sql.IsolationLevel{
+	"READ-UNCOMMITTED": sql.LevelReadUncommitted,
+	"READ-COMMITTED":   sql.LevelReadCommitted,
+	"REPEATABLE-READ":  sql.LevelRepeatableRead,
+	"SERIALIZABLE":     sql.LevelSerializable,
 }
 
 type MySQLOption struct {
@@ -37,9 +81,128 @@ type MySQLOption struct {
 	DSN      string
 }
 
+type PostgresOption struct {
+	User     string
+	Password string
+	Addr     string
+	Database string
+	Table    string
+	SSLMode  string
+	DSN      string
+}
+
 type ServerOption struct {
 	Host string
 	Port string
+	// IdempotentDelete responds 204 instead of 404 to DELETE of a sample which does not exist or is already deleted.
+	IdempotentDelete bool
+	// ClientIPHeader is the header of the client IP set by a trusted reverse proxy. The remote address is used if it is empty.
+	ClientIPHeader string
+}
+
+type CursorOption struct {
+	Secret string
+}
+
+const (
+	SearchIndexMemory   = "memory"
+	SearchIndexFulltext = "fulltext"
+)
+
+type SearchOption struct {
+	// Index is the kind of the full-text search index. An empty index is treated as SearchIndexFulltext on MySQL,
+	// and disables full-text search on the other databases. SearchIndexMemory must be selected explicitly
+	// because each process only sees its own changes.
+	Index string
+}
+
+type PurgeOption struct {
+	// Interval is the interval of the background purge job. The job is disabled if it is not positive.
+	Interval  time.Duration
+	Retention time.Duration
+	BatchSize int
+	DryRun    bool
+}
+
+const (
+	AuthStoreDB     = "db"
+	AuthStoreMemory = "memory"
+)
+
+const (
+	NotifierLog  = "log"
+	NotifierFile = "file"
+)
+
+const (
+	JWTAlgHS256 = "HS256"
+	JWTAlgEdDSA = "EdDSA"
+)
+
+type AuthOption struct {
+	// Store is where users and refresh tokens are stored. An empty store is treated as AuthStoreDB.
+	Store string
+	// TokenTTL is the lifetime of access tokens. auth.DefaultTokenTTL is used if it is not positive.
+	TokenTTL time.Duration
+	// RefreshTTL is the lifetime of refresh tokens. auth.DefaultRefreshTTL is used if it is not positive.
+	RefreshTTL time.Duration
+	// CleanupInterval is the interval to delete expired tokens and old login failures. The job is disabled if it is not positive.
+	CleanupInterval time.Duration
+	// ResetTTL is the lifetime of reset codes. auth.DefaultResetTTL is used if it is not positive.
+	ResetTTL time.Duration
+	// Notifier tells reset codes to users. Password reset is disabled if it is empty.
+	Notifier string
+	// NotifierFile is the file where NotifierFile appends reset codes.
+	NotifierFile string
+	// NoRBAC allows all operations on samples to anyone instead of authorizing them by the actor of the bearer token.
+	// It is only for local development, and GET "/admin/audit" is not exposed with it.
+	NoRBAC  bool
+	JWT     JWTOption
+	JWKS    JWKSOption
+	Lockout LockoutOption
+}
+
+// LockoutOption locks out logins with too many failures. See auth.LoginThrottle.
+type LockoutOption struct {
+	// UserFailures is the number of failures of a user name to lock it out. User names are not locked out if it is 0.
+	UserFailures int
+	// IPFailures is the number of failures from a client IP to lock it out. Client IPs are not locked out if it is 0.
+	IPFailures int
+	Window     time.Duration
+	Base       time.Duration
+	Max        time.Duration
+}
+
+type JWTOption struct {
+	// Alg is the signing algorithm. An empty algorithm is treated as JWTAlgHS256.
+	Alg string
+	// Secret is the secret of HS256. A random secret is used if it is empty.
+	Secret string
+	// KeyFile is the PEM file of the Ed25519 private key of EdDSA.
+	KeyFile string
+	Issuer  string
+}
+
+// JWKSOption verifies bearer tokens issued by an external identity provider with its JSON Web Key Set.
+type JWKSOption struct {
+	// Source is the file or the http(s) URL of the key set. External tokens are rejected if it is empty.
+	Source          string
+	Issuer          string
+	Audience        string
+	RefreshInterval time.Duration
+	UserNameClaim   string
+	ActorClaim      string
+	// Actors maps values of ActorClaim to actors like "admins=SYSTEM,editors=MANAGER".
+	Actors string
+}
+
+// APIKeyOption is the API key to create or revoke by `go-api apikey`.
+type APIKeyOption struct {
+	Name  string
+	Actor string
+	// Scopes are separated by commas.
+	Scopes string
+	ID     string
 }
 
 type LogOption struct {
@@ -55,21 +218,76 @@ func (l *SlogLevel) Set(s string) error {
 }
 
 func (c *GoAPICmd) Usage() {
-	fmt.Fprintf(c.flags.Output(), "Usage of go-api:\nA go-api requires '-mysql.addr' or '-mysql.dsn' (which is prioritized over '-mysql.addr').\n\n")
+	fmt.Fprintf(c.flags.Output(), "Usage of go-api:\n  go-api [flags]        serve the API\n  go-api purge [flags]  purge deleted samples once and exit\n"+
+		"  go-api apikey create|list|revoke [flags]\n                        manage API keys of machine clients\n\n"+
+		"A go-api requires '-mysql.addr' or '-mysql.dsn' (which is prioritized over '-mysql.addr').\n"+
+		"With '-db.driver=postgres', '-postgres.addr' or '-postgres.dsn' is required instead.\n\n")
 	c.flags.PrintDefaults()
 }
 
 var cmd = &GoAPICmd{
-	flags:  flag.NewFlagSet("go-api", flag.ExitOnError),
-	MySQL:  MySQLOption{},
-	Server: ServerOption{},
-	Log:    LogOption{Level: SlogLevel{slog.LevelInfo}},
+	flags:    flag.NewFlagSet("go-api", flag.ExitOnError),
+	DB:       DBOption{},
+	MySQL:    MySQLOption{},
+	Postgres: PostgresOption{},
+	Server:   ServerOption{},
+	Cursor:   CursorOption{},
+	Search:   SearchOption{},
+	Purge:    PurgeOption{},
+	Log:      LogOption{Level: SlogLevel{slog.LevelInfo}},
 }
 
 func init() {
 	cmd.flags.Var(&cmd.Log.Level, "log.level", "Logging level one of [DEBUG INFO WARN ERROR]")
 	cmd.flags.StringVar(&cmd.Server.Host, "server.host", "localhost", "Host to serve")
 	cmd.flags.StringVar(&cmd.Server.Port, "server.port", "8080", "Port to serve")
+	cmd.flags.BoolVar(&cmd.Server.IdempotentDelete, "server.idempotent-delete", false, "Respond 204 instead of 404 to DELETE of a sample which does not exist or is already deleted")
+	cmd.flags.StringVar(&cmd.Server.ClientIPHeader, "server.client-ip-header", "", "Header of the client IP set by a trusted reverse proxy such as X-Forwarded-For, whose last value is used. "+
+		"The remote address is used if empty")
+	cmd.flags.StringVar(&cmd.Cursor.Secret, "cursor.secret", "", "Secret to sign pagination cursors. Required. "+
+		"Every replica must share the same secret so that cursors stay valid on restart and among replicas")
+	cmd.flags.StringVar(&cmd.Search.Index, "search.index", "", "Full-text search index one of [fulltext memory]. "+
+		"fulltext requires FULLTEXT index of MySQL and is used on MySQL if empty. Full-text search is disabled on PostgreSQL if empty. "+
+		"memory is built on start up in each process and misses changes by the other processes, so it is only for a single process")
+	cmd.flags.DurationVar(&cmd.Purge.Interval, "purge.interval", 0, "Interval to purge deleted samples in background. Disabled if 0")
+	cmd.flags.DurationVar(&cmd.Purge.Retention, "purge.retention", 30*24*time.Hour, "Retention of deleted samples before purged. Must be positive")
+	cmd.flags.IntVar(&cmd.Purge.BatchSize, "purge.batch-size", sample.DefaultPurgeBatchSize, "Maximum number of samples purged at once")
+	cmd.flags.BoolVar(&cmd.Purge.DryRun, "purge.dry-run", false, "Count samples to purge without deleting them")
+	cmd.flags.StringVar(&cmd.Auth.Store, "auth.store", AuthStoreDB, "Store of users and refresh tokens one of [db memory]. memory is lost on restart and not shared among replicas")
+	cmd.flags.DurationVar(&cmd.Auth.TokenTTL, "auth.token-ttl", auth.DefaultTokenTTL, "Lifetime of access tokens")
+	cmd.flags.DurationVar(&cmd.Auth.RefreshTTL, "auth.refresh-ttl", auth.DefaultRefreshTTL, "Lifetime of refresh tokens")
+	cmd.flags.DurationVar(&cmd.Auth.CleanupInterval, "auth.cleanup-interval", time.Hour, "Interval to delete expired tokens and old login failures in background. Disabled if 0")
+	cmd.flags.DurationVar(&cmd.Auth.ResetTTL, "auth.reset-ttl", auth.DefaultResetTTL, "Lifetime of password reset codes")
+	cmd.flags.StringVar(&cmd.Auth.Notifier, "auth.notifier", "", "Notifier of password reset codes one of [log file] for local development. Password reset is disabled if empty")
+	cmd.flags.StringVar(&cmd.Auth.NotifierFile, "auth.notifier.file", "reset_codes.jsonl", "File to append password reset codes with auth.notifier=file")
+	cmd.flags.BoolVar(&cmd.Auth.NoRBAC, "auth.no-rbac", false, "Allow anyone to do any operations on samples for local development. "+
+		"Otherwise operations are authorized by the actor of the bearer token: USER can read and edit their own samples, "+
+		"MANAGER can do so on all samples, and SYSTEM can restore, hard-delete and search the audit log in addition")
+	cmd.flags.IntVar(&cmd.Auth.Lockout.UserFailures, "auth.lockout.user-failures", auth.DefaultMaxUserFailures, "Consecutive login failures of a user name to lock it out. Disabled if 0")
+	cmd.flags.IntVar(&cmd.Auth.Lockout.IPFailures, "auth.lockout.ip-failures", auth.DefaultMaxIPFailures, "Consecutive login failures from a client IP to lock it out. Disabled if 0")
+	cmd.flags.DurationVar(&cmd.Auth.Lockout.Window, "auth.lockout.window", auth.DefaultFailureWindow, "Period after which login failures are forgotten")
+	cmd.flags.DurationVar(&cmd.Auth.Lockout.Base, "auth.lockout.base", auth.DefaultBaseLockout, "First lockout, which doubles on each lockout of the same user name or client IP")
+	cmd.flags.DurationVar(&cmd.Auth.Lockout.Max, "auth.lockout.max", auth.DefaultMaxLockout, "Limit of lockouts")
+	cmd.flags.StringVar(&cmd.APIKey.Name, "apikey.name", "", "Name of the API key to create by go-api apikey create")
+	cmd.flags.StringVar(&cmd.APIKey.Actor, "apikey.actor", model.ActorManager.String(), "Actor of the API key to create one of [SYSTEM MANAGER]")
+	cmd.flags.StringVar(&cmd.APIKey.Scopes, "apikey.scopes", string(auth.ScopeSamplesRead), "Comma separated scopes of the API key to create from [samples:read samples:write]")
+	cmd.flags.StringVar(&cmd.APIKey.ID, "apikey.id", "", "ID of the API key to revoke by go-api apikey revoke")
+	cmd.flags.StringVar(&cmd.Auth.JWT.Alg, "auth.jwt.alg", JWTAlgHS256, "Signing algorithm of access tokens one of [HS256 EdDSA]")
+	cmd.flags.StringVar(&cmd.Auth.JWT.Secret, "auth.jwt.secret", "", "Secret of HS256. A random secret is generated if empty, which invalidates access tokens on restart and among replicas")
+	cmd.flags.StringVar(&cmd.Auth.JWT.KeyFile, "auth.jwt.key-file", "", "PEM file of the Ed25519 private key in PKCS #8 for EdDSA")
+	cmd.flags.StringVar(&cmd.Auth.JWT.Issuer, "auth.jwt.issuer", "go-api", "Issuer of access tokens")
+	cmd.flags.StringVar(&cmd.Auth.JWKS.Source, "auth.jwks.source", "", "File or http(s) URL of the JSON Web Key Set of an external identity provider. "+
+		"Bearer tokens of auth.jwks.issuer are verified with it if not empty")
+	cmd.flags.StringVar(&cmd.Auth.JWKS.Issuer, "auth.jwks.issuer", "", "Issuer of external tokens. Required if auth.jwks.source is not empty")
+	cmd.flags.StringVar(&cmd.Auth.JWKS.Audience, "auth.jwks.audience", "", "Audience which external tokens must be issued for. Required if auth.jwks.source is not empty")
+	cmd.flags.DurationVar(&cmd.Auth.JWKS.RefreshInterval, "auth.jwks.refresh-interval", auth.DefaultJWKSRefreshInterval, "Interval to fetch the key set again to rotate keys")
+	cmd.flags.StringVar(&cmd.Auth.JWKS.UserNameClaim, "auth.jwks.user-name-claim", "preferred_username", "Claim of the user name of external tokens. The subject is used if absent")
+	cmd.flags.StringVar(&cmd.Auth.JWKS.ActorClaim, "auth.jwks.actor-claim", "groups", "Claim of a string or strings of external tokens mapped to the actor by auth.jwks.actors")
+	cmd.flags.StringVar(&cmd.Auth.JWKS.Actors, "auth.jwks.actors", "", "Comma separated mapping from values of auth.jwks.actor-claim to actors like admins=SYSTEM,editors=MANAGER. "+
+		"USER is used if no values are mapped")
+	cmd.flags.StringVar(&cmd.DB.Driver, "db.driver", DriverMySQL, "Database driver one of [mysql postgres]")
+	cmd.flags.StringVar(&cmd.DB.Isolation, "db.isolation", "", "Transaction isolation level one of [READ-UNCOMMITTED READ-COMMITTED REPEATABLE-READ SERIALIZABLE]. "+
+		"The database default is used if empty")
 	cmd.flags.StringVar(&cmd.MySQL.User, "mysql.user", "root", "Username")
 	cmd.flags.StringVar(&cmd.MySQL.Password, "mysql.password", "", "Password")
 	cmd.flags.StringVar(&cmd.MySQL.Addr, "mysql.addr", "localhost:3566", "MySQL URL. Required if mysql.dsn is empty")
@@ -77,42 +295,94 @@ func init() {
 	cmd.flags.StringVar(&cmd.MySQL.Table, "mysql.table", "SAMPLE", "Table name")
 	cmd.flags.StringVar(&cmd.MySQL.DSN, "mysql.dsn", "", `Data source name format defined as follow: `+
 		`"[username[:password]@][protocol[(address)]]/dbname[?param1=value1&...&paramN=valueN]"`)
+	cmd.flags.StringVar(&cmd.Postgres.User, "postgres.user", "postgres", "Username")
+	cmd.flags.StringVar(&cmd.Postgres.Password, "postgres.password", "", "Password")
+	cmd.flags.StringVar(&cmd.Postgres.Addr, "postgres.addr", "localhost:5432", "PostgreSQL URL. Required if postgres.dsn is empty")
+	cmd.flags.StringVar(&cmd.Postgres.Database, "postgres.database", "YOUR_APPLICATION", "Database name")
+	cmd.flags.StringVar(&cmd.Postgres.Table, "postgres.table", "SAMPLE", "Table name")
+	cmd.flags.StringVar(&cmd.Postgres.SSLMode, "postgres.sslmode", "disable", "SSL mode one of [disable require verify-ca verify-full]")
+	cmd.flags.StringVar(&cmd.Postgres.DSN, "postgres.dsn", "", `Data source name format defined as follow: `+
+		`"postgres://[username[:password]@]host[:port]/dbname[?param1=value1&...&paramN=valueN]" or "key1=value1 ... keyN=valueN". `+
+		`It is passed to the driver as is`)
 }
 
 func main() {
 	cmd.flags.Usage = cmd.Usage
-	cmd.flags.Parse(os.Args[1:])
 	ctx := context.Background()
+	if len(os.Args) > 2 && os.Args[1] == "apikey" {
+		cmd.flags.Parse(os.Args[3:])
+		if err := cmd.RunAPIKey(ctx, os.Args[2], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "purge" {
+		cmd.flags.Parse(os.Args[2:])
+		if err := cmd.RunPurge(ctx); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	cmd.flags.Parse(os.Args[1:])
 	if err := cmd.Run(ctx); err != nil {
 		log.Fatal(err)
 	}
 }
 
 func (c *GoAPICmd) Run(ctx context.Context) error {
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: c.Log.Level.Level,
-	},
-	))
-	dsn := (&mysql.Config{
-		User:   c.MySQL.User,
-		Passwd: c.MySQL.Password,
-		Net:    "tcp",
-		Addr:   c.MySQL.Addr,
-		DBName: c.MySQL.Database,
-	}).FormatDSN()
-	if c.MySQL.DSN != "" {
-		dsn = c.MySQL.DSN
+	logger := c.logger()
+	driver, xormEngine, table, err := c.engine()
+	if err != nil {
+		return err
+	}
+	cursor, err := sample.NewCursorCodec([]byte(c.Cursor.Secret))
+	if err != nil {
+		return fmt.Errorf("cursor.secret is required: %w", err)
+	}
+	repo := repository.NewSampleXorm(xormEngine, table)
+	backfilled, err := repo.BackfillNormalizedNames(ctx)
+	if err != nil {
+		return err
+	}
+	logger.Info("backfill normalized names", "rows", backfilled)
+	index, err := c.searchIndex(logger, driver, xormEngine, table)
+	if err != nil {
+		return err
+	}
+	authUsecase, err := c.authUsecase(logger, xormEngine)
+	if err != nil {
+		return err
 	}
-	log.Printf("connect MySql to %q", dsn)
-	xormEngine, err := xorm.NewEngine("mysql", dsn)
+	authHandler := server.AuthHandler{Usecase: authUsecase, Logger: logger, ClientIPHeader: c.Server.ClientIPHeader}
+	usecase := sample.Usecase{Repository: repo, Cursor: cursor, Transaction: repository.NewTxXorm(xormEngine, c.txOptions()), SearchIndex: index, History: repository.NewHistoryXorm(xormEngine, repository.HistoryTable)}
+	indexed, err := usecase.RebuildIndex(ctx)
 	if err != nil {
 		return err
 	}
-	repo := repository.NewSampleXorm(xormEngine, c.MySQL.Table)
-	usecase := sample.Usecase{Repository: repo}
-	handler := server.InternalSampleHandler{Usecase: usecase, Logger: logger}
+	logger.Info("build search index", "index", c.Search.Index, "samples", indexed)
 	mux := mux.NewRouter()
-	handler.Route(mux)
+	mux.Use(server.RequestID)
+	samples := mux.NewRoute().Subrouter()
+	if !c.Auth.NoRBAC {
+		usecase.Policy = auth.DefaultActorPolicy()
+		samples.Use(authHandler.Authenticate)
+	}
+	handler := server.InternalSampleHandler{Usecase: usecase, Logger: logger, IdempotentDelete: c.Server.IdempotentDelete}
+	handler.Route(samples)
+	if c.Auth.NoRBAC {
+		logger.Warn("auth.no-rbac is set so that anyone can do any operations on samples and the audit log is not exposed")
+	} else {
+		handler.RouteAudit(samples)
+	}
+	authHandler.Route(mux)
+	logger.Info(`expose GET "/debug/vars"`)
+	mux.Handle("/debug/vars", expvar.Handler()).Methods(http.MethodGet)
+	var purger *sample.Purger
+	if c.Purge.Interval > 0 {
+		if purger, err = c.purger(xormEngine, repo, table); err != nil {
+			return err
+		}
+	}
 	addr := net.JoinHostPort(c.Server.Host, c.Server.Port)
 	s := http.Server{
 		Addr:    addr,
@@ -128,6 +398,27 @@ func (c *GoAPICmd) Run(ctx context.Context) error {
 	}()
 	sigCtx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, os.Interrupt, os.Kill)
 	defer stop()
+	scheduler := job.Scheduler{Logger: logger}
+	defer scheduler.Wait()
+	if c.Auth.CleanupInterval > 0 {
+		scheduler.Every(sigCtx, "token-cleanup", c.Auth.CleanupInterval, func(ctx context.Context) error {
+			deleted, err := authUsecase.Tokens.Cleanup(ctx)
+			logger.Info("delete expired tokens", "deleted", deleted)
+			if err != nil || authUsecase.Throttle == nil {
+				return err
+			}
+			deleted, err = authUsecase.Throttle.Cleanup(ctx)
+			logger.Info("delete old login failures", "deleted", deleted)
+			return err
+		})
+	}
+	if purger != nil {
+		scheduler.Every(sigCtx, "purge", c.Purge.Interval, func(ctx context.Context) error {
+			result, err := purger.Purge(ctx)
+			logger.Info("purge deleted samples", "purged", result.Purged, "skipped", result.Skipped, "dry_run", result.DryRun)
+			return err
+		})
+	}
 	select {
 	case err := <-serverChan:
 		log.Fatal(err)
@@ -139,3 +430,354 @@ func (c *GoAPICmd) Run(ctx context.Context) error {
 	}
 	return nil
 }
+
+// RunPurge purges deleted samples once.
+func (c *GoAPICmd) RunPurge(ctx context.Context) error {
+	logger := c.logger()
+	_, xormEngine, table, err := c.engine()
+	if err != nil {
+		return err
+	}
+	purger, err := c.purger(xormEngine, repository.NewSampleXorm(xormEngine, table), table)
+	if err != nil {
+		return err
+	}
+	result, err := purger.Purge(ctx)
+	if err != nil {
+		return err
+	}
+	logger.Info("purge deleted samples", "purged", result.Purged, "skipped", result.Skipped, "dry_run", result.DryRun)
+	return nil
+}
+
+// RunAPIKey runs the subcommand of `go-api apikey` and writes the result to out.
+// The key is written only by create because only the hash is stored.
+func (c *GoAPICmd) RunAPIKey(ctx context.Context, subcommand string, out io.Writer) error {
+	_, xormEngine, _, err := c.engine()
+	if err != nil {
+		return err
+	}
+	keys := &auth.APIKeyService{Repository: repository.NewAPIKeyXorm(xormEngine, repository.APIKeyTable)}
+	switch subcommand {
+	case "create":
+		actor, err := model.ParseActor(c.APIKey.Actor)
+		if err != nil {
+			return err
+		}
+		var scopes []auth.Scope
+		for _, s := range strings.Split(c.APIKey.Scopes, ",") {
+			scopes = append(scopes, auth.Scope(strings.TrimSpace(s)))
+		}
+		key, created, err := keys.Create(ctx, auth.CreateAPIKeyQuery{Name: c.APIKey.Name, Actor: actor, Scopes: scopes})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "ID:  %s\nKey: %s\nThe key is shown only once.\n", created.ID, key)
+		return nil
+	case "list":
+		list, err := keys.List(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tPREFIX\tNAME\tACTOR\tSCOPES\tCREATED\tREVOKED")
+		for _, k := range list {
+			scopes := make([]string, len(k.Scopes))
+			for i, s := range k.Scopes {
+				scopes[i] = string(s)
+			}
+			revoked := "-"
+			if k.RevokedAt != nil {
+				revoked = k.RevokedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%v\t%s\t%s\t%s\n", k.ID, k.Prefix, k.Name, k.Actor, strings.Join(scopes, ","), k.CreatedAt.Format(time.RFC3339), revoked)
+		}
+		return w.Flush()
+	case "revoke":
+		id, err := uuid.Parse(c.APIKey.ID)
+		if err != nil {
+			return fmt.Errorf("invalid apikey.id: %w", err)
+		}
+		return keys.Revoke(ctx, id)
+	default:
+		return fmt.Errorf("unknown subcommand apikey %q", subcommand)
+	}
+}
+
+func (c *GoAPICmd) logger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: c.Log.Level.Level,
+	},
+	))
+}
+
+// engine connects to the database selected by DB.Driver and returns the driver name, the engine and the table name.
+func (c *GoAPICmd) engine() (string, *xorm.Engine, string, error) {
+	driver, dsn, table, err := c.dataSource()
+	if err != nil {
+		return "", nil, "", err
+	}
+	xormEngine, err := xorm.NewEngine(driver, dsn)
+	if err != nil {
+		return "", nil, "", err
+	}
+	return driver, xormEngine, table, nil
+}
+
+func (c *GoAPICmd) authUsecase(logger *slog.Logger, e *xorm.Engine) (auth.Usecase, error) {
+	var (
+		users    auth.UserRepository
+		tokens   auth.TokenRepository
+		apiKeys  auth.APIKeyRepository
+		attempts auth.LoginAttemptRepository
+		tx       transaction.Manager
+	)
+	switch c.Auth.Store {
+	case "", AuthStoreDB:
+		users, tokens, tx = repository.NewUserXorm(e, repository.UserTable), repository.NewTokenXorm(e, repository.TokenTable), repository.NewTxXorm(e, c.txOptions())
+		apiKeys, attempts = repository.NewAPIKeyXorm(e, repository.APIKeyTable), repository.NewLoginAttemptXorm(e, repository.LoginAttemptTable)
+	case AuthStoreMemory:
+		users, tokens, apiKeys = repository.NewUserMemory(), repository.NewTokenMemory(), repository.NewAPIKeyMemory()
+		attempts = repository.NewLoginAttemptMemory()
+	default:
+		return auth.Usecase{}, fmt.Errorf("unsupported auth.store %q", c.Auth.Store)
+	}
+	signer, err := c.jwt(logger)
+	if err != nil {
+		return auth.Usecase{}, err
+	}
+	notifier, err := c.notifier(logger)
+	if err != nil {
+		return auth.Usecase{}, err
+	}
+	external, err := c.externalVerifier()
+	if err != nil {
+		return auth.Usecase{}, err
+	}
+	var throttle *auth.LoginThrottle
+	if o := c.Auth.Lockout; o.UserFailures > 0 || o.IPFailures > 0 {
+		throttle = &auth.LoginThrottle{
+			Repository:      attempts,
+			Auditor:         audit.Log{Logger: logger},
+			MaxUserFailures: o.UserFailures,
+			MaxIPFailures:   o.IPFailures,
+			Window:          o.Window,
+			BaseLockout:     o.Base,
+			MaxLockout:      o.Max,
+		}
+	} else {
+		logger.Warn("auth.lockout is disabled so that passwords can be brute-forced")
+	}
+	return auth.Usecase{
+		Repository:  users,
+		Throttle:    throttle,
+		Notifier:    notifier,
+		ResetTTL:    c.Auth.ResetTTL,
+		Transaction: tx,
+		Tokens: &auth.TokenService{
+			Repository:  tokens,
+			Users:       users,
+			JWT:         signer,
+			Transaction: tx,
+			AccessTTL:   c.Auth.TokenTTL,
+			RefreshTTL:  c.Auth.RefreshTTL,
+		},
+		APIKeys:  &auth.APIKeyService{Repository: apiKeys},
+		External: external,
+	}, nil
+}
+
+// externalVerifier returns auth.ExternalVerifier configured by Auth.JWKS, or nil if the source is empty.
+func (c *GoAPICmd) externalVerifier() (*auth.ExternalVerifier, error) {
+	o := c.Auth.JWKS
+	if o.Source == "" {
+		return nil, nil
+	}
+	if o.Issuer == "" || o.Audience == "" {
+		return nil, errors.New("auth.jwks.issuer and auth.jwks.audience are required with auth.jwks.source")
+	}
+	if o.Issuer == c.Auth.JWT.Issuer {
+		return nil, fmt.Errorf("auth.jwks.issuer must be different from auth.jwt.issuer %q", c.Auth.JWT.Issuer)
+	}
+	actors := map[string]model.Actor{}
+	if o.Actors != "" {
+		for _, pair := range strings.Split(o.Actors, ",") {
+			value, name, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok {
+				return nil, fmt.Errorf("auth.jwks.actors: %q is not value=ACTOR", pair)
+			}
+			actor, err := model.ParseActor(name)
+			if err != nil {
+				return nil, fmt.Errorf("auth.jwks.actors: %w", err)
+			}
+			actors[value] = actor
+		}
+	}
+	return &auth.ExternalVerifier{
+		Keys:          &auth.KeySet{Source: jwks.New(o.Source), RefreshInterval: o.RefreshInterval},
+		Issuer:        o.Issuer,
+		Audience:      o.Audience,
+		UserNameClaim: o.UserNameClaim,
+		ActorClaim:    o.ActorClaim,
+		Actors:        actors,
+	}, nil
+}
+
+func (c *GoAPICmd) notifier(logger *slog.Logger) (auth.Notifier, error) {
+	switch c.Auth.Notifier {
+	case "":
+		return nil, nil
+	case NotifierLog:
+		logger.Warn("password reset codes are logged, which must not be used in production")
+		return notify.Log{Logger: logger}, nil
+	case NotifierFile:
+		return &notify.File{Path: c.Auth.NotifierFile}, nil
+	default:
+		return nil, fmt.Errorf("unsupported auth.notifier %q", c.Auth.Notifier)
+	}
+}
+
+func (c *GoAPICmd) jwt(logger *slog.Logger) (*auth.JWT, error) {
+	switch c.Auth.JWT.Alg {
+	case "", JWTAlgHS256:
+		if c.Auth.JWT.Secret == "" {
+			logger.Warn("auth.jwt.secret is empty so that a random secret is used")
+		}
+		return auth.NewHS256([]byte(c.Auth.JWT.Secret), c.Auth.JWT.Issuer)
+	case JWTAlgEdDSA:
+		b, err := os.ReadFile(c.Auth.JWT.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read auth.jwt.key-file: %w", err)
+		}
+		key, err := jwt.ParseEdPrivateKeyFromPEM(b)
+		if err != nil {
+			return nil, fmt.Errorf("parse auth.jwt.key-file: %w", err)
+		}
+		return auth.NewEdDSA(key.(ed25519.PrivateKey), c.Auth.JWT.Issuer), nil
+	default:
+		return nil, fmt.Errorf("unsupported auth.jwt.alg %q", c.Auth.JWT.Alg)
+	}
+}
+
+// purger returns sample.Purger configured by Purge, which is locked among replicas by the advisory lock of the database.
+// It returns an error if Purge.Retention is not positive so that misconfiguration is reported on startup.
+func (c *GoAPICmd) purger(e *xorm.Engine, repo sample.PurgeRepository, table string) (*sample.Purger, error) {
+	if c.Purge.Retention <= 0 {
+		return nil, fmt.Errorf("purge.retention must be positive: %s", c.Purge.Retention)
+	}
+	return &sample.Purger{
+		Repository: repo,
+		Locker:     repository.NewLockXorm(e),
+		LockName:   "go-api.purge." + table,
+		Retention:  c.Purge.Retention,
+		BatchSize:  c.Purge.BatchSize,
+		DryRun:     c.Purge.DryRun,
+	}, nil
+}
+
+// searchIndex returns the full-text search index selected by Search.Index, or nil if full-text search is disabled.
+func (c *GoAPICmd) searchIndex(logger *slog.Logger, driver string, e *xorm.Engine, table string) (sample.SearchIndex, error) {
+	switch c.Search.Index {
+	case "":
+		if driver != DriverMySQL {
+			logger.Info("full-text search is disabled without search.index", "driver", driver)
+			return nil, nil
+		}
+		return repository.NewSampleFulltextXorm(e, table), nil
+	case SearchIndexMemory:
+		logger.Warn("search.index=memory misses samples changed by the other processes, so run only one process with it")
+		return search.NewInvertedIndex(), nil
+	case SearchIndexFulltext:
+		if driver != DriverMySQL {
+			return nil, fmt.Errorf("search.index %q is unsupported on %s", c.Search.Index, driver)
+		}
+		return repository.NewSampleFulltextXorm(e, table), nil
+	default:
+		return nil, fmt.Errorf("unsupported search.index %q", c.Search.Index)
+	}
+}
+
+// redactedPassword replaces passwords in data source names written to logs.
+const redactedPassword = "xxxxx"
+
+// redactMySQL returns the data source name of cfg with the password replaced by redactedPassword.
+func redactMySQL(cfg *mysql.Config) string {
+	redacted := cfg.Clone()
+	if redacted.Passwd != "" {
+		redacted.Passwd = redactedPassword
+	}
+	return redacted.FormatDSN()
+}
+
+// keywordPassword matches passwords in data source names of the keyword/value format of lib/pq,
+// which are quoted if they contain spaces.
+var keywordPassword = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S*)`)
+
+// redactPostgres returns dsn of either the URL or keyword/value format with passwords replaced by redactedPassword.
+func redactPostgres(dsn string) (string, error) {
+	if !strings.HasPrefix(dsn, "postgres://") && !strings.HasPrefix(dsn, "postgresql://") {
+		return keywordPassword.ReplaceAllString(dsn, "${1}"+redactedPassword), nil
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", err
+	}
+	if q := u.Query(); q.Has("password") {
+		q.Set("password", redactedPassword)
+		u.RawQuery = q.Encode()
+	}
+	return u.Redacted(), nil
+}
+
+// txOptions returns the options of transactions with DB.Isolation, which is validated by dataSource.
+func (c *GoAPICmd) txOptions() repository.TxOptions {
+	return repository.TxOptions{Isolation: isolationLevels[c.DB.Isolation]}
+}
+
+// dataSource returns the driver name, data source name and table name of the database selected by DB.Driver.
+// An empty driver is treated as MySQL. mysql.dsn and postgres.dsn are passed to the driver as is,
+// and the data source name is built from the other flags only if they are empty.
+func (c *GoAPICmd) dataSource() (driver, dsn, table string, err error) {
+	if _, ok := isolationLevels[c.DB.Isolation]; c.DB.Isolation != "" && !ok {
+		return "", "", "", fmt.Errorf("unsupported db.isolation %q", c.DB.Isolation)
+	}
+	switch c.DB.Driver {
+	case "", DriverMySQL:
+		dsn = c.MySQL.DSN
+		if dsn == "" {
+			dsn = (&mysql.Config{
+				User:   c.MySQL.User,
+				Passwd: c.MySQL.Password,
+				Net:    "tcp",
+				Addr:   c.MySQL.Addr,
+				DBName: c.MySQL.Database,
+			}).FormatDSN()
+		}
+		// the configuration is parsed only to redact the password in the log.
+		var cfg *mysql.Config
+		if cfg, err = mysql.ParseDSN(dsn); err != nil {
+			return "", "", "", fmt.Errorf("parse mysql.dsn: %w", err)
+		}
+		log.Printf("connect MySql to %q", redactMySQL(cfg))
+		return DriverMySQL, dsn, c.MySQL.Table, nil
+	case DriverPostgres:
+		dsn = c.Postgres.DSN
+		if dsn == "" {
+			dsn = (&url.URL{
+				Scheme:   "postgres",
+				User:     url.UserPassword(c.Postgres.User, c.Postgres.Password),
+				Host:     c.Postgres.Addr,
+				Path:     c.Postgres.Database,
+				RawQuery: url.Values{"sslmode": []string{c.Postgres.SSLMode}}.Encode(),
+			}).String()
+		}
+		var redacted string
+		if redacted, err = redactPostgres(dsn); err != nil {
+			return "", "", "", fmt.Errorf("parse postgres.dsn: %w", err)
+		}
+		log.Printf("connect PostgreSQL to %q", redacted)
+		return DriverPostgres, dsn, c.Postgres.Table, nil
+	default:
+		return "", "", "", fmt.Errorf("unsupported db.driver %q", c.DB.Driver)
+	}
+}
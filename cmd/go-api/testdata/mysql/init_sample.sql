@@ -1,14 +1,23 @@
 DROP TABLE IF EXISTS `SAMPLE`;
+-- bigrams containing stopwords such as "a" are not indexed unless stopwords are disabled on creating the index.
+SET SESSION innodb_ft_enable_stopword = OFF;
 CREATE TABLE IF NOT EXISTS `SAMPLE`
 (
-    `ID`          CHAR(36)     NOT NULL PRIMARY KEY,
-    `NAME`        VARCHAR(400) NOT NULL,
-    `BIRTHDAY`    TIMESTAMP    NOT NULL,
-    `IS_JAPANESE` BOOLEAN      NOT NULL,
-    `CREATED_AT`  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
-    `UPDATED_AT`  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-    `IS_DELETED`  BOOLEAN      NOT NULL DEFAULT FALSE,
-    `DELETED_AT`  TIMESTAMP    NULL
+    `ID`              CHAR(36)     NOT NULL PRIMARY KEY,
+    `NAME`            VARCHAR(400) NOT NULL,
+    `NAME_NORMALIZED` VARCHAR(400) NULL,
+    `BIRTHDAY`        TIMESTAMP    NOT NULL,
+    `IS_JAPANESE`     BOOLEAN      NOT NULL,
+    `VERSION`         INT          NOT NULL DEFAULT 1,
+    `CREATED_AT`      TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `UPDATED_AT`      TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `IS_DELETED`      BOOLEAN      NOT NULL DEFAULT FALSE,
+    `DELETED_AT`      TIMESTAMP    NULL,
+    `OWNER_ID`        CHAR(36)     NULL,
+    `CREATED_BY`      CHAR(36)     NULL,
+    `UPDATED_BY`      CHAR(36)     NULL,
+    INDEX `IDX_SAMPLE_OWNER_ID` (`OWNER_ID`),
+    FULLTEXT INDEX `FT_SAMPLE_NAME_NORMALIZED` (`NAME_NORMALIZED`) WITH PARSER ngram
 );
 
 INSERT INTO SAMPLE(ID, NAME, BIRTHDAY, IS_JAPANESE, CREATED_AT, UPDATED_AT, IS_DELETED, DELETED_AT)
@@ -18,3 +27,69 @@ VALUES ('00000000-0000-0000-0000-000000000000', 'test-japanese',         '1994-0
        ('00000000-0000-0000-0000-000000000003', 'test-foreiner',         '1994-11-08', false, '2003-06-14', '2004-06-14', false, null),
        ('00000000-0000-0000-0000-000000000004', 'test-ninja',            '1994-12-12', true,  '2003-06-14', '2004-06-14', false, null);
 
+DROP TABLE IF EXISTS `USERS`;
+CREATE TABLE IF NOT EXISTS `USERS`
+(
+    `ID`               CHAR(36)     NOT NULL PRIMARY KEY,
+    `USER_NAME`        VARCHAR(100) NOT NULL UNIQUE,
+    `ENCRYPT_PASSWORD` VARCHAR(255) NOT NULL,
+    `ACTOR`            INT          NOT NULL,
+    `RESET_CODE`       CHAR(64)     NULL,
+    `RESET_UNTIL`      TIMESTAMP    NULL,
+    `CREATED_AT`       TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `UPDATED_AT`       TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+DROP TABLE IF EXISTS `TOKENS`;
+CREATE TABLE IF NOT EXISTS `TOKENS`
+(
+    `ID`                    CHAR(36)  NOT NULL PRIMARY KEY,
+    `FAMILY_ID`             CHAR(36)  NOT NULL,
+    `USER_ID`               CHAR(36)  NOT NULL,
+    `ENCRYPT_REFRESH_TOKEN` CHAR(64)  NOT NULL UNIQUE,
+    `EXPIRES_AT`            TIMESTAMP NOT NULL,
+    `ROTATED_AT`            TIMESTAMP NULL,
+    `REVOKED_AT`            TIMESTAMP NULL,
+    `CREATED_AT`            TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX `IDX_TOKENS_FAMILY_ID` (`FAMILY_ID`),
+    INDEX `IDX_TOKENS_EXPIRES_AT` (`EXPIRES_AT`)
+);
+
+DROP TABLE IF EXISTS `API_KEYS`;
+CREATE TABLE IF NOT EXISTS `API_KEYS`
+(
+    `ID`          CHAR(36)     NOT NULL PRIMARY KEY,
+    `NAME`        VARCHAR(100) NOT NULL,
+    `PREFIX`      CHAR(12)     NOT NULL UNIQUE,
+    `ENCRYPT_KEY` CHAR(64)     NOT NULL,
+    `ACTOR`       INT          NOT NULL,
+    `SCOPES`      VARCHAR(255) NOT NULL,
+    `CREATED_AT`  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `REVOKED_AT`  TIMESTAMP    NULL
+);
+
+DROP TABLE IF EXISTS `LOGIN_ATTEMPTS`;
+CREATE TABLE IF NOT EXISTS `LOGIN_ATTEMPTS`
+(
+    `SUBJECT`      VARCHAR(128) NOT NULL PRIMARY KEY,
+    `FAILURES`     INT          NOT NULL,
+    `LOCKOUTS`     INT          NOT NULL,
+    `LOCKED_UNTIL` TIMESTAMP    NULL,
+    `UPDATED_AT`   TIMESTAMP    NOT NULL,
+    INDEX `IDX_LOGIN_ATTEMPTS_UPDATED_AT` (`UPDATED_AT`)
+);
+
+DROP TABLE IF EXISTS `SAMPLE_HISTORY`;
+CREATE TABLE IF NOT EXISTS `SAMPLE_HISTORY`
+(
+    `ID`         CHAR(36)     NOT NULL PRIMARY KEY,
+    `SAMPLE_ID`  CHAR(36)     NOT NULL,
+    `ACTION`     VARCHAR(32)  NOT NULL,
+    `ACTOR_ID`   CHAR(36)     NULL,
+    `ACTOR`      VARCHAR(128) NOT NULL,
+    `REQUEST_ID` VARCHAR(128) NOT NULL,
+    `AT`         TIMESTAMP(6) NOT NULL,
+    `CHANGES`    TEXT         NOT NULL,
+    INDEX `IDX_SAMPLE_HISTORY_SAMPLE_ID` (`SAMPLE_ID`, `AT`),
+    INDEX `IDX_SAMPLE_HISTORY_AT` (`AT`)
+);
@@ -5,18 +5,22 @@ go 1.21.5
 require github.com/google/uuid v1.5.0
 
 require (
+	github.com/evanphx/json-patch/v5 v5.9.0
 	github.com/go-sql-driver/mysql v1.7.1
+	github.com/golang-jwt/jwt/v5 v5.2.0
 	github.com/gorilla/mux v1.8.1
+	github.com/lib/pq v1.10.9
 	github.com/stretchr/testify v1.8.4
 	github.com/testcontainers/testcontainers-go v0.26.0
 	github.com/testcontainers/testcontainers-go/modules/mysql v0.26.0
+	golang.org/x/crypto v0.14.0
+	golang.org/x/text v0.13.0
 	xorm.io/xorm v1.3.4
 )
 
 require (
 	dario.cat/mergo v1.0.0 // indirect
 	github.com/Azure/go-ansiterm v0.0.0-20210617225240-d185dfc1b5a1 // indirect
-	github.com/DATA-DOG/go-sqlmock v1.5.1 // indirect
 	github.com/Microsoft/go-winio v0.6.1 // indirect
 	github.com/Microsoft/hcsshim v0.11.1 // indirect
 	github.com/cenkalti/backoff/v4 v4.2.1 // indirect
@@ -0,0 +1,35 @@
+// Package audit implements auth.Auditor.
+package audit
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/auth"
+)
+
+// Log logs audit events at WARN level with "audit" message so that they can be collected by the log pipeline.
+type Log struct {
+	Logger *slog.Logger
+}
+
+var _ auth.Auditor = Log{}
+
+// Audit implements auth.Auditor.
+func (a Log) Audit(ctx context.Context, e auth.AuditEvent) error {
+	attrs := []interface{}{slog.String("event", e.Type), slog.Time("at", e.At)}
+	if e.UserName != "" {
+		attrs = append(attrs, slog.String("user_name", e.UserName))
+	}
+	if e.IP != "" {
+		attrs = append(attrs, slog.String("ip", e.IP))
+	}
+	if e.By != "" {
+		attrs = append(attrs, slog.String("by", e.By))
+	}
+	if e.Until != nil {
+		attrs = append(attrs, slog.Time("until", *e.Until))
+	}
+	a.Logger.WarnContext(ctx, "audit", attrs...)
+	return nil
+}
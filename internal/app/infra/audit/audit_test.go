@@ -0,0 +1,32 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_Audit(t *testing.T) {
+	var buf bytes.Buffer
+	a := Log{Logger: slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	}))}
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := at.Add(time.Minute)
+
+	assert.NoError(t, a.Audit(context.Background(), auth.AuditEvent{Type: auth.AuditLoginLocked, UserName: "kawamura", Until: &until, At: at}))
+	assert.NoError(t, a.Audit(context.Background(), auth.AuditEvent{Type: auth.AuditLoginUnlocked, IP: "192.0.2.1", By: "admin", At: at}))
+
+	assert.Equal(t, "level=WARN msg=audit event=login.locked at=2024-01-01T00:00:00.000Z user_name=kawamura until=2024-01-01T00:01:00.000Z\n"+
+		"level=WARN msg=audit event=login.unlocked at=2024-01-01T00:00:00.000Z ip=192.0.2.1 by=admin\n", buf.String())
+}
@@ -0,0 +1,75 @@
+// Package jwks implements auth.KeySource to load JSON Web Key Sets of external identity providers.
+package jwks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/auth"
+)
+
+// maxKeySetSize limits the size of key sets so that a broken source cannot exhaust memory.
+const maxKeySetSize = 1 << 20
+
+// File reads the key set at Path, which is replaced to rotate keys.
+type File struct {
+	Path string
+}
+
+var _ auth.KeySource = File{}
+
+// FetchKeySet implements auth.KeySource.
+func (s File) FetchKeySet(context.Context) ([]byte, error) {
+	b, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", s.Path, err)
+	}
+	return b, nil
+}
+
+// URL gets the key set from URL, such as jwks_uri of OpenID Connect discovery.
+type URL struct {
+	URL string
+	// Client gets the key set. http.DefaultClient is used if it is nil.
+	Client *http.Client
+}
+
+var _ auth.KeySource = URL{}
+
+// FetchKeySet implements auth.KeySource.
+func (s URL) FetchKeySet(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %w", s.URL, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %w", s.URL, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get %s: %s", s.URL, res.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(res.Body, maxKeySetSize))
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %w", s.URL, err)
+	}
+	return b, nil
+}
+
+// New returns URL if source is an http or https URL, and File otherwise.
+func New(source string) auth.KeySource {
+	if strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://") {
+		return URL{URL: source}
+	}
+	return File{Path: source}
+}
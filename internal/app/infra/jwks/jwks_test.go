@@ -0,0 +1,44 @@
+package jwks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const keySet = `{"keys":[]}`
+
+func TestFile_FetchKeySet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(t, os.WriteFile(path, []byte(keySet), 0o600))
+
+	got, err := New(path).FetchKeySet(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, keySet, string(got))
+
+	_, err = File{Path: filepath.Join(t.TempDir(), "missing.json")}.FetchKeySet(context.Background())
+	assert.Error(t, err)
+}
+
+func TestURL_FetchKeySet(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/jwks.json" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(keySet))
+	}))
+	defer srv.Close()
+
+	got, err := New(srv.URL + "/.well-known/jwks.json").FetchKeySet(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, keySet, string(got))
+
+	_, err = URL{URL: srv.URL + "/missing"}.FetchKeySet(context.Background())
+	assert.ErrorContains(t, err, "404")
+}
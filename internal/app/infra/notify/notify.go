@@ -0,0 +1,62 @@
+// Package notify implements auth.Notifier for local development instead of sending emails.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/auth"
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+)
+
+// Log logs reset codes, which must not be used in production because anyone reading logs can reset passwords.
+type Log struct {
+	Logger *slog.Logger
+}
+
+var _ auth.Notifier = Log{}
+
+// NotifyResetCode implements auth.Notifier.
+func (n Log) NotifyResetCode(ctx context.Context, user *model.User, code string, until time.Time) error {
+	n.Logger.InfoContext(ctx, "password reset requested", "user_name", user.UserName(), "code", code, "until", until)
+	return nil
+}
+
+// ResetCodeMessage is a line of File.
+type ResetCodeMessage struct {
+	UserName string    `json:"user_name"`
+	Code     string    `json:"code"`
+	Until    time.Time `json:"until"`
+}
+
+// File appends reset codes to the file at Path as JSON lines, which works as a mailbox.
+type File struct {
+	Path string
+	mu   sync.Mutex
+}
+
+var _ auth.Notifier = (*File)(nil)
+
+// NotifyResetCode implements auth.Notifier.
+func (n *File) NotifyResetCode(ctx context.Context, user *model.User, code string, until time.Time) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	f, err := os.OpenFile(n.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", n.Path, err)
+	}
+	defer f.Close()
+	if err := json.NewEncoder(f).Encode(ResetCodeMessage{
+		UserName: user.UserName(),
+		Code:     code,
+		Until:    until,
+	}); err != nil {
+		return fmt.Errorf("write %s: %w", n.Path, err)
+	}
+	return nil
+}
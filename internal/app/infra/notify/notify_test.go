@@ -0,0 +1,29 @@
+package notify
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFile_NotifyResetCode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mailbox.jsonl")
+	n := &File{Path: path}
+	user, err := model.NewUser(uuid.New(), "kawamura", "hash", model.ActorUser, nil, nil, nil)
+	assert.NoError(t, err)
+	until := time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC)
+
+	assert.NoError(t, n.NotifyResetCode(context.Background(), user, "code1", until))
+	assert.NoError(t, n.NotifyResetCode(context.Background(), user, "code2", until))
+
+	got, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"user_name":"kawamura","code":"code1","until":"2024-01-01T00:30:00Z"}`+"\n"+
+		`{"user_name":"kawamura","code":"code2","until":"2024-01-01T00:30:00Z"}`+"\n", string(got))
+}
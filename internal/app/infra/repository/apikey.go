@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/auth"
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+	"github.com/google/uuid"
+	"xorm.io/xorm"
+)
+
+const APIKeyTable = "API_KEYS"
+
+type APIKeyRow struct {
+	ID         string `xorm:"pk notnull 'ID'"`
+	Name       string `xorm:"notnull 'NAME'"`
+	Prefix     string `xorm:"notnull unique 'PREFIX'"`
+	EncryptKey string `xorm:"notnull 'ENCRYPT_KEY'"`
+	Actor      int    `xorm:"notnull 'ACTOR'"`
+	// Scopes are separated by spaces as well as OAuth 2.0.
+	Scopes    string     `xorm:"notnull 'SCOPES'"`
+	CreatedAt time.Time  `xorm:"notnull 'CREATED_AT'"`
+	RevokedAt *time.Time `xorm:"null 'REVOKED_AT'"`
+}
+
+func newAPIKeyRow(k *auth.APIKey) *APIKeyRow {
+	scopes := make([]string, len(k.Scopes))
+	for i, s := range k.Scopes {
+		scopes[i] = string(s)
+	}
+	return &APIKeyRow{
+		ID:         k.ID.String(),
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		EncryptKey: k.Hash,
+		Actor:      int(k.Actor),
+		Scopes:     strings.Join(scopes, " "),
+		CreatedAt:  k.CreatedAt,
+		RevokedAt:  k.RevokedAt,
+	}
+}
+
+func (r APIKeyRow) toAPIKey() (*auth.APIKey, error) {
+	id, err := uuid.Parse(r.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id: %w", err)
+	}
+	var scopes []auth.Scope
+	for _, s := range strings.Fields(r.Scopes) {
+		scopes = append(scopes, auth.Scope(s))
+	}
+	return &auth.APIKey{
+		ID:        id,
+		Name:      r.Name,
+		Prefix:    r.Prefix,
+		Hash:      r.EncryptKey,
+		Actor:     model.Actor(r.Actor),
+		Scopes:    scopes,
+		CreatedAt: r.CreatedAt,
+		RevokedAt: r.RevokedAt,
+	}, nil
+}
+
+// APIKeyXorm is an auth.APIKeyRepository backed by xorm.
+type APIKeyXorm struct {
+	e     *xorm.Engine
+	table string
+}
+
+func NewAPIKeyXorm(e *xorm.Engine, table string) *APIKeyXorm {
+	return &APIKeyXorm{
+		e:     e,
+		table: table,
+	}
+}
+
+var _ (auth.APIKeyRepository) = (*APIKeyXorm)(nil)
+
+// Insert implements auth.APIKeyRepository.
+func (r *APIKeyXorm) Insert(ctx context.Context, k *auth.APIKey) error {
+	if _, err := session(ctx, r.e).Table(r.table).Insert(newAPIKeyRow(k)); err != nil {
+		return fmt.Errorf("insert %s: %w", k.ID, err)
+	}
+	return nil
+}
+
+// FindByPrefix implements auth.APIKeyRepository.
+func (r *APIKeyXorm) FindByPrefix(ctx context.Context, prefix string) (*auth.APIKey, error) {
+	row := APIKeyRow{}
+	ok, err := session(ctx, r.e).Table(r.table).Where("`PREFIX` = ?", prefix).Get(&row)
+	if err != nil {
+		return nil, fmt.Errorf("find by prefix %s: %w", prefix, err)
+	}
+	if !ok {
+		return nil, auth.ErrNotFound
+	}
+	return row.toAPIKey()
+}
+
+// List implements auth.APIKeyRepository.
+func (r *APIKeyXorm) List(ctx context.Context) ([]auth.APIKey, error) {
+	var rows []APIKeyRow
+	if err := session(ctx, r.e).Table(r.table).OrderBy("`CREATED_AT`, `ID`").Find(&rows); err != nil {
+		return nil, fmt.Errorf("list: %w", err)
+	}
+	keys := make([]auth.APIKey, 0, len(rows))
+	for _, row := range rows {
+		k, err := row.toAPIKey()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *k)
+	}
+	return keys, nil
+}
+
+// Revoke implements auth.APIKeyRepository.
+func (r *APIKeyXorm) Revoke(ctx context.Context, id uuid.UUID, at time.Time) error {
+	affected, err := session(ctx, r.e).Table(r.table).ID(id.String()).
+		Where("`REVOKED_AT` IS NULL").
+		Cols("REVOKED_AT").
+		Update(&APIKeyRow{RevokedAt: &at})
+	if err != nil {
+		return fmt.Errorf("revoke %s: %w", id, err)
+	}
+	if affected == 0 {
+		return auth.ErrNotFound
+	}
+	return nil
+}
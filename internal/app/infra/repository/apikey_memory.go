@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/auth"
+	"github.com/google/uuid"
+)
+
+// APIKeyMemory is an auth.APIKeyRepository in memory, which is lost on restart and not shared among replicas.
+type APIKeyMemory struct {
+	mu   sync.Mutex
+	keys map[uuid.UUID]auth.APIKey
+}
+
+func NewAPIKeyMemory() *APIKeyMemory {
+	return &APIKeyMemory{keys: map[uuid.UUID]auth.APIKey{}}
+}
+
+var _ (auth.APIKeyRepository) = (*APIKeyMemory)(nil)
+
+// Insert implements auth.APIKeyRepository.
+func (r *APIKeyMemory) Insert(ctx context.Context, k *auth.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys[k.ID] = *k
+	return nil
+}
+
+// FindByPrefix implements auth.APIKeyRepository.
+func (r *APIKeyMemory) FindByPrefix(ctx context.Context, prefix string) (*auth.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, k := range r.keys {
+		if k.Prefix == prefix {
+			return &k, nil
+		}
+	}
+	return nil, auth.ErrNotFound
+}
+
+// List implements auth.APIKeyRepository.
+func (r *APIKeyMemory) List(ctx context.Context) ([]auth.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keys := make([]auth.APIKey, 0, len(r.keys))
+	for _, k := range r.keys {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].ID.String() < keys[j].ID.String()
+	})
+	return keys, nil
+}
+
+// Revoke implements auth.APIKeyRepository.
+func (r *APIKeyMemory) Revoke(ctx context.Context, id uuid.UUID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k, ok := r.keys[id]
+	if !ok || k.RevokedAt != nil {
+		return auth.ErrNotFound
+	}
+	k.RevokedAt = &at
+	r.keys[id] = k
+	return nil
+}
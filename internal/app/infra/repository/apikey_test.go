@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/auth"
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyXorm(t *testing.T) {
+	ctx := context.Background()
+	e := setupEngine(ctx, t)
+	testAPIKeyRepository(ctx, t, NewAPIKeyXorm(e, APIKeyTable))
+}
+
+func TestAPIKeyMemory(t *testing.T) {
+	testAPIKeyRepository(context.Background(), t, NewAPIKeyMemory())
+}
+
+// testAPIKeyRepository tests repo which has no API keys.
+func testAPIKeyRepository(ctx context.Context, t *testing.T, repo auth.APIKeyRepository) {
+	var (
+		now   = time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+		batch = &auth.APIKey{
+			ID: uuid.MustParse("00000000-0000-0000-0000-000000000300"), Name: "batch", Prefix: "gak_00000000", Hash: auth.HashToken("batch"),
+			Actor: model.ActorSystem, Scopes: []auth.Scope{auth.ScopeSamplesRead, auth.ScopeSamplesWrite}, CreatedAt: now,
+		}
+		report = &auth.APIKey{
+			ID: uuid.MustParse("00000000-0000-0000-0000-000000000301"), Name: "report", Prefix: "gak_00000001", Hash: auth.HashToken("report"),
+			Actor: model.ActorManager, Scopes: []auth.Scope{auth.ScopeSamplesRead}, CreatedAt: now.Add(time.Hour),
+		}
+	)
+	for _, k := range []*auth.APIKey{report, batch} {
+		assert.NoError(t, repo.Insert(ctx, k))
+	}
+
+	got, err := repo.FindByPrefix(ctx, "gak_00000000")
+	assert.NoError(t, err)
+	assert.Equal(t, batch.ID, got.ID)
+	assert.Equal(t, batch.Name, got.Name)
+	assert.Equal(t, batch.Hash, got.Hash)
+	assert.Equal(t, model.ActorSystem, got.Actor)
+	assert.Equal(t, batch.Scopes, got.Scopes)
+	assert.Nil(t, got.RevokedAt)
+	_, err = repo.FindByPrefix(ctx, "gak_ffffffff")
+	assert.Equal(t, auth.ErrNotFound, err)
+
+	keys, err := repo.List(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, keys, 2) {
+		assert.Equal(t, batch.ID, keys[0].ID)
+		assert.Equal(t, report.ID, keys[1].ID)
+	}
+
+	assert.NoError(t, repo.Revoke(ctx, report.ID, now))
+	got, err = repo.FindByPrefix(ctx, "gak_00000001")
+	assert.NoError(t, err)
+	assert.NotNil(t, got.RevokedAt)
+	assert.Equal(t, auth.ErrNotFound, repo.Revoke(ctx, report.ID, now))
+	assert.Equal(t, auth.ErrNotFound, repo.Revoke(ctx, uuid.New(), now))
+}
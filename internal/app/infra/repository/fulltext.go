@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/sample"
+	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
+	"github.com/google/uuid"
+	"xorm.io/xorm"
+)
+
+// SampleFulltextXorm is a sample.SearchIndex backed by the FULLTEXT index of NAME_NORMALIZED with the ngram parser on MySQL.
+// The index is maintained by MySQL so that Put and Remove do nothing.
+type SampleFulltextXorm struct {
+	e     *xorm.Engine
+	table string
+}
+
+func NewSampleFulltextXorm(e *xorm.Engine, table string) *SampleFulltextXorm {
+	return &SampleFulltextXorm{
+		e:     e,
+		table: table,
+	}
+}
+
+// matchAgainst is the relevance of NAME_NORMALIZED to the normalized query, which is positive if matched.
+const matchAgainst = "MATCH(`NAME_NORMALIZED`) AGAINST (? IN NATURAL LANGUAGE MODE)"
+
+// ScoredSampleRow is a SampleRow with the relevance.
+type ScoredSampleRow struct {
+	SampleRow `xorm:"extends"`
+	Score     float64 `xorm:"'SCORE'"`
+}
+
+// Search implements sample.SearchIndex.
+func (r *SampleFulltextXorm) Search(ctx context.Context, q string, offset, limit int) (*model.ScoredSamples, error) {
+	q = sample.NormalizeName(q)
+	where, args := "`IS_DELETED` = ? AND "+matchAgainst, []interface{}{false, q}
+	if cond, condArgs := ownedCondition(ctx); cond != "" {
+		where, args = where+" AND "+cond, append(args, condArgs...)
+	}
+	rows := []ScoredSampleRow{}
+	if err := session(ctx, r.e).SQL(
+		"SELECT *, "+matchAgainst+" AS `SCORE` FROM `"+r.table+"`"+
+			" WHERE "+where+
+			" ORDER BY `SCORE` DESC, `ID` LIMIT ? OFFSET ?",
+		append(append([]interface{}{q}, args...), limit, offset)...,
+	).Find(&rows); err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+	total, err := session(ctx, r.e).Table(r.table).Where(where, args...).Count(&SampleRow{})
+	if err != nil {
+		return nil, fmt.Errorf("count: %w", err)
+	}
+
+	result := &model.ScoredSamples{Total: int(total), Hits: make([]model.ScoredSample, len(rows))}
+	for i, row := range rows {
+		s, err := row.toSample()
+		if err != nil {
+			return nil, err
+		}
+		result.Hits[i] = model.ScoredSample{Sample: *s, Score: row.Score}
+	}
+	return result, nil
+}
+
+// Put implements sample.SearchIndex.
+func (r *SampleFulltextXorm) Put(ctx context.Context, s *model.Sample) error {
+	return nil
+}
+
+// Remove implements sample.SearchIndex.
+func (r *SampleFulltextXorm) Remove(ctx context.Context, id uuid.UUID) error {
+	return nil
+}
+
+var _ sample.SearchIndex = (*SampleFulltextXorm)(nil)
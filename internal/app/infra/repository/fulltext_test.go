@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampleFulltextXorm_Search(t *testing.T) {
+	if os.Getenv(POSTGRES_DSN_ENV) != "" {
+		t.Skip("FULLTEXT index is available only on MySQL")
+	}
+	ctx := context.Background()
+	e := setupEngine(ctx, t)
+	index := NewSampleFulltextXorm(e, SAMPLE_TABLE)
+	tests := map[string]struct {
+		q    string
+		want []model.Sample
+	}{
+		"match normalized name": {
+			q: "ﾃｽﾄ忍者",
+			want: []model.Sample{
+				{
+					ID:         uuid.MustParse("00000000-0000-0000-0000-000000000007"),
+					Name:       "テスト忍者",
+					Birthday:   time.Date(2000, 2, 2, 0, 0, 0, 0, time.Local),
+					IsJapanese: true,
+					Version:    1,
+				},
+			},
+		},
+		"return empty when only deleted samples found": {
+			q:    "ele",
+			want: []model.Sample{},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := index.Search(ctx, tt.q, 0, 10)
+			assert.NoError(t, err)
+			assert.Equal(t, len(tt.want), got.Total)
+			samples := []model.Sample{}
+			for _, hit := range got.Hits {
+				assert.Positive(t, hit.Score)
+				samples = append(samples, hit.Sample)
+			}
+			assert.Equal(t, tt.want, samples)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/sample"
+	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
+	"github.com/google/uuid"
+	"xorm.io/xorm"
+)
+
+const HistoryTable = "SAMPLE_HISTORY"
+
+type HistoryRow struct {
+	ID       string `xorm:"pk notnull 'ID'"`
+	SampleID string `xorm:"notnull 'SAMPLE_ID'"`
+	Action   string `xorm:"notnull 'ACTION'"`
+	// ActorID is the ID of the user, which is NULL if the actor is not a user.
+	ActorID   *string   `xorm:"null 'ACTOR_ID'"`
+	Actor     string    `xorm:"notnull 'ACTOR'"`
+	RequestID string    `xorm:"notnull 'REQUEST_ID'"`
+	At        time.Time `xorm:"notnull 'AT'"`
+	// Changes is a JSON array of model.Change.
+	Changes string `xorm:"notnull 'CHANGES'"`
+}
+
+func newHistoryRow(event *model.HistoryEvent) (*HistoryRow, error) {
+	changes, err := json.Marshal(event.Changes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid changes: %w", err)
+	}
+	return &HistoryRow{
+		ID:        event.ID.String(),
+		SampleID:  event.SampleID.String(),
+		Action:    string(event.Action),
+		ActorID:   formatUserID(event.ActorID),
+		Actor:     event.Actor,
+		RequestID: event.RequestID,
+		At:        event.At,
+		Changes:   string(changes),
+	}, nil
+}
+
+func (r HistoryRow) toHistoryEvent() (*model.HistoryEvent, error) {
+	id, err := uuid.Parse(r.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id: %w", err)
+	}
+	sampleID, err := uuid.Parse(r.SampleID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sample id: %w", err)
+	}
+	actorID, err := parseUserID(r.ActorID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid actor id: %w", err)
+	}
+	changes := []model.Change{}
+	if err := json.Unmarshal([]byte(r.Changes), &changes); err != nil {
+		return nil, fmt.Errorf("invalid changes: %w", err)
+	}
+	return &model.HistoryEvent{
+		ID:        id,
+		SampleID:  sampleID,
+		Action:    model.Action(r.Action),
+		ActorID:   actorID,
+		Actor:     r.Actor,
+		RequestID: r.RequestID,
+		At:        r.At,
+		Changes:   changes,
+	}, nil
+}
+
+// HistoryXorm is a sample.HistoryRepository backed by xorm.
+type HistoryXorm struct {
+	e     *xorm.Engine
+	table string
+}
+
+func NewHistoryXorm(e *xorm.Engine, table string) *HistoryXorm {
+	return &HistoryXorm{
+		e:     e,
+		table: table,
+	}
+}
+
+var _ (sample.HistoryRepository) = (*HistoryXorm)(nil)
+
+// Insert implements sample.HistoryRepository.
+func (r *HistoryXorm) Insert(ctx context.Context, event *model.HistoryEvent) error {
+	row, err := newHistoryRow(event)
+	if err != nil {
+		return err
+	}
+	if _, err := session(ctx, r.e).Table(r.table).Insert(row); err != nil {
+		return fmt.Errorf("insert history of %s: %w", event.SampleID, err)
+	}
+	return nil
+}
+
+// Search implements sample.HistoryRepository.
+func (r *HistoryXorm) Search(ctx context.Context, q sample.HistoryQuery, offset, limit int) (*model.PagedHistory, error) {
+	s := session(ctx, r.e).Table(r.table)
+	if q.SampleID != nil {
+		s = s.Where("`SAMPLE_ID` = ?", q.SampleID.String())
+	}
+	if q.ActorID != nil {
+		s = s.Where("`ACTOR_ID` = ?", q.ActorID.String())
+	}
+	if q.Action != "" {
+		s = s.Where("`ACTION` = ?", string(q.Action))
+	}
+	if q.RequestID != "" {
+		s = s.Where("`REQUEST_ID` = ?", q.RequestID)
+	}
+	if q.Since != nil {
+		s = s.Where("`AT` >= ?", *q.Since)
+	}
+	if q.Until != nil {
+		s = s.Where("`AT` < ?", *q.Until)
+	}
+	rows := []HistoryRow{}
+	count, err := s.Desc("AT").Asc("ID").
+		Limit(limit, offset).
+		FindAndCount(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("search history: %w", err)
+	}
+	events := make([]model.HistoryEvent, len(rows))
+	for i, row := range rows {
+		event, err := row.toHistoryEvent()
+		if err != nil {
+			return nil, err
+		}
+		events[i] = *event
+	}
+	return &model.PagedHistory{Total: int(count), Events: events}, nil
+}
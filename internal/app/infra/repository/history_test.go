@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/sample"
+	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryXorm(t *testing.T) {
+	ctx := context.Background()
+	e := setupEngine(ctx, t)
+	repo := NewHistoryXorm(e, HistoryTable)
+
+	var (
+		sampleID = uuid.MustParse("00000000-0000-0000-0000-000000000000")
+		actorID  = uuid.MustParse("00000000-0000-0000-0000-000000000101")
+		now      = time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	)
+	events := []model.HistoryEvent{
+		{
+			ID:        uuid.MustParse("00000000-0000-0000-0000-000000000201"),
+			SampleID:  sampleID,
+			Action:    model.ActionCreate,
+			ActorID:   &actorID,
+			Actor:     "user:kawamura",
+			RequestID: "req-1",
+			At:        now,
+			Changes:   []model.Change{{Field: "name", After: json.RawMessage(`"test-japanese"`)}},
+		},
+		{
+			ID:        uuid.MustParse("00000000-0000-0000-0000-000000000202"),
+			SampleID:  sampleID,
+			Action:    model.ActionDelete,
+			Actor:     "apikey:gak_1f3c9a0b",
+			RequestID: "req-2",
+			At:        now.Add(time.Minute),
+			Changes:   []model.Change{},
+		},
+		{
+			ID:        uuid.MustParse("00000000-0000-0000-0000-000000000203"),
+			SampleID:  uuid.MustParse("00000000-0000-0000-0000-000000000003"),
+			Action:    model.ActionEdit,
+			ActorID:   &actorID,
+			Actor:     "user:kawamura",
+			RequestID: "req-3",
+			At:        now.Add(2 * time.Minute),
+			Changes:   []model.Change{{Field: "is_japanese", Before: json.RawMessage(`false`), After: json.RawMessage(`true`)}},
+		},
+	}
+	for i := range events {
+		assert.NoError(t, repo.Insert(ctx, &events[i]))
+	}
+
+	until := now.Add(2 * time.Minute)
+	tests := map[string]struct {
+		query  sample.HistoryQuery
+		offset int
+		limit  int
+		want   []model.HistoryEvent
+		total  int
+	}{
+		"return all events in descending order of time": {
+			limit: 10,
+			want:  []model.HistoryEvent{events[2], events[1], events[0]},
+			total: 3,
+		},
+		"return events of the sample": {
+			query: sample.HistoryQuery{SampleID: &sampleID},
+			limit: 10,
+			want:  []model.HistoryEvent{events[1], events[0]},
+			total: 2,
+		},
+		"return events of the actor in the range": {
+			query: sample.HistoryQuery{ActorID: &actorID, Since: &now, Until: &until},
+			limit: 10,
+			want:  []model.HistoryEvent{events[0]},
+			total: 1,
+		},
+		"return events of the action and request": {
+			query: sample.HistoryQuery{Action: model.ActionDelete, RequestID: "req-2"},
+			limit: 10,
+			want:  []model.HistoryEvent{events[1]},
+			total: 1,
+		},
+		"return a page of events": {
+			offset: 1,
+			limit:  1,
+			want:   []model.HistoryEvent{events[1]},
+			total:  3,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := repo.Search(ctx, tt.query, tt.offset, tt.limit)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.total, got.Total)
+			if assert.Len(t, got.Events, len(tt.want)) {
+				for i := range tt.want {
+					assert.True(t, tt.want[i].At.Equal(got.Events[i].At))
+					got.Events[i].At = tt.want[i].At
+				}
+				assert.Equal(t, tt.want, got.Events)
+			}
+		})
+	}
+}
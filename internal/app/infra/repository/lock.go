@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/lock"
+	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
+)
+
+// LockXorm is a lock.Locker backed by advisory locks of the database,
+// GET_LOCK on MySQL and pg_try_advisory_lock on PostgreSQL.
+// A lock is held by a dedicated connection until unlocked, and released by the database if the connection is lost.
+type LockXorm struct {
+	e *xorm.Engine
+}
+
+func NewLockXorm(e *xorm.Engine) *LockXorm {
+	return &LockXorm{e: e}
+}
+
+// TryLock implements lock.Locker.
+func (l *LockXorm) TryLock(ctx context.Context, name string) (func() error, bool, error) {
+	conn, err := l.e.DB().Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("connect: %w", err)
+	}
+	lockSQL, unlockSQL, arg := "SELECT GET_LOCK(?, 0)", "SELECT RELEASE_LOCK(?)", interface{}(name)
+	if l.e.Dialect().URI().DBType == schemas.POSTGRES {
+		lockSQL, unlockSQL, arg = "SELECT pg_try_advisory_lock($1)", "SELECT pg_advisory_unlock($1)", advisoryKey(name)
+	}
+
+	var ok sql.NullBool
+	if err := conn.QueryRowContext(ctx, lockSQL, arg).Scan(&ok); err != nil {
+		return nil, false, errors.Join(fmt.Errorf("lock %s: %w", name, err), conn.Close())
+	}
+	if !ok.Bool {
+		return nil, false, conn.Close()
+	}
+	unlock := func() error {
+		// the lock is released on a new context because it must be released even if ctx is done.
+		_, err := conn.ExecContext(context.Background(), unlockSQL, arg)
+		return errors.Join(err, conn.Close())
+	}
+	return unlock, true, nil
+}
+
+// advisoryKey returns the key of the advisory lock of PostgreSQL for name.
+func advisoryKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+var _ (lock.Locker) = (*LockXorm)(nil)
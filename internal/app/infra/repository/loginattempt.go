@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/auth"
+	"xorm.io/xorm"
+)
+
+const LoginAttemptTable = "LOGIN_ATTEMPTS"
+
+type LoginAttemptRow struct {
+	Subject     string     `xorm:"pk notnull 'SUBJECT'"`
+	Failures    int        `xorm:"notnull 'FAILURES'"`
+	Lockouts    int        `xorm:"notnull 'LOCKOUTS'"`
+	LockedUntil *time.Time `xorm:"null 'LOCKED_UNTIL'"`
+	UpdatedAt   time.Time  `xorm:"notnull 'UPDATED_AT'"`
+}
+
+func (r LoginAttemptRow) toLoginAttempt() auth.LoginAttempt {
+	return auth.LoginAttempt{
+		Subject:     r.Subject,
+		Failures:    r.Failures,
+		Lockouts:    r.Lockouts,
+		LockedUntil: r.LockedUntil,
+		UpdatedAt:   r.UpdatedAt,
+	}
+}
+
+// LoginAttemptXorm is an auth.LoginAttemptRepository backed by xorm.
+// Counters are updated by single statements so that replicas do not lose failures of each other.
+type LoginAttemptXorm struct {
+	e     *xorm.Engine
+	table string
+}
+
+func NewLoginAttemptXorm(e *xorm.Engine, table string) *LoginAttemptXorm {
+	return &LoginAttemptXorm{
+		e:     e,
+		table: table,
+	}
+}
+
+var _ (auth.LoginAttemptRepository) = (*LoginAttemptXorm)(nil)
+
+// FindBySubjects implements auth.LoginAttemptRepository.
+func (r *LoginAttemptXorm) FindBySubjects(ctx context.Context, subjects []string) ([]auth.LoginAttempt, error) {
+	var rows []LoginAttemptRow
+	if err := session(ctx, r.e).Table(r.table).In("SUBJECT", subjects).Find(&rows); err != nil {
+		return nil, fmt.Errorf("find by subjects: %w", err)
+	}
+	attempts := make([]auth.LoginAttempt, len(rows))
+	for i, row := range rows {
+		attempts[i] = row.toLoginAttempt()
+	}
+	return attempts, nil
+}
+
+// Fail implements auth.LoginAttemptRepository.
+func (r *LoginAttemptXorm) Fail(ctx context.Context, subject string, since, at time.Time) (*auth.LoginAttempt, error) {
+	if _, err := session(ctx, r.e).Table(r.table).
+		Where("`SUBJECT` = ? AND `UPDATED_AT` < ?", subject, since).
+		Cols("FAILURES").
+		Update(&LoginAttemptRow{}); err != nil {
+		return nil, fmt.Errorf("forget failures of %s: %w", subject, err)
+	}
+	incremented, err := r.increment(ctx, subject, at)
+	if err != nil {
+		return nil, err
+	}
+	if !incremented {
+		_, err := session(ctx, r.e).Table(r.table).Insert(&LoginAttemptRow{Subject: subject, Failures: 1, UpdatedAt: at})
+		if isUniqueViolation(err) {
+			// another replica has inserted the subject at the same time.
+			_, err = r.increment(ctx, subject, at)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("insert failure of %s: %w", subject, err)
+		}
+	}
+	row := LoginAttemptRow{}
+	ok, err := session(ctx, r.e).Table(r.table).Where("`SUBJECT` = ?", subject).Get(&row)
+	if err != nil {
+		return nil, fmt.Errorf("find %s: %w", subject, err)
+	}
+	if !ok {
+		return nil, auth.ErrNotFound
+	}
+	attempt := row.toLoginAttempt()
+	return &attempt, nil
+}
+
+func (r *LoginAttemptXorm) increment(ctx context.Context, subject string, at time.Time) (bool, error) {
+	affected, err := session(ctx, r.e).Table(r.table).
+		Where("`SUBJECT` = ?", subject).
+		Incr("FAILURES").
+		Cols("UPDATED_AT").
+		Update(&LoginAttemptRow{UpdatedAt: at})
+	if err != nil {
+		return false, fmt.Errorf("count failure of %s: %w", subject, err)
+	}
+	return affected > 0, nil
+}
+
+// Lock implements auth.LoginAttemptRepository.
+func (r *LoginAttemptXorm) Lock(ctx context.Context, subject string, failures int, until time.Time) (bool, error) {
+	affected, err := session(ctx, r.e).Table(r.table).
+		Where("`SUBJECT` = ? AND `FAILURES` >= ?", subject, failures).
+		Incr("LOCKOUTS").
+		Cols("FAILURES", "LOCKED_UNTIL").
+		Update(&LoginAttemptRow{LockedUntil: &until})
+	if err != nil {
+		return false, fmt.Errorf("lock %s: %w", subject, err)
+	}
+	return affected > 0, nil
+}
+
+// Delete implements auth.LoginAttemptRepository.
+func (r *LoginAttemptXorm) Delete(ctx context.Context, subject string) error {
+	affected, err := session(ctx, r.e).Table(r.table).
+		Where("`SUBJECT` = ?", subject).
+		Delete(&LoginAttemptRow{})
+	if err != nil {
+		return fmt.Errorf("delete %s: %w", subject, err)
+	}
+	if affected == 0 {
+		return auth.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteUpdatedBefore implements auth.LoginAttemptRepository.
+func (r *LoginAttemptXorm) DeleteUpdatedBefore(ctx context.Context, before time.Time) (int, error) {
+	affected, err := session(ctx, r.e).Table(r.table).
+		Where("`UPDATED_AT` < ? AND (`LOCKED_UNTIL` IS NULL OR `LOCKED_UNTIL` < ?)", before, before).
+		Delete(&LoginAttemptRow{})
+	if err != nil {
+		return 0, fmt.Errorf("delete updated before %s: %w", before, err)
+	}
+	return int(affected), nil
+}
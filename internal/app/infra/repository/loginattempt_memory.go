@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/auth"
+)
+
+// LoginAttemptMemory is an auth.LoginAttemptRepository in memory, which is lost on restart and not shared among replicas.
+type LoginAttemptMemory struct {
+	mu       sync.Mutex
+	attempts map[string]auth.LoginAttempt
+}
+
+func NewLoginAttemptMemory() *LoginAttemptMemory {
+	return &LoginAttemptMemory{attempts: map[string]auth.LoginAttempt{}}
+}
+
+var _ (auth.LoginAttemptRepository) = (*LoginAttemptMemory)(nil)
+
+// FindBySubjects implements auth.LoginAttemptRepository.
+func (r *LoginAttemptMemory) FindBySubjects(ctx context.Context, subjects []string) ([]auth.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var attempts []auth.LoginAttempt
+	for _, s := range subjects {
+		if a, ok := r.attempts[s]; ok {
+			attempts = append(attempts, a)
+		}
+	}
+	return attempts, nil
+}
+
+// Fail implements auth.LoginAttemptRepository.
+func (r *LoginAttemptMemory) Fail(ctx context.Context, subject string, since, at time.Time) (*auth.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a, ok := r.attempts[subject]
+	if !ok {
+		a = auth.LoginAttempt{Subject: subject}
+	}
+	if a.UpdatedAt.Before(since) {
+		a.Failures = 0
+	}
+	a.Failures++
+	a.UpdatedAt = at
+	r.attempts[subject] = a
+	return &a, nil
+}
+
+// Lock implements auth.LoginAttemptRepository.
+func (r *LoginAttemptMemory) Lock(ctx context.Context, subject string, failures int, until time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a, ok := r.attempts[subject]
+	if !ok || a.Failures < failures {
+		return false, nil
+	}
+	a.Failures = 0
+	a.Lockouts++
+	a.LockedUntil = &until
+	r.attempts[subject] = a
+	return true, nil
+}
+
+// Delete implements auth.LoginAttemptRepository.
+func (r *LoginAttemptMemory) Delete(ctx context.Context, subject string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.attempts[subject]; !ok {
+		return auth.ErrNotFound
+	}
+	delete(r.attempts, subject)
+	return nil
+}
+
+// DeleteUpdatedBefore implements auth.LoginAttemptRepository.
+func (r *LoginAttemptMemory) DeleteUpdatedBefore(ctx context.Context, before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	deleted := 0
+	for s, a := range r.attempts {
+		if a.UpdatedAt.Before(before) && (a.LockedUntil == nil || a.LockedUntil.Before(before)) {
+			delete(r.attempts, s)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginAttemptXorm(t *testing.T) {
+	ctx := context.Background()
+	e := setupEngine(ctx, t)
+	testLoginAttemptRepository(ctx, t, NewLoginAttemptXorm(e, LoginAttemptTable))
+}
+
+func TestLoginAttemptMemory(t *testing.T) {
+	testLoginAttemptRepository(context.Background(), t, NewLoginAttemptMemory())
+}
+
+// testLoginAttemptRepository tests repo which has no login attempts.
+func testLoginAttemptRepository(ctx context.Context, t *testing.T, repo auth.LoginAttemptRepository) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+
+	for i := 1; i <= 3; i++ {
+		got, err := repo.Fail(ctx, "user:kawamura", now.Add(-time.Hour), now)
+		assert.NoError(t, err)
+		assert.Equal(t, i, got.Failures)
+	}
+	got, err := repo.Fail(ctx, "ip:192.0.2.1", now.Add(-time.Hour), now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, got.Failures)
+
+	// failures before since are forgotten.
+	later := now.Add(2 * time.Hour)
+	got, err = repo.Fail(ctx, "ip:192.0.2.1", later.Add(-time.Hour), later)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, got.Failures)
+
+	locked, err := repo.Lock(ctx, "user:kawamura", 4, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.False(t, locked, "less failures than 4")
+	locked, err = repo.Lock(ctx, "user:kawamura", 3, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.True(t, locked)
+	locked, err = repo.Lock(ctx, "user:kawamura", 3, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.False(t, locked, "already locked by another replica")
+
+	attempts, err := repo.FindBySubjects(ctx, []string{"user:kawamura", "user:unknown"})
+	assert.NoError(t, err)
+	if assert.Len(t, attempts, 1) {
+		assert.Equal(t, "user:kawamura", attempts[0].Subject)
+		assert.Equal(t, 0, attempts[0].Failures)
+		assert.Equal(t, 1, attempts[0].Lockouts)
+		if assert.NotNil(t, attempts[0].LockedUntil) {
+			assert.True(t, now.Add(time.Minute).Equal(*attempts[0].LockedUntil))
+		}
+	}
+
+	deleted, err := repo.DeleteUpdatedBefore(ctx, now.Add(30*time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, deleted, "the locked subject is kept until the lockout ends")
+	deleted, err = repo.DeleteUpdatedBefore(ctx, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	attempts, err = repo.FindBySubjects(ctx, []string{"user:kawamura", "ip:192.0.2.1"})
+	assert.NoError(t, err)
+	if assert.Len(t, attempts, 1) {
+		assert.Equal(t, "ip:192.0.2.1", attempts[0].Subject)
+	}
+
+	_, err = repo.Fail(ctx, "user:kawamura", now.Add(-time.Hour), now)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Delete(ctx, "user:kawamura"))
+	assert.Equal(t, auth.ErrNotFound, repo.Delete(ctx, "user:kawamura"))
+}
@@ -2,8 +2,10 @@ package repository
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/Accel-Hack/go-api/internal/app/usercase/sample"
 	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
 	"github.com/google/uuid"
 )
@@ -20,14 +22,21 @@ type UpdateSampleRow struct {
 }
 
 type SampleRow struct {
-	ID         string    `xorm:"pk notnull 'ID'"`
-	Name       string    `xorm:"notnull 'NAME'"`
-	Birthday   time.Time `xorm:"notnull 'BIRTHDAY'"`
-	IsJapanese bool      `xorm:"notnull 'IS_JAPANESE'"`
-	CreatedAt  time.Time `xorm:"notnull 'CREATED_AT' created"`
-	UpdatedAt  time.Time `xorm:"notnull 'UPDATED_AT' updated"`
-	IsDeleted  bool      `xorm:"notnull 'IS_DELETED' default 'false'"`
-	DeletedAt  time.Time `xorm:"null 'DELETED_AT'"`
+	ID   string `xorm:"pk notnull 'ID'"`
+	Name string `xorm:"notnull 'NAME'"`
+	// NameNormalized is sample.NormalizeName of Name to search by. It is NULL until backfilled in rows of old versions.
+	NameNormalized string    `xorm:"null 'NAME_NORMALIZED'"`
+	Birthday       time.Time `xorm:"notnull 'BIRTHDAY'"`
+	IsJapanese     bool      `xorm:"notnull 'IS_JAPANESE'"`
+	Version        int       `xorm:"notnull 'VERSION' version"`
+	CreatedAt      time.Time `xorm:"notnull 'CREATED_AT' created"`
+	UpdatedAt      time.Time `xorm:"notnull 'UPDATED_AT' updated"`
+	IsDeleted      bool      `xorm:"notnull 'IS_DELETED' default 'false'"`
+	DeletedAt      time.Time `xorm:"null 'DELETED_AT'"`
+	// OwnerID, CreatedBy and UpdatedBy are IDs of users, which are NULL if the sample is saved by a caller other than a user.
+	OwnerID   *string `xorm:"null 'OWNER_ID'"`
+	CreatedBy *string `xorm:"null 'CREATED_BY'"`
+	UpdatedBy *string `xorm:"null 'UPDATED_BY'"`
 }
 
 func (r SampleRow) toSample() (*model.Sample, error) {
@@ -35,10 +44,62 @@ func (r SampleRow) toSample() (*model.Sample, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid id: %w", err)
 	}
-	return &model.Sample{
+	s := &model.Sample{
 		ID:         id,
 		Name:       r.Name,
 		Birthday:   r.Birthday,
 		IsJapanese: r.IsJapanese,
-	}, err
+		Version:    r.Version,
+	}
+	if s.OwnerID, err = parseUserID(r.OwnerID); err != nil {
+		return nil, fmt.Errorf("invalid owner id: %w", err)
+	}
+	if s.CreatedBy, err = parseUserID(r.CreatedBy); err != nil {
+		return nil, fmt.Errorf("invalid created by: %w", err)
+	}
+	if s.UpdatedBy, err = parseUserID(r.UpdatedBy); err != nil {
+		return nil, fmt.Errorf("invalid updated by: %w", err)
+	}
+	return s, nil
+}
+
+// parseUserID parses a nullable column of a user ID.
+func parseUserID(s *string) (*uuid.UUID, error) {
+	if s == nil {
+		return nil, nil
+	}
+	id, err := uuid.Parse(*s)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// formatUserID formats a user ID for a nullable column.
+func formatUserID(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
+
+// keyValues returns the values of keys other than the last ID formatted for sample.Keyset.
+func (r SampleRow) keyValues(keys []sample.SortKey) []string {
+	values := make([]string, 0, len(keys)-1)
+	for _, key := range keys[:len(keys)-1] {
+		switch key.Field {
+		case sample.SortByName:
+			values = append(values, r.Name)
+		case sample.SortByBirthday:
+			values = append(values, r.Birthday.Format(time.RFC3339Nano))
+		case sample.SortByIsJapanese:
+			values = append(values, strconv.FormatBool(r.IsJapanese))
+		case sample.SortByCreatedAt:
+			values = append(values, r.CreatedAt.Format(time.RFC3339Nano))
+		case sample.SortByUpdatedAt:
+			values = append(values, r.UpdatedAt.Format(time.RFC3339Nano))
+		}
+	}
+	return values
 }
@@ -1,5 +1,37 @@
 package repository
 
+import (
+	"strings"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/sample"
+)
+
+// likeEscape is the escape character of LIKE patterns. It is not a backslash
+// because MySQL and PostgreSQL disagree on escaping a backslash in a string literal.
+const likeEscape = "!"
+
+// likeEscaper escapes wildcards and the escape character itself so that they match themselves.
+var likeEscaper = strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_")
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// likePattern returns a LIKE pattern with likeEscape which matches s in mode.
+func likePattern(s string, mode sample.MatchMode) string {
+	s = escapeLike(s)
+	switch mode {
+	case sample.MatchPrefix:
+		return startWith(s)
+	case sample.MatchSuffix:
+		return endWith(s)
+	case sample.MatchExact:
+		return s
+	default:
+		return contains(s)
+	}
+}
+
 func contains(s string) string {
 	return "%" + s + "%"
 }
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/sample"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_likePattern(t *testing.T) {
+	tests := map[string]struct {
+		s    string
+		mode sample.MatchMode
+		want string
+	}{
+		"contains by default": {
+			s:    "test",
+			want: "%test%",
+		},
+		"escape wildcards": {
+			s:    "50%_off",
+			mode: sample.MatchContains,
+			want: "%50!%!_off%",
+		},
+		"escape escape character": {
+			s:    "hey!",
+			mode: sample.MatchExact,
+			want: "hey!!",
+		},
+		"prefix": {
+			s:    "a_b",
+			mode: sample.MatchPrefix,
+			want: "a!_b%",
+		},
+		"suffix": {
+			s:    "a%b",
+			mode: sample.MatchSuffix,
+			want: "%a!%b",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, likePattern(tt.s, tt.mode))
+		})
+	}
+}
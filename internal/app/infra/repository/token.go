@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/auth"
+	"github.com/google/uuid"
+	"xorm.io/xorm"
+)
+
+const TokenTable = "TOKENS"
+
+type TokenRow struct {
+	ID                  string     `xorm:"pk notnull 'ID'"`
+	FamilyID            string     `xorm:"notnull 'FAMILY_ID'"`
+	UserID              string     `xorm:"notnull 'USER_ID'"`
+	EncryptRefreshToken string     `xorm:"notnull unique 'ENCRYPT_REFRESH_TOKEN'"`
+	ExpiresAt           time.Time  `xorm:"notnull 'EXPIRES_AT'"`
+	RotatedAt           *time.Time `xorm:"null 'ROTATED_AT'"`
+	RevokedAt           *time.Time `xorm:"null 'REVOKED_AT'"`
+	CreatedAt           time.Time  `xorm:"notnull 'CREATED_AT' created"`
+}
+
+func newTokenRow(t *auth.RefreshToken) *TokenRow {
+	return &TokenRow{
+		ID:                  t.ID.String(),
+		FamilyID:            t.Family.String(),
+		UserID:              t.UserID.String(),
+		EncryptRefreshToken: t.Hash,
+		ExpiresAt:           t.ExpiresAt,
+		RotatedAt:           t.RotatedAt,
+		RevokedAt:           t.RevokedAt,
+	}
+}
+
+func (r TokenRow) toRefreshToken() (*auth.RefreshToken, error) {
+	id, err := uuid.Parse(r.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id: %w", err)
+	}
+	family, err := uuid.Parse(r.FamilyID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid family id: %w", err)
+	}
+	userID, err := uuid.Parse(r.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+	return &auth.RefreshToken{
+		ID:        id,
+		Family:    family,
+		UserID:    userID,
+		Hash:      r.EncryptRefreshToken,
+		ExpiresAt: r.ExpiresAt,
+		RotatedAt: r.RotatedAt,
+		RevokedAt: r.RevokedAt,
+	}, nil
+}
+
+// TokenXorm is an auth.TokenRepository backed by xorm.
+type TokenXorm struct {
+	e     *xorm.Engine
+	table string
+}
+
+func NewTokenXorm(e *xorm.Engine, table string) *TokenXorm {
+	return &TokenXorm{
+		e:     e,
+		table: table,
+	}
+}
+
+var _ (auth.TokenRepository) = (*TokenXorm)(nil)
+
+// Insert implements auth.TokenRepository.
+func (r *TokenXorm) Insert(ctx context.Context, t *auth.RefreshToken) error {
+	if _, err := session(ctx, r.e).Table(r.table).Insert(newTokenRow(t)); err != nil {
+		return fmt.Errorf("insert %s: %w", t.ID, err)
+	}
+	return nil
+}
+
+// FindByHash implements auth.TokenRepository.
+func (r *TokenXorm) FindByHash(ctx context.Context, hash string) (*auth.RefreshToken, error) {
+	row := TokenRow{}
+	ok, err := session(ctx, r.e).Table(r.table).Where("`ENCRYPT_REFRESH_TOKEN` = ?", hash).Get(&row)
+	if err != nil {
+		return nil, fmt.Errorf("find by hash: %w", err)
+	}
+	if !ok {
+		return nil, auth.ErrNotFound
+	}
+	return row.toRefreshToken()
+}
+
+// MarkRotated implements auth.TokenRepository.
+func (r *TokenXorm) MarkRotated(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	affected, err := session(ctx, r.e).Table(r.table).ID(id.String()).
+		Where("`ROTATED_AT` IS NULL").
+		Cols("ROTATED_AT").
+		Update(&TokenRow{RotatedAt: &at})
+	if err != nil {
+		return false, fmt.Errorf("mark %s rotated: %w", id, err)
+	}
+	return affected > 0, nil
+}
+
+// RevokeFamily implements auth.TokenRepository.
+func (r *TokenXorm) RevokeFamily(ctx context.Context, family uuid.UUID, at time.Time) error {
+	_, err := session(ctx, r.e).Table(r.table).
+		Where("`FAMILY_ID` = ? AND `REVOKED_AT` IS NULL", family.String()).
+		Cols("REVOKED_AT").
+		Update(&TokenRow{RevokedAt: &at})
+	if err != nil {
+		return fmt.Errorf("revoke family %s: %w", family, err)
+	}
+	return nil
+}
+
+// RevokeUser implements auth.TokenRepository.
+func (r *TokenXorm) RevokeUser(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	_, err := session(ctx, r.e).Table(r.table).
+		Where("`USER_ID` = ? AND `REVOKED_AT` IS NULL", userID.String()).
+		Cols("REVOKED_AT").
+		Update(&TokenRow{RevokedAt: &at})
+	if err != nil {
+		return fmt.Errorf("revoke user %s: %w", userID, err)
+	}
+	return nil
+}
+
+// DeleteExpiredBefore implements auth.TokenRepository.
+func (r *TokenXorm) DeleteExpiredBefore(ctx context.Context, before time.Time) (int, error) {
+	affected, err := session(ctx, r.e).Table(r.table).
+		Where("`EXPIRES_AT` < ?", before).
+		Delete(&TokenRow{})
+	if err != nil {
+		return 0, fmt.Errorf("delete expired before %s: %w", before, err)
+	}
+	return int(affected), nil
+}
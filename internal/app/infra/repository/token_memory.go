@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/auth"
+	"github.com/google/uuid"
+)
+
+// TokenMemory is an auth.TokenRepository in memory, which is lost on restart and not shared among replicas.
+type TokenMemory struct {
+	mu     sync.Mutex
+	tokens map[uuid.UUID]auth.RefreshToken
+}
+
+func NewTokenMemory() *TokenMemory {
+	return &TokenMemory{tokens: map[uuid.UUID]auth.RefreshToken{}}
+}
+
+var _ (auth.TokenRepository) = (*TokenMemory)(nil)
+
+// Insert implements auth.TokenRepository.
+func (r *TokenMemory) Insert(ctx context.Context, t *auth.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[t.ID] = *t
+	return nil
+}
+
+// FindByHash implements auth.TokenRepository.
+func (r *TokenMemory) FindByHash(ctx context.Context, hash string) (*auth.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tokens {
+		if t.Hash == hash {
+			return &t, nil
+		}
+	}
+	return nil, auth.ErrNotFound
+}
+
+// MarkRotated implements auth.TokenRepository.
+func (r *TokenMemory) MarkRotated(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tokens[id]
+	if !ok || t.RotatedAt != nil {
+		return false, nil
+	}
+	t.RotatedAt = &at
+	r.tokens[id] = t
+	return true, nil
+}
+
+// RevokeFamily implements auth.TokenRepository.
+func (r *TokenMemory) RevokeFamily(ctx context.Context, family uuid.UUID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, t := range r.tokens {
+		if t.Family == family && t.RevokedAt == nil {
+			t.RevokedAt = &at
+			r.tokens[id] = t
+		}
+	}
+	return nil
+}
+
+// RevokeUser implements auth.TokenRepository.
+func (r *TokenMemory) RevokeUser(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, t := range r.tokens {
+		if t.UserID == userID && t.RevokedAt == nil {
+			t.RevokedAt = &at
+			r.tokens[id] = t
+		}
+	}
+	return nil
+}
+
+// DeleteExpiredBefore implements auth.TokenRepository.
+func (r *TokenMemory) DeleteExpiredBefore(ctx context.Context, before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	deleted := 0
+	for id, t := range r.tokens {
+		if t.ExpiresAt.Before(before) {
+			delete(r.tokens, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/auth"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenXorm(t *testing.T) {
+	ctx := context.Background()
+	e := setupEngine(ctx, t)
+	testTokenRepository(ctx, t, NewTokenXorm(e, TokenTable))
+}
+
+func TestTokenMemory(t *testing.T) {
+	testTokenRepository(context.Background(), t, NewTokenMemory())
+}
+
+// testTokenRepository tests repo which has no tokens.
+func testTokenRepository(ctx context.Context, t *testing.T, repo auth.TokenRepository) {
+	var (
+		family  = uuid.MustParse("00000000-0000-0000-0000-000000000200")
+		userID  = uuid.MustParse("00000000-0000-0000-0000-000000000100")
+		now     = time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+		first   = &auth.RefreshToken{ID: uuid.MustParse("00000000-0000-0000-0000-000000000201"), Family: family, UserID: userID, Hash: auth.HashToken("first"), ExpiresAt: now.Add(time.Hour)}
+		second  = &auth.RefreshToken{ID: uuid.MustParse("00000000-0000-0000-0000-000000000202"), Family: family, UserID: userID, Hash: auth.HashToken("second"), ExpiresAt: now.Add(2 * time.Hour)}
+		another = &auth.RefreshToken{ID: uuid.MustParse("00000000-0000-0000-0000-000000000203"), Family: uuid.New(), UserID: userID, Hash: auth.HashToken("another"), ExpiresAt: now.Add(3 * time.Hour)}
+	)
+	for _, token := range []*auth.RefreshToken{first, second, another} {
+		assert.NoError(t, repo.Insert(ctx, token))
+	}
+
+	got, err := repo.FindByHash(ctx, auth.HashToken("first"))
+	assert.NoError(t, err)
+	assert.Equal(t, first.ID, got.ID)
+	assert.Equal(t, family, got.Family)
+	assert.Equal(t, userID, got.UserID)
+	assert.True(t, first.ExpiresAt.Equal(got.ExpiresAt))
+	assert.Nil(t, got.RotatedAt)
+	_, err = repo.FindByHash(ctx, auth.HashToken("unknown"))
+	assert.Equal(t, auth.ErrNotFound, err)
+
+	ok, err := repo.MarkRotated(ctx, first.ID, now)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = repo.MarkRotated(ctx, first.ID, now)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, repo.RevokeFamily(ctx, family, now))
+	for hash, revoked := range map[string]bool{"first": true, "second": true, "another": false} {
+		got, err := repo.FindByHash(ctx, auth.HashToken(hash))
+		assert.NoError(t, err)
+		assert.Equal(t, revoked, got.RevokedAt != nil, hash)
+	}
+
+	deleted, err := repo.DeleteExpiredBefore(ctx, now.Add(90*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	_, err = repo.FindByHash(ctx, auth.HashToken("first"))
+	assert.Equal(t, auth.ErrNotFound, err)
+}
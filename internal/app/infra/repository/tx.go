@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/transaction"
+	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
+)
+
+type sessionKey struct{}
+
+// TxXorm is a transaction.Manager backed by xorm.Session.
+type TxXorm struct {
+	e    *xorm.Engine
+	opts TxOptions
+}
+
+// TxOptions configures transactions started by TxXorm.
+type TxOptions struct {
+	// Isolation is the isolation level of transactions, one of sql.LevelReadUncommitted, sql.LevelReadCommitted,
+	// sql.LevelRepeatableRead and sql.LevelSerializable. The connection settings of the engine are used if it is sql.LevelDefault.
+	Isolation sql.IsolationLevel
+}
+
+func NewTxXorm(e *xorm.Engine, opts TxOptions) *TxXorm {
+	return &TxXorm{e: e, opts: opts}
+}
+
+// Do implements transaction.Manager.
+func (m *TxXorm) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(sessionKey{}).(*xorm.Session); ok {
+		return fn(ctx)
+	}
+	session := m.e.NewSession().Context(ctx)
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	if err := m.setIsolation(session); err != nil {
+		return errors.Join(err, session.Rollback())
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			session.Rollback()
+			panic(p)
+		}
+	}()
+	if err := fn(context.WithValue(ctx, sessionKey{}, session)); err != nil {
+		if rbErr := session.Rollback(); rbErr != nil {
+			return errors.Join(err, fmt.Errorf("rollback: %w", rbErr))
+		}
+		return err
+	}
+	if err := session.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// setIsolation sets the isolation level of the transaction begun by session with SET TRANSACTION ISOLATION LEVEL.
+func (m *TxXorm) setIsolation(session *xorm.Session) error {
+	if m.opts.Isolation == sql.LevelDefault {
+		return nil
+	}
+	level, ok := isolationLevels[m.opts.Isolation]
+	if !ok {
+		return fmt.Errorf("unsupported isolation level %s", m.opts.Isolation)
+	}
+	statements := []string{"SET TRANSACTION ISOLATION LEVEL " + level}
+	if m.e.Dialect().URI().DBType != schemas.POSTGRES {
+		// MySQL does not allow SET TRANSACTION in a transaction, and xorm starts it in Begin without options.
+		// The empty transaction is committed and started again on the same connection after setting the level.
+		statements = []string{"COMMIT", statements[0], "START TRANSACTION"}
+	}
+	for _, statement := range statements {
+		if _, err := session.Exec(statement); err != nil {
+			return fmt.Errorf("set isolation level %s: %w", level, err)
+		}
+	}
+	return nil
+}
+
+// isolationLevels maps isolation levels supported by both MySQL and PostgreSQL to their names in SQL.
+var isolationLevels = map[sql.IsolationLevel]string{
+	sql.LevelReadUncommitted: "READ UNCOMMITTED",
+	sql.LevelReadCommitted:   "READ COMMITTED",
+	sql.LevelRepeatableRead:  "REPEATABLE READ",
+	sql.LevelSerializable:    "SERIALIZABLE",
+}
+
+// session returns the session of the transaction started by TxXorm.Do if ctx has it, or a new auto-commit session.
+func session(ctx context.Context, e *xorm.Engine) *xorm.Session {
+	if s, ok := ctx.Value(sessionKey{}).(*xorm.Session); ok {
+		return s
+	}
+	return e.Context(ctx)
+}
+
+var _ (transaction.Manager) = (*TxXorm)(nil)
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"xorm.io/xorm/schemas"
+)
+
+func TestTxXorm_Do(t *testing.T) {
+	ctx := context.Background()
+	e := setupEngine(ctx, t)
+	repo := NewSampleXorm(e, SAMPLE_TABLE)
+	tx := NewTxXorm(e, TxOptions{})
+	errFn := errors.New("error in fn")
+	tests := map[string]struct {
+		fn        func(ctx context.Context, s *model.Sample) error
+		wantErr   error
+		wantPanic bool
+		wantFound bool
+	}{
+		"commit when fn succeeds": {
+			fn: func(ctx context.Context, s *model.Sample) error {
+				return repo.Insert(ctx, s)
+			},
+			wantFound: true,
+		},
+		"rollback when fn returns error": {
+			fn: func(ctx context.Context, s *model.Sample) error {
+				if err := repo.Insert(ctx, s); err != nil {
+					return err
+				}
+				return errFn
+			},
+			wantErr: errFn,
+		},
+		"rollback when fn panics": {
+			fn: func(ctx context.Context, s *model.Sample) error {
+				if err := repo.Insert(ctx, s); err != nil {
+					return err
+				}
+				panic("panic in fn")
+			},
+			wantPanic: true,
+		},
+		"join outer transaction": {
+			fn: func(ctx context.Context, s *model.Sample) error {
+				return tx.Do(ctx, func(ctx context.Context) error {
+					return repo.Insert(ctx, s)
+				})
+			},
+			wantFound: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := model.NewSample("tx-"+uuid.NewString(), time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local), true)
+			do := func() error {
+				return tx.Do(ctx, func(ctx context.Context) error {
+					return tt.fn(ctx, s)
+				})
+			}
+			if tt.wantPanic {
+				assert.Panics(t, func() { do() })
+			} else {
+				assert.Equal(t, tt.wantErr, do())
+			}
+			_, err := repo.FindByID(ctx, s.ID)
+			if tt.wantFound {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, ErrNotFound, err)
+			}
+		})
+	}
+}
+
+func TestTxXorm_Do_isolation(t *testing.T) {
+	ctx := context.Background()
+	e := setupEngine(ctx, t)
+	repo := NewSampleXorm(e, SAMPLE_TABLE)
+	// the level is read from INNODB_TRX on MySQL because @@transaction_isolation does not reflect SET TRANSACTION.
+	// InnoDB registers the transaction when it reads the first row.
+	query := "SELECT `trx_isolation_level` FROM `information_schema`.`INNODB_TRX` WHERE `trx_mysql_thread_id` = CONNECTION_ID()"
+	if e.Dialect().URI().DBType == schemas.POSTGRES {
+		query = "SELECT UPPER(current_setting('transaction_isolation'))"
+	}
+	tests := map[string]struct {
+		isolation sql.IsolationLevel
+		want      string
+	}{
+		"read committed": {isolation: sql.LevelReadCommitted, want: "READ COMMITTED"},
+		"serializable":   {isolation: sql.LevelSerializable, want: "SERIALIZABLE"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := NewTxXorm(e, TxOptions{Isolation: tt.isolation}).Do(ctx, func(ctx context.Context) error {
+				if _, err := repo.FindByID(ctx, uuid.MustParse("00000000-0000-0000-0000-000000000000")); err != nil {
+					return err
+				}
+				var got string
+				if _, err := session(ctx, e).SQL(query).Get(&got); err != nil {
+					return err
+				}
+				assert.Equal(t, tt.want, got)
+				return nil
+			})
+			assert.NoError(t, err)
+		})
+	}
+
+	err := NewTxXorm(e, TxOptions{Isolation: sql.LevelSnapshot}).Do(ctx, func(ctx context.Context) error { return nil })
+	assert.ErrorContains(t, err, "unsupported isolation level")
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/auth"
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"xorm.io/xorm"
+)
+
+const UserTable = "USERS"
+
+type UserRow struct {
+	ID              string `xorm:"pk notnull 'ID'"`
+	UserName        string `xorm:"notnull unique 'USER_NAME'"`
+	EncryptPassword string `xorm:"notnull 'ENCRYPT_PASSWORD'"`
+	Actor           int    `xorm:"notnull 'ACTOR'"`
+	// ResetCode is the hash of the reset code, which is NULL unless the password reset is requested.
+	ResetCode  *string    `xorm:"null 'RESET_CODE'"`
+	ResetUntil *time.Time `xorm:"null 'RESET_UNTIL'"`
+	CreatedAt  time.Time  `xorm:"notnull 'CREATED_AT' created"`
+	UpdatedAt  time.Time  `xorm:"notnull 'UPDATED_AT' updated"`
+}
+
+func newUserRow(u *model.User) *UserRow {
+	return &UserRow{
+		ID:              u.ID().String(),
+		UserName:        u.UserName(),
+		EncryptPassword: u.EncryptPassword(),
+		Actor:           int(u.Actor()),
+		ResetCode:       u.ResetCode(),
+		ResetUntil:      u.ResetUntil(),
+	}
+}
+
+func (r UserRow) toUser() (*model.User, error) {
+	id, err := uuid.Parse(r.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id: %w", err)
+	}
+	return model.NewUser(id, r.UserName, r.EncryptPassword, model.Actor(r.Actor), r.ResetCode, r.ResetUntil, nil)
+}
+
+// UserXorm is an auth.UserRepository backed by xorm.
+type UserXorm struct {
+	e     *xorm.Engine
+	table string
+}
+
+func NewUserXorm(e *xorm.Engine, table string) *UserXorm {
+	return &UserXorm{
+		e:     e,
+		table: table,
+	}
+}
+
+var _ (auth.UserRepository) = (*UserXorm)(nil)
+
+// FindByID implements auth.UserRepository.
+func (r *UserXorm) FindByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	row := UserRow{}
+	ok, err := session(ctx, r.e).Table(r.table).ID(id.String()).Get(&row)
+	if err != nil {
+		return nil, fmt.Errorf("find by id: %w", err)
+	}
+	if !ok {
+		return nil, auth.ErrNotFound
+	}
+	return row.toUser()
+}
+
+// FindByUserName implements auth.UserRepository.
+func (r *UserXorm) FindByUserName(ctx context.Context, userName string) (*model.User, error) {
+	row := UserRow{}
+	ok, err := session(ctx, r.e).Table(r.table).Where("`USER_NAME` = ?", userName).Get(&row)
+	if err != nil {
+		return nil, fmt.Errorf("find by user name: %w", err)
+	}
+	if !ok {
+		return nil, auth.ErrNotFound
+	}
+	return row.toUser()
+}
+
+// Insert implements auth.UserRepository.
+func (r *UserXorm) Insert(ctx context.Context, u *model.User) error {
+	_, err := session(ctx, r.e).Table(r.table).Insert(newUserRow(u))
+	if isUniqueViolation(err) {
+		return auth.ErrDuplicate
+	}
+	if err != nil {
+		return fmt.Errorf("insert %s: %w", u.ID(), err)
+	}
+	return nil
+}
+
+// Update implements auth.UserRepository.
+func (r *UserXorm) Update(ctx context.Context, u *model.User) error {
+	// RESET_CODE and RESET_UNTIL are set to NULL if they are nil because they are nullable.
+	affected, err := session(ctx, r.e).Table(r.table).ID(u.ID().String()).
+		Cols("ENCRYPT_PASSWORD", "RESET_CODE", "RESET_UNTIL").Nullable("RESET_CODE", "RESET_UNTIL").
+		Update(newUserRow(u))
+	if err != nil {
+		return fmt.Errorf("update %s: %w", u.ID(), err)
+	}
+	if affected == 0 {
+		return auth.ErrNotFound
+	}
+	return nil
+}
+
+// ResetPassword implements auth.UserRepository.
+func (r *UserXorm) ResetPassword(ctx context.Context, u *model.User, resetCode string) error {
+	affected, err := session(ctx, r.e).Table(r.table).ID(u.ID().String()).
+		Where("`RESET_CODE` = ?", resetCode).
+		Cols("ENCRYPT_PASSWORD", "RESET_CODE", "RESET_UNTIL").Nullable("RESET_CODE", "RESET_UNTIL").
+		Update(&UserRow{EncryptPassword: u.EncryptPassword()})
+	if err != nil {
+		return fmt.Errorf("reset password %s: %w", u.ID(), err)
+	}
+	if affected == 0 {
+		return auth.ErrInvalidResetCode
+	}
+	return nil
+}
+
+// isUniqueViolation returns true if err is caused by a unique constraint of MySQL or PostgreSQL.
+func isUniqueViolation(err error) bool {
+	var (
+		mysqlErr *mysql.MySQLError
+		pqErr    *pq.Error
+	)
+	switch {
+	case errors.As(err, &mysqlErr):
+		return mysqlErr.Number == 1062 // ER_DUP_ENTRY
+	case errors.As(err, &pqErr):
+		return pqErr.Code == "23505" // unique_violation
+	}
+	return false
+}
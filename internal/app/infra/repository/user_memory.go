@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/auth"
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+	"github.com/google/uuid"
+)
+
+// UserMemory is an auth.UserRepository in memory, which is lost on restart and not shared among replicas.
+// Users are copied on read and write so that changes are not visible until updated as well as the database.
+type UserMemory struct {
+	mu    sync.RWMutex
+	users map[string]model.User
+}
+
+func NewUserMemory() *UserMemory {
+	return &UserMemory{users: map[string]model.User{}}
+}
+
+var _ (auth.UserRepository) = (*UserMemory)(nil)
+
+// FindByID implements auth.UserRepository.
+func (r *UserMemory) FindByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, u := range r.users {
+		if u.ID() == id {
+			return &u, nil
+		}
+	}
+	return nil, auth.ErrNotFound
+}
+
+// FindByUserName implements auth.UserRepository.
+func (r *UserMemory) FindByUserName(ctx context.Context, userName string) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	u, ok := r.users[userName]
+	if !ok {
+		return nil, auth.ErrNotFound
+	}
+	return &u, nil
+}
+
+// Insert implements auth.UserRepository.
+func (r *UserMemory) Insert(ctx context.Context, u *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[u.UserName()]; ok {
+		return auth.ErrDuplicate
+	}
+	r.users[u.UserName()] = *u
+	return nil
+}
+
+// Update implements auth.UserRepository.
+func (r *UserMemory) Update(ctx context.Context, u *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if stored, ok := r.users[u.UserName()]; !ok || stored.ID() != u.ID() {
+		return auth.ErrNotFound
+	}
+	r.users[u.UserName()] = *u
+	return nil
+}
+
+// ResetPassword implements auth.UserRepository.
+func (r *UserMemory) ResetPassword(ctx context.Context, u *model.User, resetCode string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.users[u.UserName()]
+	if !ok || stored.ID() != u.ID() || stored.ResetCode() == nil || *stored.ResetCode() != resetCode {
+		return auth.ErrInvalidResetCode
+	}
+	r.users[u.UserName()] = *u
+	return nil
+}
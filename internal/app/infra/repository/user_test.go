@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/auth"
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserXorm(t *testing.T) {
+	ctx := context.Background()
+	e := setupEngine(ctx, t)
+	testUserRepository(ctx, t, NewUserXorm(e, UserTable))
+}
+
+func TestUserMemory(t *testing.T) {
+	testUserRepository(context.Background(), t, NewUserMemory())
+}
+
+// testUserRepository tests repo which has no users.
+func testUserRepository(ctx context.Context, t *testing.T, repo auth.UserRepository) {
+	user, err := model.NewUser(uuid.MustParse("00000000-0000-0000-0000-000000000100"), "kawamura", "hash", model.ActorManager, nil, nil, nil)
+	assert.NoError(t, err)
+
+	_, err = repo.FindByUserName(ctx, "kawamura")
+	assert.Equal(t, auth.ErrNotFound, err)
+
+	assert.NoError(t, repo.Insert(ctx, user))
+	got, err := repo.FindByUserName(ctx, "kawamura")
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID(), got.ID())
+	assert.Equal(t, user.UserName(), got.UserName())
+	assert.Equal(t, user.EncryptPassword(), got.EncryptPassword())
+	assert.Equal(t, user.Actor(), got.Actor())
+	got, err = repo.FindByID(ctx, user.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, user.UserName(), got.UserName())
+	_, err = repo.FindByID(ctx, uuid.MustParse("00000000-0000-0000-0000-000000000101"))
+	assert.Equal(t, auth.ErrNotFound, err)
+
+	until := time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC)
+	got.RequestReset("reset-hash", until)
+	assert.NoError(t, repo.Update(ctx, got))
+	got, err = repo.FindByID(ctx, user.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, "reset-hash", *got.ResetCode())
+	assert.True(t, until.Equal(*got.ResetUntil()))
+	assert.NoError(t, got.ChangePassword("new-hash"))
+	assert.NoError(t, repo.Update(ctx, got))
+	got, err = repo.FindByID(ctx, user.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, "new-hash", got.EncryptPassword())
+	assert.Nil(t, got.ResetCode())
+	assert.Nil(t, got.ResetUntil())
+
+	// the reset code is consumed only by the first of concurrent confirmations which read it.
+	got.RequestReset("reset-hash", until)
+	assert.NoError(t, repo.Update(ctx, got))
+	first, err := repo.FindByID(ctx, user.ID())
+	assert.NoError(t, err)
+	second, err := repo.FindByID(ctx, user.ID())
+	assert.NoError(t, err)
+	assert.NoError(t, first.ChangePassword("first-hash"))
+	assert.NoError(t, second.ChangePassword("second-hash"))
+	assert.NoError(t, repo.ResetPassword(ctx, first, "reset-hash"))
+	assert.Equal(t, auth.ErrInvalidResetCode, repo.ResetPassword(ctx, second, "reset-hash"))
+	got, err = repo.FindByID(ctx, user.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, "first-hash", got.EncryptPassword())
+	assert.Nil(t, got.ResetCode())
+
+	missing, err := model.NewUser(uuid.MustParse("00000000-0000-0000-0000-000000000102"), "missing", "hash", model.ActorUser, nil, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, auth.ErrNotFound, repo.Update(ctx, missing))
+
+	duplicate, err := model.NewUser(uuid.MustParse("00000000-0000-0000-0000-000000000101"), "kawamura", "hash", model.ActorUser, nil, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, auth.ErrDuplicate, repo.Insert(ctx, duplicate))
+}
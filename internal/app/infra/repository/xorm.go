@@ -2,17 +2,23 @@ package repository
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"log"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Accel-Hack/go-api/internal/app/usercase/sample"
 	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
 	"github.com/google/uuid"
 	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
 )
 
+// SampleXorm is a sample.SampleRepository backed by xorm.
+//
+// Column names in conditions are quoted with backquotes, which xorm replaces with the quote character of the dialect
+// so that upper-case column names are preserved on PostgreSQL.
 type SampleXorm struct {
 	e     *xorm.Engine
 	table string
@@ -25,28 +31,65 @@ func NewSampleXorm(e *xorm.Engine, table string) *SampleXorm {
 	}
 }
 
-var ErrNotFound = errors.New("not found")
+var ErrNotFound = sample.ErrNotFound
+
+// extract returns the SQL expression which extracts field (MONTH or DAY) of column.
+func (r *SampleXorm) extract(field, column string) string {
+	if r.e.Dialect().URI().DBType == schemas.POSTGRES {
+		return "EXTRACT(" + field + " FROM `" + column + "`)"
+	}
+	return field + "(`" + column + "`)"
+}
+
+// ownedCondition returns the condition of samples which the caller in ctx can access,
+// or an empty string if the caller can access all samples.
+func ownedCondition(ctx context.Context) (string, []interface{}) {
+	access := sample.AccessFrom(ctx)
+	if !access.OwnedOnly {
+		return "", nil
+	}
+	if access.UserID == nil {
+		// no samples are owned by callers other than users.
+		return "1 = 0", nil
+	}
+	return "`OWNER_ID` = ?", []interface{}{access.UserID.String()}
+}
+
+// owned adds the condition of samples which the caller in ctx can access to s.
+// Samples are limited in queries rather than filtered after found so that pages and counts are consistent.
+func owned(ctx context.Context, s *xorm.Session) *xorm.Session {
+	if cond, args := ownedCondition(ctx); cond != "" {
+		return s.Where(cond, args...)
+	}
+	return s
+}
 
 // FindByID implements sample.SampleRepository.
 func (r *SampleXorm) FindByID(ctx context.Context, id uuid.UUID) (*model.Sample, error) {
+	return r.findByID(ctx, owned(ctx, session(ctx, r.e).Table(r.table)).Where("`IS_DELETED` = ?", false), id)
+}
+
+// FindByIDIncludeDeleted implements sample.SampleRepository.
+func (r *SampleXorm) FindByIDIncludeDeleted(ctx context.Context, id uuid.UUID) (*model.Sample, error) {
+	return r.findByID(ctx, owned(ctx, session(ctx, r.e).Table(r.table)), id)
+}
+
+func (r *SampleXorm) findByID(ctx context.Context, s *xorm.Session, id uuid.UUID) (*model.Sample, error) {
 	sampleRow := SampleRow{}
-	ok, err := r.e.Context(ctx).Table(r.table).ID(id.String()).Where("IS_DELETED = ?", false).Get(&sampleRow)
-	if !ok {
-		log.Printf("ID(%q) not found", id.String())
-		return nil, ErrNotFound
-	}
+	ok, err := s.ID(id.String()).Get(&sampleRow)
 	if err != nil {
 		return nil, fmt.Errorf("find by id: %w", err)
 	}
+	if !ok {
+		return nil, ErrNotFound
+	}
 	return sampleRow.toSample()
 }
 
-// FindByNameLike implements sample.SampleRepository.
-func (r *SampleXorm) FindByNameLike(ctx context.Context, name string, offset int, limit int) (*model.PagedSamples, error) {
+// FindByFilter implements sample.SampleRepository.
+func (r *SampleXorm) FindByFilter(ctx context.Context, filter sample.SampleFilter, sort []sample.SortKey, fields sample.Fields, offset int, limit int) (*model.PagedSamples, error) {
 	sampleRows := []SampleRow{}
-	count, err := r.e.Context(ctx).Table(r.table).
-		Where("IS_DELETED = ?", false).
-		Where("name LIKE ?", contains(name)).
+	count, err := orderBy(r.where(ctx, selectFields(session(ctx, r.e).Table(r.table), fields, nil), filter), sortKeys(sort), false).
 		Limit(limit, offset).
 		FindAndCount(&sampleRows)
 	if err != nil {
@@ -63,51 +106,405 @@ func (r *SampleXorm) FindByNameLike(ctx context.Context, name string, offset int
 	return model.NewPagedSamples(int(count), samples)
 }
 
-// Insert implements sample.SampleRepository.
+// where adds conditions of samples matching filter to s, which the caller in ctx can access.
+// Deleted samples are excluded unless filter.IncludeDeleted.
+func (r *SampleXorm) where(ctx context.Context, s *xorm.Session, filter sample.SampleFilter) *xorm.Session {
+	s = owned(ctx, s)
+	if !filter.IncludeDeleted {
+		s = s.Where("`IS_DELETED` = ?", false)
+	}
+	if filter.Name != nil {
+		// LIKE compares the case-folded names as is on both databases, while ILIKE of PostgreSQL would fold them again
+		// by its own rules, which differ from the binary collation of MySQL.
+		name := sample.NormalizeName(*filter.Name)
+		s = s.Where("`NAME_NORMALIZED` LIKE ? ESCAPE '"+likeEscape+"'", likePattern(name, filter.NameMatch))
+	}
+	if filter.ExactName != nil {
+		s = s.Where("`NAME` = ?", *filter.ExactName)
+	}
+	if filter.IsJapanese != nil {
+		s = s.Where("`IS_JAPANESE` = ?", *filter.IsJapanese)
+	}
+	if filter.BornAfter != nil {
+		s = s.Where("`BIRTHDAY` > ?", *filter.BornAfter)
+	}
+	if filter.BornBefore != nil {
+		s = s.Where("`BIRTHDAY` < ?", *filter.BornBefore)
+	}
+	if filter.BirthMonth != nil {
+		s = s.Where(r.extract("MONTH", "BIRTHDAY")+" = ?", *filter.BirthMonth)
+	}
+	if filter.BirthDay != nil {
+		s = s.Where(r.extract("DAY", "BIRTHDAY")+" = ?", *filter.BirthDay)
+	}
+	if filter.CreatedAfter != nil {
+		s = s.Where("`CREATED_AT` > ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		s = s.Where("`CREATED_AT` < ?", *filter.CreatedBefore)
+	}
+	if filter.UpdatedAfter != nil {
+		s = s.Where("`UPDATED_AT` > ?", *filter.UpdatedAfter)
+	}
+	if filter.UpdatedBefore != nil {
+		s = s.Where("`UPDATED_AT` < ?", *filter.UpdatedBefore)
+	}
+	return s
+}
+
+// sortColumns maps sortable fields to columns.
+var sortColumns = map[sample.SortField]string{
+	sample.SortByID:         "ID",
+	sample.SortByName:       "NAME",
+	sample.SortByBirthday:   "BIRTHDAY",
+	sample.SortByIsJapanese: "IS_JAPANESE",
+	sample.SortByCreatedAt:  "CREATED_AT",
+	sample.SortByUpdatedAt:  "UPDATED_AT",
+}
+
+// fieldColumns maps fields to columns.
+var fieldColumns = map[sample.Field]string{
+	sample.FieldID:         "ID",
+	sample.FieldName:       "NAME",
+	sample.FieldBirthday:   "BIRTHDAY",
+	sample.FieldIsJapanese: "IS_JAPANESE",
+}
+
+// selectFields selects columns of fields and keys in addition to ID. It selects all columns if fields is nil.
+func selectFields(s *xorm.Session, fields sample.Fields, keys []sample.SortKey) *xorm.Session {
+	if fields == nil {
+		return s
+	}
+	columns := []string{"ID"}
+	for _, field := range fields {
+		if field != sample.FieldID {
+			columns = append(columns, fieldColumns[field])
+		}
+	}
+	for _, key := range keys {
+		if column := sortColumns[key.Field]; !slices.Contains(columns, column) {
+			columns = append(columns, column)
+		}
+	}
+	return s.Cols(columns...)
+}
+
+// sortKeys returns sort followed by ID as a tiebreaker.
+// Keys after ID are dropped because ID is unique so that they are meaningless.
+func sortKeys(sort []sample.SortKey) []sample.SortKey {
+	keys := make([]sample.SortKey, 0, len(sort)+1)
+	for _, key := range sort {
+		keys = append(keys, key)
+		if key.Field == sample.SortByID {
+			return keys
+		}
+	}
+	return append(keys, sample.SortKey{Field: sample.SortByID})
+}
+
+// orderBy adds ORDER BY keys to s. The order of each key is reversed if reverse is true.
+func orderBy(s *xorm.Session, keys []sample.SortKey, reverse bool) *xorm.Session {
+	for _, key := range keys {
+		if key.Desc != reverse {
+			s = s.Desc(sortColumns[key.Field])
+		} else {
+			s = s.Asc(sortColumns[key.Field])
+		}
+	}
+	return s
+}
+
+// FindByKeyset implements sample.SampleRepository.
+func (r *SampleXorm) FindByKeyset(ctx context.Context, q sample.KeysetQuery) (*sample.KeysetPage, error) {
+	keys := sortKeys(q.Sort)
+	s := r.where(ctx, selectFields(session(ctx, r.e).Table(r.table), q.Fields, keys), q.Filter)
+	if q.After != nil {
+		cond, args, err := keysetCondition(keys, *q.After, q.Backward)
+		if err != nil {
+			return nil, err
+		}
+		s = s.Where(cond, args...)
+	}
+	sampleRows := []SampleRow{}
+	if err := orderBy(s, keys, q.Backward).Limit(q.Limit).Find(&sampleRows); err != nil {
+		return nil, err
+	}
+	if q.Backward {
+		slices.Reverse(sampleRows)
+	}
+
+	page := &sample.KeysetPage{
+		Samples: make([]model.Sample, len(sampleRows)),
+		Keysets: make([]sample.Keyset, len(sampleRows)),
+	}
+	for i, row := range sampleRows {
+		s, err := row.toSample()
+		if err != nil {
+			return nil, err
+		}
+		page.Samples[i] = *s
+		page.Keysets[i] = sample.Keyset{Values: row.keyValues(keys), ID: s.ID}
+	}
+	if q.IncludeTotal {
+		count, err := r.where(ctx, session(ctx, r.e).Table(r.table), q.Filter).Count(&SampleRow{})
+		if err != nil {
+			return nil, err
+		}
+		total := int(count)
+		page.Total = &total
+	}
+	return page, nil
+}
+
+// keysetCondition returns the condition of rows after the keyset in the order of keys, which is
+//
+//	k1 > v1 OR (k1 = v1 AND k2 > v2) OR ... OR (k1 = v1 AND ... AND ID > id)
+//
+// where > is replaced with < for descending keys, and reversed if backward is true.
+func keysetCondition(keys []sample.SortKey, after sample.Keyset, backward bool) (string, []interface{}, error) {
+	if len(after.Values) != len(keys)-1 {
+		return "", nil, fmt.Errorf("%w: cursor has %d values for %d keys", sample.ErrInvalid, len(after.Values), len(keys)-1)
+	}
+	values := make([]interface{}, len(keys))
+	for i, key := range keys[:len(keys)-1] {
+		v, err := parseKeyValue(key.Field, after.Values[i])
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: cursor: %w", sample.ErrInvalid, err)
+		}
+		values[i] = v
+	}
+	values[len(keys)-1] = after.ID.String()
+
+	var (
+		ors  = make([]string, len(keys))
+		args = []interface{}{}
+	)
+	for i, key := range keys {
+		ands := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, "`"+sortColumns[keys[j].Field]+"` = ?")
+			args = append(args, values[j])
+		}
+		op := ">"
+		if key.Desc != backward {
+			op = "<"
+		}
+		ands = append(ands, "`"+sortColumns[key.Field]+"` "+op+" ?")
+		args = append(args, values[i])
+		ors[i] = "(" + strings.Join(ands, " AND ") + ")"
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args, nil
+}
+
+// parseKeyValue parses a value of sample.Keyset formatted by SampleRow.keyValues.
+func parseKeyValue(field sample.SortField, v string) (interface{}, error) {
+	switch field {
+	case sample.SortByBirthday, sample.SortByCreatedAt, sample.SortByUpdatedAt:
+		return time.Parse(time.RFC3339Nano, v)
+	case sample.SortByIsJapanese:
+		return strconv.ParseBool(v)
+	default:
+		return v, nil
+	}
+}
+
+// Insert implements sample.SampleRepository. The caller in ctx is recorded as the owner of the sample.
 func (r *SampleXorm) Insert(ctx context.Context, s *model.Sample) error {
+	userID := sample.AccessFrom(ctx).UserID
 	newRow := SampleRow{
-		ID:         s.ID.String(),
-		Name:       s.Name,
-		Birthday:   s.Birthday,
-		IsJapanese: s.IsJapanese,
+		ID:             s.ID.String(),
+		Name:           s.Name,
+		NameNormalized: sample.NormalizeName(s.Name),
+		Birthday:       s.Birthday,
+		IsJapanese:     s.IsJapanese,
+		OwnerID:        formatUserID(userID),
+		CreatedBy:      formatUserID(userID),
+		UpdatedBy:      formatUserID(userID),
 	}
-	_, err := r.e.Context(ctx).Table(r.table).Insert(&newRow)
+	_, err := session(ctx, r.e).Table(r.table).Insert(&newRow)
 	if err != nil {
-		return fmt.Errorf("insert %#v: %w", newRow, err)
+		return fmt.Errorf("insert %s: %w", s.ID, err)
 	}
+	s.OwnerID, s.CreatedBy, s.UpdatedBy = userID, userID, userID
 	return nil
 }
 
-// Update implements sample.SampleRepository.
-func (r *SampleXorm) Update(ctx context.Context, query sample.UpdateQuery) error {
-	updateRow := UpdateSampleRow{
-		ID:         query.ID.String(),
-		Name:       query.Name,
-		Birthday:   query.Birthday,
-		IsJapanese: query.IsJapanese,
+// Update implements sample.SampleRepository. The caller in ctx is recorded as the editor of the sample.
+func (r *SampleXorm) Update(ctx context.Context, s *model.Sample) error {
+	userID := sample.AccessFrom(ctx).UserID
+	updateRow := SampleRow{
+		ID:             s.ID.String(),
+		Name:           s.Name,
+		NameNormalized: sample.NormalizeName(s.Name),
+		Birthday:       s.Birthday,
+		IsJapanese:     s.IsJapanese,
+		Version:        s.Version,
+		UpdatedBy:      formatUserID(userID),
 	}
-	log.Printf("[DEBUG] update sample with %#v", updateRow)
-	if _, err := r.e.Context(ctx).Table(r.table).ID(query.ID.String()).Update(&updateRow); err != nil {
+	// xorm adds the condition of VERSION and increments it because SampleRow.Version has version tag.
+	affected, err := owned(ctx, session(ctx, r.e).Table(r.table)).ID(s.ID.String()).
+		Where("`IS_DELETED` = ?", false).
+		Cols("NAME", "NAME_NORMALIZED", "BIRTHDAY", "IS_JAPANESE", "UPDATED_BY").
+		Update(&updateRow)
+	if err != nil {
 		return fmt.Errorf("update: %w", err)
 	}
+	if affected == 0 {
+		return sample.ErrConflict
+	}
+	s.Version, s.UpdatedBy = updateRow.Version, userID
 	return nil
 }
 
+// backfillBatchSize is the number of rows backfilled in a transaction.
+const backfillBatchSize = 100
+
+// BackfillNormalizedNames sets NAME_NORMALIZED of rows where it is NULL, including deleted rows, and returns the number of them.
+// It neither changes VERSION nor UPDATED_AT because samples are not modified. It is safe to run concurrently and repeatedly.
+func (r *SampleXorm) BackfillNormalizedNames(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		n, err := r.backfillNormalizedNames(ctx)
+		if err != nil {
+			return total, fmt.Errorf("backfill normalized names: %w", err)
+		}
+		total += n
+		if n < backfillBatchSize {
+			return total, nil
+		}
+	}
+}
+
+func (r *SampleXorm) backfillNormalizedNames(ctx context.Context) (int, error) {
+	rows := []SampleRow{}
+	if err := r.e.Context(ctx).Table(r.table).Cols("ID", "NAME").
+		Where("`NAME_NORMALIZED` IS NULL").
+		Limit(backfillBatchSize).
+		Find(&rows); err != nil {
+		return 0, err
+	}
+	for _, row := range rows {
+		// NULL is checked again in case that the row is updated after found.
+		if _, err := r.e.Context(ctx).Table(r.table).ID(row.ID).
+			Where("`NAME_NORMALIZED` IS NULL").
+			Cols("NAME_NORMALIZED").NoVersionCheck().NoAutoTime().
+			Update(&SampleRow{NameNormalized: sample.NormalizeName(row.Name)}); err != nil {
+			return 0, err
+		}
+	}
+	return len(rows), nil
+}
+
 // DeleteByID implements sample.SampleRepository.
 func (r *SampleXorm) DeleteByID(ctx context.Context, id uuid.UUID) error {
 	var (
 		isDeleted = true
 		now       = time.Now()
 	)
-	_, err := r.e.Context(ctx).Table(r.table).ID(id.String()).Update(&UpdateSampleRow{
-		ID:        id.String(),
-		IsDeleted: &isDeleted,
-		DeletedAt: &now,
-	})
+	// deleted samples are excluded so that DELETED_AT keeps the time when it is deleted first.
+	affected, err := owned(ctx, session(ctx, r.e).Table(r.table)).ID(id.String()).
+		Where("`IS_DELETED` = ?", false).
+		Incr("VERSION").
+		Update(&UpdateSampleRow{
+			ID:        id.String(),
+			IsDeleted: &isDeleted,
+			DeletedAt: &now,
+		})
 	if err != nil {
 		return fmt.Errorf("delete %s: %w", id, err)
 	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// HardDeleteByID implements sample.SampleRepository.
+func (r *SampleXorm) HardDeleteByID(ctx context.Context, id uuid.UUID) error {
+	affected, err := owned(ctx, session(ctx, r.e).Table(r.table)).ID(id.String()).Delete(&SampleRow{})
+	if err != nil {
+		return fmt.Errorf("hard delete %s: %w", id, err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// FindDeleted implements sample.SampleRepository.
+func (r *SampleXorm) FindDeleted(ctx context.Context, offset, limit int) (*model.PagedDeletedSamples, error) {
+	sampleRows := []SampleRow{}
+	count, err := owned(ctx, session(ctx, r.e).Table(r.table)).
+		Where("`IS_DELETED` = ?", true).
+		Desc("DELETED_AT").Asc("ID").
+		Limit(limit, offset).
+		FindAndCount(&sampleRows)
+	if err != nil {
+		return nil, fmt.Errorf("find deleted: %w", err)
+	}
+	samples := make([]model.DeletedSample, len(sampleRows))
+	for i, row := range sampleRows {
+		s, err := row.toSample()
+		if err != nil {
+			return nil, err
+		}
+		samples[i] = model.DeletedSample{Sample: *s, DeletedAt: row.DeletedAt}
+	}
+	return &model.PagedDeletedSamples{Total: int(count), Samples: samples}, nil
+}
+
+// Restore implements sample.SampleRepository.
+func (r *SampleXorm) Restore(ctx context.Context, id uuid.UUID) error {
+	// DELETED_AT is set to NULL because it is nullable and zero in SampleRow.
+	// VERSION is incremented without the version check because the caller does not know the version of deleted samples.
+	affected, err := owned(ctx, session(ctx, r.e).Table(r.table)).ID(id.String()).
+		Where("`IS_DELETED` = ?", true).
+		Incr("VERSION").
+		Cols("IS_DELETED", "DELETED_AT").Nullable("DELETED_AT").NoVersionCheck().
+		Update(&SampleRow{})
+	if err != nil {
+		return fmt.Errorf("restore %s: %w", id, err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
 	return nil
 }
 
+// CountDeletedBefore implements sample.PurgeRepository.
+func (r *SampleXorm) CountDeletedBefore(ctx context.Context, before time.Time) (int, error) {
+	count, err := session(ctx, r.e).Table(r.table).
+		Where("`IS_DELETED` = ? AND `DELETED_AT` < ?", true, before).
+		Count(&SampleRow{})
+	if err != nil {
+		return 0, fmt.Errorf("count deleted before %s: %w", before, err)
+	}
+	return int(count), nil
+}
+
+// PurgeDeletedBefore implements sample.PurgeRepository.
+// IDs to delete are selected first because PostgreSQL does not support DELETE with LIMIT.
+func (r *SampleXorm) PurgeDeletedBefore(ctx context.Context, before time.Time, limit int) (int, error) {
+	ids := []string{}
+	if err := session(ctx, r.e).Table(r.table).Cols("ID").
+		Where("`IS_DELETED` = ? AND `DELETED_AT` < ?", true, before).
+		Asc("DELETED_AT").
+		Limit(limit).
+		Find(&ids); err != nil {
+		return 0, fmt.Errorf("find deleted before %s: %w", before, err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	// the conditions are checked again in case that the samples are restored after found.
+	affected, err := session(ctx, r.e).Table(r.table).In("ID", ids).
+		Where("`IS_DELETED` = ? AND `DELETED_AT` < ?", true, before).
+		Delete(&SampleRow{})
+	if err != nil {
+		return 0, fmt.Errorf("purge deleted before %s: %w", before, err)
+	}
+	return int(affected), nil
+}
+
 var _ (sample.SampleRepository) = (*SampleXorm)(nil)
+var _ (sample.PurgeRepository) = (*SampleXorm)(nil)
@@ -0,0 +1,121 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/sample"
+	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
+	"github.com/google/uuid"
+)
+
+// InvertedIndex is a sample.SearchIndex in memory of the process.
+// It must be filled by sample.Usecase.RebuildIndex on start up and is not shared among processes.
+//
+// Samples are scored by the sum of TF-IDF of the matched terms.
+type InvertedIndex struct {
+	mu      sync.RWMutex
+	samples map[uuid.UUID]model.Sample
+	// postings maps a term to the term frequencies of samples containing it.
+	postings map[string]map[uuid.UUID]int
+}
+
+func NewInvertedIndex() *InvertedIndex {
+	return &InvertedIndex{
+		samples:  map[uuid.UUID]model.Sample{},
+		postings: map[string]map[uuid.UUID]int{},
+	}
+}
+
+// Search implements sample.SearchIndex.
+func (i *InvertedIndex) Search(ctx context.Context, q string, offset, limit int) (*model.ScoredSamples, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	scores := map[uuid.UUID]float64{}
+	for _, term := range sample.SearchTerms(q) {
+		posting := i.postings[term]
+		idf := math.Log(1 + float64(len(i.samples))/float64(len(posting)))
+		for id, tf := range posting {
+			scores[id] += float64(tf) * idf
+		}
+	}
+	access := sample.AccessFrom(ctx)
+	hits := make([]model.ScoredSample, 0, len(scores))
+	for id, score := range scores {
+		if s := i.samples[id]; access.Allows(&s) {
+			hits = append(hits, model.ScoredSample{Sample: s, Score: score})
+		}
+	}
+	sort.Slice(hits, func(a, b int) bool {
+		if hits[a].Score != hits[b].Score {
+			return hits[a].Score > hits[b].Score
+		}
+		return hits[a].Sample.ID.String() < hits[b].Sample.ID.String()
+	})
+
+	result := &model.ScoredSamples{Total: len(hits), Hits: []model.ScoredSample{}}
+	if offset < len(hits) {
+		result.Hits = hits[offset:min(offset+limit, len(hits))]
+	}
+	return result, nil
+}
+
+// Put implements sample.SearchIndex.
+func (i *InvertedIndex) Put(ctx context.Context, s *model.Sample) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.remove(s.ID)
+	i.samples[s.ID] = *s
+	for _, term := range terms(s.Name) {
+		if i.postings[term] == nil {
+			i.postings[term] = map[uuid.UUID]int{}
+		}
+		i.postings[term][s.ID]++
+	}
+	return nil
+}
+
+// Remove implements sample.SearchIndex.
+func (i *InvertedIndex) Remove(ctx context.Context, id uuid.UUID) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.remove(id)
+	return nil
+}
+
+func (i *InvertedIndex) remove(id uuid.UUID) {
+	old, ok := i.samples[id]
+	if !ok {
+		return
+	}
+	delete(i.samples, id)
+	for _, term := range terms(old.Name) {
+		delete(i.postings[term], id)
+		if len(i.postings[term]) == 0 {
+			delete(i.postings, term)
+		}
+	}
+}
+
+// terms returns bigrams of the normalized name including duplicates for the term frequency.
+func terms(name string) []string {
+	runes := []rune(sample.NormalizeName(name))
+	if len(runes) == 0 {
+		return nil
+	}
+	if len(runes) < 2 {
+		return []string{string(runes)}
+	}
+	terms := make([]string, 0, len(runes)-1)
+	for i := 0; i+2 <= len(runes); i++ {
+		terms = append(terms, string(runes[i:i+2]))
+	}
+	return terms
+}
+
+var _ sample.SearchIndex = (*InvertedIndex)(nil)
@@ -0,0 +1,111 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/sample"
+	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvertedIndex_Search(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		owner   = uuid.MustParse("00000000-0000-0000-0000-000000000101")
+		ninja   = model.Sample{ID: uuid.MustParse("00000000-0000-0000-0000-000000000000"), Name: "忍者ニンジャ", OwnerID: &owner}
+		samurai = model.Sample{ID: uuid.MustParse("00000000-0000-0000-0000-000000000001"), Name: "侍"}
+		twice   = model.Sample{ID: uuid.MustParse("00000000-0000-0000-0000-000000000002"), Name: "にんにん"}
+		deleted = model.Sample{ID: uuid.MustParse("00000000-0000-0000-0000-000000000003"), Name: "にんじゃ"}
+	)
+	index := NewInvertedIndex()
+	for _, s := range []model.Sample{ninja, samurai, twice, deleted} {
+		assert.NoError(t, index.Put(ctx, &s))
+	}
+	assert.NoError(t, index.Remove(ctx, deleted.ID))
+
+	tests := map[string]struct {
+		access sample.Access
+		q      string
+		offset int
+		limit  int
+		want   []uuid.UUID
+		total  int
+	}{
+		"order by score": {
+			q:     "にん",
+			limit: 10,
+			want:  []uuid.UUID{twice.ID, ninja.ID},
+			total: 2,
+		},
+		"match normalized terms": {
+			q:     "ｼﾞｬ",
+			limit: 10,
+			want:  []uuid.UUID{ninja.ID},
+			total: 1,
+		},
+		"match a character": {
+			q:     "侍",
+			limit: 10,
+			want:  []uuid.UUID{samurai.ID},
+			total: 1,
+		},
+		"page by offset and limit": {
+			q:      "にん",
+			offset: 1,
+			limit:  1,
+			want:   []uuid.UUID{ninja.ID},
+			total:  2,
+		},
+		"return empty when offset exceeds": {
+			q:      "にん",
+			offset: 2,
+			limit:  1,
+			want:   []uuid.UUID{},
+			total:  2,
+		},
+		"match only samples owned by the caller": {
+			access: sample.Access{UserID: &owner, OwnedOnly: true},
+			q:      "にん",
+			limit:  10,
+			want:   []uuid.UUID{ninja.ID},
+			total:  1,
+		},
+		"return empty when nothing matches": {
+			q:     "samurai",
+			limit: 10,
+			want:  []uuid.UUID{},
+			total: 0,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := index.Search(sample.WithAccess(ctx, tt.access), tt.q, tt.offset, tt.limit)
+			assert.NoError(t, err)
+			ids := []uuid.UUID{}
+			for _, hit := range got.Hits {
+				ids = append(ids, hit.Sample.ID)
+			}
+			assert.Equal(t, tt.want, ids)
+			assert.Equal(t, tt.total, got.Total)
+		})
+	}
+}
+
+func TestInvertedIndex_Put(t *testing.T) {
+	ctx := context.Background()
+	s := model.Sample{ID: uuid.New(), Name: "ninja"}
+	index := NewInvertedIndex()
+	assert.NoError(t, index.Put(ctx, &s))
+
+	s.Name = "samurai"
+	assert.NoError(t, index.Put(ctx, &s))
+
+	got, err := index.Search(ctx, "ninja", 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, got.Total)
+	got, err = index.Search(ctx, "samurai", 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.ScoredSample{{Sample: s, Score: got.Hits[0].Score}}, got.Hits)
+}
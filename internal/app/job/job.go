@@ -0,0 +1,44 @@
+package job
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Scheduler runs background jobs periodically in goroutines until their context is done.
+type Scheduler struct {
+	Logger *slog.Logger
+	wg     sync.WaitGroup
+}
+
+// Every runs fn every interval after the first interval elapses until ctx is done.
+// An error of fn is logged and does not stop the job. Runs of a job never overlap.
+func (s *Scheduler) Every(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	s.Logger.Info("schedule job", "job", name, "interval", interval)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				start := time.Now()
+				if err := fn(ctx); err != nil {
+					s.Logger.Error("run job", "job", name, "err", err)
+					continue
+				}
+				s.Logger.Debug("run job", "job", name, "elapsed", time.Since(start))
+			}
+		}
+	}()
+}
+
+// Wait waits for all jobs to stop after their context is done.
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
@@ -0,0 +1,32 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduler_Every(t *testing.T) {
+	s := Scheduler{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	ctx, cancel := context.WithCancel(context.Background())
+	var runs atomic.Int32
+	s.Every(ctx, "test", time.Millisecond, func(ctx context.Context) error {
+		if runs.Add(1) == 1 {
+			return errors.New("job error")
+		}
+		return nil
+	})
+
+	assert.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, time.Millisecond, "job must continue after error")
+	cancel()
+	s.Wait()
+	stopped := runs.Load()
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, stopped, runs.Load(), "job must stop after context is done")
+}
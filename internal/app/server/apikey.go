@@ -0,0 +1,139 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/auth"
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// apiKeyRequest is the body to create an API key.
+type apiKeyRequest struct {
+	Name   string       `json:"name"`
+	Actor  model.Actor  `json:"actor"`
+	Scopes []auth.Scope `json:"scopes"`
+}
+
+// apiKeyResponse is an API key without the hash. Key is responded only when it is created.
+type apiKeyResponse struct {
+	ID        uuid.UUID    `json:"id"`
+	Name      string       `json:"name"`
+	Prefix    string       `json:"prefix"`
+	Actor     model.Actor  `json:"actor"`
+	Scopes    []auth.Scope `json:"scopes"`
+	CreatedAt time.Time    `json:"created_at"`
+	RevokedAt *time.Time   `json:"revoked_at,omitempty"`
+	Key       string       `json:"key,omitempty"`
+}
+
+func newAPIKeyResponse(k *auth.APIKey) apiKeyResponse {
+	return apiKeyResponse{
+		ID:        k.ID,
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Actor:     k.Actor,
+		Scopes:    k.Scopes,
+		CreatedAt: k.CreatedAt,
+		RevokedAt: k.RevokedAt,
+	}
+}
+
+func decodeAPIKey(r *http.Request) (apiKeyRequest, error) {
+	var req apiKeyRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxCredentialBytes)).Decode(&req); err != nil {
+		return req, fmt.Errorf("decode API key: %w", err)
+	}
+	return req, nil
+}
+
+// CreateAPIKey creates an API key and responds it with the key, which cannot be retrieved again.
+func (h *AuthHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeAPIKey(r)
+	if err != nil {
+		h.Logger.Error("parse body", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	key, created, err := h.Usecase.CreateAPIKey(r.Context(), auth.CreateAPIKeyQuery{
+		Name:   req.Name,
+		Actor:  req.Actor,
+		Scopes: req.Scopes,
+	})
+	if h.writeAdminError(w, "create API key", err) {
+		return
+	}
+	res := newAPIKeyResponse(created)
+	res.Key = key
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		h.Logger.Error("encode API key to JSON", "err", err)
+	}
+}
+
+// ListAPIKeys responds all API keys including revoked ones without the keys.
+func (h *AuthHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.Usecase.ListAPIKeys(r.Context())
+	if h.writeAdminError(w, "list API keys", err) {
+		return
+	}
+	res := make([]apiKeyResponse, len(keys))
+	for i := range keys {
+		res[i] = newAPIKeyResponse(&keys[i])
+	}
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		h.Logger.Error("encode API keys to JSON", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *AuthHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.Logger.Error("parse id", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.Usecase.RevokeAPIKey(r.Context(), id)
+	if h.writeAdminError(w, "revoke API key", err) {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeAdminError responds err of administration and returns true unless err is nil.
+func (h *AuthHandler) writeAdminError(w http.ResponseWriter, msg string, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, auth.ErrUnauthorized):
+		h.Logger.Info(msg, "err", err)
+		writeProblem(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, auth.ErrForbidden):
+		h.Logger.Info(msg, "err", err)
+		writeProblem(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, auth.ErrInvalid):
+		h.Logger.Error(msg, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+	case errors.Is(err, auth.ErrNotFound):
+		h.Logger.Error(msg, "err", err)
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, errors.ErrUnsupported):
+		h.Logger.Error(msg, "err", err)
+		w.WriteHeader(http.StatusNotImplemented)
+	default:
+		h.Logger.Error(msg, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	return true
+}
@@ -0,0 +1,331 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/auth"
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+	"github.com/gorilla/mux"
+)
+
+// maxCredentialBytes is the maximum size of a request body with credentials.
+const maxCredentialBytes = 1 << 12
+
+type AuthHandler struct {
+	Usecase auth.Usecase
+	Logger  *slog.Logger
+	// ClientIPHeader is the header of the client IP set by a trusted reverse proxy such as X-Forwarded-For,
+	// whose last value is used. The remote address is used if it is empty.
+	ClientIPHeader string
+}
+
+// credentialRequest is the body of register and login.
+// Credentials are sent in the body instead of the query so that they are not logged with URLs.
+type credentialRequest struct {
+	UserName string `json:"user_name"`
+	Password string `json:"password"`
+}
+
+// refreshRequest is the body of refresh and logout.
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// resetRequest is the body of password reset requests and confirmations.
+// Code and Password are empty in requests.
+type resetRequest struct {
+	UserName string `json:"user_name"`
+	Code     string `json:"code"`
+	Password string `json:"password"`
+}
+
+// tokenResponse is a token in the format of OAuth 2.0 access token responses.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func newTokenResponse(t *auth.IssuedToken) tokenResponse {
+	return tokenResponse{
+		AccessToken:  t.AccessToken(),
+		TokenType:    "Bearer",
+		ExpiresIn:    int(time.Until(t.ExpiresAt()).Seconds()),
+		RefreshToken: t.RefreshToken,
+	}
+}
+
+func decodeCredential(r *http.Request) (credentialRequest, error) {
+	var req credentialRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxCredentialBytes)).Decode(&req); err != nil {
+		return req, fmt.Errorf("decode credential: %w", err)
+	}
+	return req, nil
+}
+
+func decodeRefresh(r *http.Request) (refreshRequest, error) {
+	var req refreshRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxCredentialBytes)).Decode(&req); err != nil {
+		return req, fmt.Errorf("decode refresh token: %w", err)
+	}
+	if req.RefreshToken == "" {
+		return req, errors.New("refresh_token is required")
+	}
+	return req, nil
+}
+
+func decodeReset(r *http.Request) (resetRequest, error) {
+	var req resetRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxCredentialBytes)).Decode(&req); err != nil {
+		return req, fmt.Errorf("decode password reset: %w", err)
+	}
+	if req.UserName == "" {
+		return req, errors.New("user_name is required")
+	}
+	return req, nil
+}
+
+func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeCredential(r)
+	if err != nil {
+		h.Logger.Error("parse body", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	id, err := h.Usecase.Register(r.Context(), auth.RegisterQuery{
+		UserName: req.UserName,
+		Password: req.Password,
+	})
+	switch {
+	case errors.Is(err, auth.ErrInvalid):
+		h.Logger.Error("register user", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	case errors.Is(err, auth.ErrDuplicate):
+		h.Logger.Error("register user", "err", err)
+		w.WriteHeader(http.StatusConflict)
+		return
+	case err != nil:
+		h.Logger.Error("register user", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"id": id,
+	}); err != nil {
+		h.Logger.Error("encode user to JSON", "err", err)
+	}
+}
+
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeCredential(r)
+	if err != nil {
+		h.Logger.Error("parse body", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.Usecase.Login(auth.WithClientIP(r.Context(), h.clientIP(r)), req.UserName, req.Password)
+	var locked *auth.LockedError
+	switch {
+	case errors.As(err, &locked):
+		h.Logger.Warn("login", "err", err)
+		w.Header().Set("Retry-After", retryAfter(locked.Until))
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	case errors.Is(err, auth.ErrUnauthorized):
+		h.Logger.Info("login", "err", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	case err != nil:
+		h.Logger.Error("login", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	h.writeToken(w, token)
+}
+
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeRefresh(r)
+	if err != nil {
+		h.Logger.Error("parse body", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.Usecase.Refresh(r.Context(), req.RefreshToken)
+	switch {
+	case errors.Is(err, auth.ErrTokenReused):
+		h.Logger.Warn("refresh token", "err", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	case errors.Is(err, auth.ErrUnauthorized):
+		h.Logger.Info("refresh token", "err", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	case err != nil:
+		h.Logger.Error("refresh token", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	h.writeToken(w, token)
+}
+
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeRefresh(r)
+	if err != nil {
+		h.Logger.Error("parse body", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.Usecase.Logout(r.Context(), req.RefreshToken)
+	switch {
+	case errors.Is(err, auth.ErrUnauthorized):
+		h.Logger.Info("logout", "err", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	case err != nil:
+		h.Logger.Error("logout", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RequestPasswordReset responds 202 Accepted whether the user exists or not.
+func (h *AuthHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeReset(r)
+	if err != nil {
+		h.Logger.Error("parse body", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.Usecase.RequestPasswordReset(r.Context(), req.UserName)
+	switch {
+	case errors.Is(err, errors.ErrUnsupported):
+		h.Logger.Error("request password reset", "err", err)
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	case err != nil:
+		h.Logger.Error("request password reset", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *AuthHandler) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeReset(r)
+	if err != nil {
+		h.Logger.Error("parse body", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.Usecase.ConfirmPasswordReset(r.Context(), req.UserName, req.Code, req.Password)
+	switch {
+	case errors.Is(err, auth.ErrInvalid), errors.Is(err, auth.ErrInvalidResetCode):
+		h.Logger.Info("confirm password reset", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	case err != nil:
+		h.Logger.Error("confirm password reset", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Authenticate is a middleware which puts the caller of Authorization header into the request context,
+// which is the user of a bearer token or an API key of "ApiKey" scheme.
+// Requests without the header pass through as anonymous so that the policy decides whether they are allowed,
+// and requests with invalid credentials are responded 401 Unauthorized.
+func (h *AuthHandler) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		scheme, credential, _ := strings.Cut(header, " ")
+		var (
+			ctx context.Context
+			err error
+		)
+		switch {
+		case credential == "":
+			err = fmt.Errorf("%w: Authorization header has no credential", auth.ErrUnauthorized)
+		case strings.EqualFold(scheme, "Bearer"):
+			var user *model.User
+			if user, err = h.Usecase.Authenticate(r.Context(), credential); err == nil {
+				ctx = auth.WithUser(r.Context(), user)
+			}
+		case strings.EqualFold(scheme, "ApiKey"):
+			var key *auth.APIKey
+			if key, err = h.Usecase.AuthenticateAPIKey(r.Context(), credential); err == nil {
+				ctx = auth.WithAPIKey(r.Context(), key)
+			}
+		default:
+			err = fmt.Errorf("%w: unsupported scheme %q", auth.ErrUnauthorized, scheme)
+		}
+		switch {
+		case errors.Is(err, auth.ErrUnauthorized):
+			h.Logger.Info("authenticate", "err", err)
+			writeProblem(w, http.StatusUnauthorized, "credential is invalid, expired or revoked")
+			return
+		case err != nil:
+			h.Logger.Error("authenticate", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (h *AuthHandler) writeToken(w http.ResponseWriter, token *auth.IssuedToken) {
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(newTokenResponse(token)); err != nil {
+		h.Logger.Error("encode token to JSON", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *AuthHandler) Route(mux *mux.Router) {
+	h.Logger.Info(`expose POST "/auth/register"`)
+	mux.HandleFunc("/auth/register", h.Register).Methods(http.MethodPost)
+	h.Logger.Info(`expose POST "/auth/login"`)
+	mux.HandleFunc("/auth/login", h.Login).Methods(http.MethodPost)
+	h.Logger.Info(`expose POST "/auth/refresh"`)
+	mux.HandleFunc("/auth/refresh", h.Refresh).Methods(http.MethodPost)
+	h.Logger.Info(`expose POST "/auth/logout"`)
+	mux.HandleFunc("/auth/logout", h.Logout).Methods(http.MethodPost)
+	h.Logger.Info(`expose POST "/auth/password-reset/request"`)
+	mux.HandleFunc("/auth/password-reset/request", h.RequestPasswordReset).Methods(http.MethodPost)
+	h.Logger.Info(`expose POST "/auth/password-reset/confirm"`)
+	mux.HandleFunc("/auth/password-reset/confirm", h.ConfirmPasswordReset).Methods(http.MethodPost)
+
+	admin := mux.PathPrefix("/admin").Subrouter()
+	admin.Use(h.Authenticate)
+	h.Logger.Info(`expose POST "/admin/api-keys"`)
+	admin.HandleFunc("/api-keys", h.CreateAPIKey).Methods(http.MethodPost)
+	h.Logger.Info(`expose GET "/admin/api-keys"`)
+	admin.HandleFunc("/api-keys", h.ListAPIKeys).Methods(http.MethodGet)
+	h.Logger.Info(`expose DELETE "/admin/api-keys/{id}"`)
+	admin.HandleFunc("/api-keys/{id}", h.RevokeAPIKey).Methods(http.MethodDelete)
+	h.Logger.Info(`expose DELETE "/admin/lockouts"`)
+	admin.HandleFunc("/lockouts", h.UnlockLogin).Methods(http.MethodDelete)
+}
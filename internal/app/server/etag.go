@@ -0,0 +1,108 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/sample"
+	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
+)
+
+var ErrInvalidETag = errors.New("invalid etag")
+
+// sampleETag returns the strong entity tag of s derived from its version.
+func sampleETag(s *model.Sample) string {
+	return strconv.Quote(strconv.Itoa(s.Version))
+}
+
+// bodyETag returns the strong entity tag derived from the hash of the response body.
+// It is used for responses which consist of multiple samples such as pages.
+func bodyETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return strconv.Quote(base64.RawURLEncoding.EncodeToString(sum[:]))
+}
+
+// parseIfMatch returns the precondition in If-Match header, or nil if the header is absent.
+// The header is "*" or a comma-separated list of entity tags as defined by RFC 9110 section 13.1.1.
+// Weak entity tags and tags which are not versions are valid but never match because If-Match uses the strong comparison.
+// It returns ErrInvalidETag if the header is malformed.
+func parseIfMatch(h http.Header) (*sample.IfMatch, error) {
+	values := h.Values("If-Match")
+	if len(values) == 0 {
+		return nil, nil
+	}
+	v := strings.Trim(strings.Join(values, ","), " \t")
+	if v == "*" {
+		return &sample.IfMatch{Any: true}, nil
+	}
+	m := &sample.IfMatch{}
+	empty := true
+	for v != "" {
+		if v[0] == ',' {
+			// empty list elements are allowed by RFC 9110 section 5.6.1.
+			v = strings.TrimLeft(v[1:], " \t")
+			continue
+		}
+		tag, weak, rest, ok := cutETag(v)
+		if !ok {
+			return nil, ErrInvalidETag
+		}
+		empty = false
+		if version, err := strconv.Atoi(tag); err == nil && !weak {
+			m.Versions = append(m.Versions, version)
+		}
+		v = strings.TrimLeft(rest, " \t")
+		if v != "" && v[0] != ',' {
+			return nil, ErrInvalidETag
+		}
+	}
+	if empty {
+		return nil, ErrInvalidETag
+	}
+	return m, nil
+}
+
+// cutETag cuts the entity tag at the beginning of v and returns its opaque-tag without quotes, whether it is weak and the rest of v.
+func cutETag(v string) (tag string, weak bool, rest string, ok bool) {
+	if strings.HasPrefix(v, "W/") {
+		weak = true
+		v = v[2:]
+	}
+	if v == "" || v[0] != '"' {
+		return "", false, "", false
+	}
+	end := strings.IndexByte(v[1:], '"')
+	if end < 0 {
+		return "", false, "", false
+	}
+	tag = v[1 : end+1]
+	for i := 0; i < len(tag); i++ {
+		// etagc is %x21 / %x23-7E / obs-text.
+		if c := tag[i]; c < 0x21 || c == 0x7f {
+			return "", false, "", false
+		}
+	}
+	return tag, weak, v[end+2:], true
+}
+
+// noneMatch reports whether If-None-Match header matches etag using the weak comparison,
+// that is the response should be 304 Not Modified.
+func noneMatch(h http.Header, etag string) bool {
+	v := h.Get("If-None-Match")
+	if v == "" {
+		return false
+	}
+	if strings.TrimSpace(v) == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(v, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/sample"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	versions := func(v ...int) *sample.IfMatch { return &sample.IfMatch{Versions: v} }
+	tests := map[string]struct {
+		header  []string
+		want    *sample.IfMatch
+		wantErr error
+	}{
+		"absent":                      {want: nil},
+		"any":                         {header: []string{"*"}, want: &sample.IfMatch{Any: true}},
+		"strong etag":                 {header: []string{`"3"`}, want: versions(3)},
+		"list of etags":               {header: []string{`"1", "3",W/"4"`}, want: versions(1, 3)},
+		"list in multiple headers":    {header: []string{`"1"`, `"3"`}, want: versions(1, 3)},
+		"empty list elements":         {header: []string{`, "1" ,, "3"`}, want: versions(1, 3)},
+		"weak etag never matches":     {header: []string{`W/"3"`}, want: versions()},
+		"not a version never matches": {header: []string{`"abc"`}, want: versions()},
+		"comma in etag":               {header: []string{`"1,3"`}, want: versions()},
+		"empty":                       {header: []string{" "}, wantErr: ErrInvalidETag},
+		"only commas":                 {header: []string{","}, wantErr: ErrInvalidETag},
+		"unquoted":                    {header: []string{"3"}, wantErr: ErrInvalidETag},
+		"unterminated":                {header: []string{`"3`}, wantErr: ErrInvalidETag},
+		"missing comma":               {header: []string{`"1" "3"`}, wantErr: ErrInvalidETag},
+		"space in etag":               {header: []string{`"1 3"`}, wantErr: ErrInvalidETag},
+		"any with etags":              {header: []string{`*, "3"`}, wantErr: ErrInvalidETag},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			h := http.Header{}
+			for _, v := range tt.header {
+				h.Add("If-Match", v)
+			}
+			got, err := parseIfMatch(h)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIfMatch_Matches(t *testing.T) {
+	var none *sample.IfMatch
+	assert.True(t, none.Matches(3))
+	assert.True(t, (&sample.IfMatch{Any: true}).Matches(3))
+	assert.True(t, (&sample.IfMatch{Versions: []int{1, 3}}).Matches(3))
+	assert.False(t, (&sample.IfMatch{Versions: []int{1, 2}}).Matches(3))
+	assert.False(t, (&sample.IfMatch{Versions: []int{}}).Matches(3))
+}
+
+func TestNoneMatch(t *testing.T) {
+	tests := map[string]struct {
+		header string
+		want   bool
+	}{
+		"absent":            {header: "", want: false},
+		"any":               {header: "*", want: true},
+		"same etag":         {header: `"3"`, want: true},
+		"weak same etag":    {header: `W/"3"`, want: true},
+		"one of etags":      {header: `"1", "3"`, want: true},
+		"different etag":    {header: `"2"`, want: false},
+		"different etags":   {header: `"1", W/"2"`, want: false},
+		"unquoted same tag": {header: `3`, want: false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			h := http.Header{}
+			if tt.header != "" {
+				h.Set("If-None-Match", tt.header)
+			}
+			assert.Equal(t, tt.want, noneMatch(h, `"3"`))
+		})
+	}
+}
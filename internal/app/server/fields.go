@@ -0,0 +1,61 @@
+package server
+
+import (
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/sample"
+	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
+	"github.com/google/uuid"
+)
+
+// sampleView is model.Sample in JSON with only fields of a sparse fieldset.
+// The names and the order of fields are the same as model.Sample.
+type sampleView struct {
+	ID         *uuid.UUID `json:",omitempty"`
+	Name       *string    `json:",omitempty"`
+	Birthday   *time.Time `json:",omitempty"`
+	IsJapanese *bool      `json:",omitempty"`
+}
+
+func newSampleViews(samples []model.Sample, fields sample.Fields) []sampleView {
+	views := make([]sampleView, len(samples))
+	for i := range samples {
+		s := &samples[i]
+		if fields.Has(sample.FieldID) {
+			views[i].ID = &s.ID
+		}
+		if fields.Has(sample.FieldName) {
+			views[i].Name = &s.Name
+		}
+		if fields.Has(sample.FieldBirthday) {
+			views[i].Birthday = &s.Birthday
+		}
+		if fields.Has(sample.FieldIsJapanese) {
+			views[i].IsJapanese = &s.IsJapanese
+		}
+	}
+	return views
+}
+
+// projectPage returns page of which samples have only fields. It returns page as is if fields is nil.
+func projectPage(page interface{}, fields sample.Fields) interface{} {
+	if fields == nil {
+		return page
+	}
+	switch page := page.(type) {
+	case *model.PagedSamples:
+		return struct {
+			Total   int
+			Samples []sampleView
+		}{page.Total, newSampleViews(page.Samples, fields)}
+	case *model.CursorPagedSamples:
+		return struct {
+			Total   *int `json:",omitempty"`
+			Samples []sampleView
+			Next    string `json:",omitempty"`
+			Prev    string `json:",omitempty"`
+		}{page.Total, newSampleViews(page.Samples, fields), page.Next, page.Prev}
+	default:
+		return page
+	}
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/sample"
+	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectPage(t *testing.T) {
+	s := model.Sample{
+		ID:         uuid.MustParse("00000000-0000-0000-0000-000000000000"),
+		Name:       "test-japanese",
+		Birthday:   time.Date(1994, 9, 14, 0, 0, 0, 0, time.UTC),
+		IsJapanese: false,
+	}
+	total := 1
+	tests := map[string]struct {
+		page   interface{}
+		fields sample.Fields
+		want   string
+	}{
+		"all fields": {
+			page: &model.PagedSamples{Total: 1, Samples: []model.Sample{s}},
+			want: `{"Total":1,"Samples":[{"ID":"00000000-0000-0000-0000-000000000000","Name":"test-japanese","Birthday":"1994-09-14T00:00:00Z","IsJapanese":false}]}`,
+		},
+		"only fields in order of model": {
+			page:   &model.PagedSamples{Total: 1, Samples: []model.Sample{s}},
+			fields: sample.Fields{sample.FieldIsJapanese, sample.FieldID},
+			want:   `{"Total":1,"Samples":[{"ID":"00000000-0000-0000-0000-000000000000","IsJapanese":false}]}`,
+		},
+		"cursor paged samples": {
+			page:   &model.CursorPagedSamples{Total: &total, Samples: []model.Sample{s}, Next: "next"},
+			fields: sample.Fields{sample.FieldName},
+			want:   `{"Total":1,"Samples":[{"Name":"test-japanese"}],"Next":"next"}`,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := json.Marshal(projectPage(tt.page, tt.fields))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/Accel-Hack/go-api/internal/app/server/parser"
+	"github.com/Accel-Hack/go-api/internal/app/usercase/sample"
+)
+
+// parseSampleFilter parses query parameters of GET /samples as follows:
+//
+//	name           part of name to match in the match mode
+//	match          contains, prefix, suffix or exact (default contains)
+//	exact_name     name
+//	is_japanese    true or false
+//	born_after     date (2006-01-02)
+//	born_before    date (2006-01-02)
+//	birth_month    1-12
+//	birth_day      1-31
+//	created_after  date time (RFC 3339)
+//	created_before date time (RFC 3339)
+//	updated_after  date time (RFC 3339)
+//	updated_before date time (RFC 3339)
+//	include_deleted true or false (default false)
+func parseSampleFilter(query url.Values) (sample.SampleFilter, error) {
+	var (
+		parseName           = parser.QueryString().OrNil().Key("name")
+		parseMatch          = parser.QueryString().Key("match")
+		parseExactName      = parser.QueryString().OrNil().Key("exact_name")
+		parseIsJapanese     = parser.QueryBool().OrNil().Key("is_japanese")
+		parseBornAfter      = parser.QueryTime().OrNil().Key("born_after")
+		parseBornBefore     = parser.QueryTime().OrNil().Key("born_before")
+		parseBirthMonth     = parser.QueryInt().OrNil().Key("birth_month")
+		parseBirthDay       = parser.QueryInt().OrNil().Key("birth_day")
+		parseCreatedAfter   = parser.QueryDateTime().OrNil().Key("created_after")
+		parseCreatedBefore  = parser.QueryDateTime().OrNil().Key("created_before")
+		parseUpdatedAfter   = parser.QueryDateTime().OrNil().Key("updated_after")
+		parseUpdatedBefore  = parser.QueryDateTime().OrNil().Key("updated_before")
+		parseIncludeDeleted = parser.QueryBool().OrNil().Key("include_deleted")
+	)
+
+	var (
+		f   sample.SampleFilter
+		err error
+	)
+	if f.Name, err = parseName(query); err != nil {
+		return f, fmt.Errorf("parse name: %w", err)
+	}
+	match, err := parseMatch(query)
+	if err != nil {
+		return f, fmt.Errorf("parse match: %w", err)
+	}
+	if f.NameMatch, err = sample.ParseMatchMode(match); err != nil {
+		return f, fmt.Errorf("parse match: %w", err)
+	}
+	if f.ExactName, err = parseExactName(query); err != nil {
+		return f, fmt.Errorf("parse exact_name: %w", err)
+	}
+	if f.IsJapanese, err = parseIsJapanese(query); err != nil {
+		return f, fmt.Errorf("parse is_japanese: %w", err)
+	}
+	if f.BornAfter, err = parseBornAfter(query); err != nil {
+		return f, fmt.Errorf("parse born_after: %w", err)
+	}
+	if f.BornBefore, err = parseBornBefore(query); err != nil {
+		return f, fmt.Errorf("parse born_before: %w", err)
+	}
+	if f.BirthMonth, err = parseBirthMonth(query); err != nil {
+		return f, fmt.Errorf("parse birth_month: %w", err)
+	}
+	if f.BirthDay, err = parseBirthDay(query); err != nil {
+		return f, fmt.Errorf("parse birth_day: %w", err)
+	}
+	if f.CreatedAfter, err = parseCreatedAfter(query); err != nil {
+		return f, fmt.Errorf("parse created_after: %w", err)
+	}
+	if f.CreatedBefore, err = parseCreatedBefore(query); err != nil {
+		return f, fmt.Errorf("parse created_before: %w", err)
+	}
+	if f.UpdatedAfter, err = parseUpdatedAfter(query); err != nil {
+		return f, fmt.Errorf("parse updated_after: %w", err)
+	}
+	if f.UpdatedBefore, err = parseUpdatedBefore(query); err != nil {
+		return f, fmt.Errorf("parse updated_before: %w", err)
+	}
+	includeDeleted, err := parseIncludeDeleted(query)
+	if err != nil {
+		return f, fmt.Errorf("parse include_deleted: %w", err)
+	}
+	f.IncludeDeleted = includeDeleted != nil && *includeDeleted
+	return f, nil
+}
@@ -1,18 +1,29 @@
 package server
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
 	"log/slog"
 	"net/http"
 
 	"github.com/Accel-Hack/go-api/internal/app/server/parser"
 	"github.com/Accel-Hack/go-api/internal/app/usercase/sample"
+	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
 
+// maxPatchBytes is the maximum size of a patch document.
+const maxPatchBytes = 1 << 20
+
 type InternalSampleHandler struct {
 	Usecase sample.Usecase
 	Logger  *slog.Logger
+	// IdempotentDelete responds 204 No Content instead of 404 Not Found
+	// to DELETE of a sample which does not exist or is already deleted.
+	IdempotentDelete bool
 }
 
 func (h *InternalSampleHandler) Get(w http.ResponseWriter, r *http.Request) {
@@ -25,13 +36,32 @@ func (h *InternalSampleHandler) Get(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
-	sample, err := h.Usecase.Get(r.Context(), id)
-	if err != nil {
+	found, err := h.Usecase.Get(r.Context(), id)
+	switch {
+	case errors.Is(err, sample.ErrUnauthorized):
+		h.Logger.Info("get sample", "err", err)
+		writeProblem(w, http.StatusUnauthorized, err.Error())
+		return
+	case errors.Is(err, sample.ErrForbidden):
+		h.Logger.Info("get sample", "err", err)
+		writeProblem(w, http.StatusForbidden, err.Error())
+		return
+	case errors.Is(err, sample.ErrNotFound):
+		h.Logger.Error("get sample", "err", err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case err != nil:
 		h.Logger.Error("get sample", "err", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	if err := json.NewEncoder(w).Encode(sample); err != nil {
+	etag := sampleETag(found)
+	w.Header().Set("ETag", etag)
+	if noneMatch(r.Header, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(found); err != nil {
 		h.Logger.Error("encode sample to JSON", "err", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -40,15 +70,40 @@ func (h *InternalSampleHandler) Get(w http.ResponseWriter, r *http.Request) {
 
 func (h *InternalSampleHandler) Search(w http.ResponseWriter, r *http.Request) {
 	var (
-		parseName   = parser.QueryString().Required().Key("name")
+		parseSort   = parser.QueryString().Key("sort")
+		parseFields = parser.QueryString().Key("fields")
 		parseLimit  = parser.QueryInt().OrNil().Key("limit")
 		parseOffset = parser.QueryInt().OrNil().Key("offset")
 	)
 
 	query := r.URL.Query()
-	name, err := parseName(query)
+	filter, err := parseSampleFilter(query)
 	if err != nil {
-		h.Logger.Error("parse name", "err", err)
+		h.Logger.Error("parse filter", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	sortQuery, err := parseSort(query)
+	if err != nil {
+		h.Logger.Error("parse sort", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	sort, err := sample.ParseSort(sortQuery)
+	if err != nil {
+		h.Logger.Error("parse sort", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	fieldsQuery, err := parseFields(query)
+	if err != nil {
+		h.Logger.Error("parse fields", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	fields, err := sample.ParseFields(fieldsQuery)
+	if err != nil {
+		h.Logger.Error("parse fields", "err", err)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -59,6 +114,11 @@ func (h *InternalSampleHandler) Search(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if query.Has("cursor") {
+		h.searchByCursor(w, r, filter, sort, fields, limit)
+		return
+	}
+
 	offset, err := parseOffset(query)
 	if err != nil {
 		h.Logger.Error("parse offset", "err", err)
@@ -66,17 +126,152 @@ func (h *InternalSampleHandler) Search(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sample, err := h.Usecase.Search(r.Context(), name, limit, offset)
+	samples, err := h.Usecase.Search(r.Context(), filter, sort, fields, limit, offset)
+	if errors.Is(err, sample.ErrInvalid) {
+		h.Logger.Error("search samples", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, sample.ErrUnauthorized) {
+		h.Logger.Info("search samples", "err", err)
+		writeProblem(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if errors.Is(err, sample.ErrForbidden) {
+		h.Logger.Info("search samples", "err", err)
+		writeProblem(w, http.StatusForbidden, err.Error())
+		return
+	}
 	if err != nil {
-		h.Logger.Error("get sample", "err", err)
+		h.Logger.Error("search samples", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	h.writePage(w, r, projectPage(samples, fields))
+}
+
+// searchByCursor responds a page of samples next to the cursor in the query.
+// An empty cursor means the first page. The total count is included only if include_total is true.
+func (h *InternalSampleHandler) searchByCursor(w http.ResponseWriter, r *http.Request, filter sample.SampleFilter, sort []sample.SortKey, fields sample.Fields, limit *int) {
+	var (
+		parseCursor       = parser.QueryString().Required().Key("cursor")
+		parseIncludeTotal = parser.QueryBool().OrNil().Key("include_total")
+	)
+
+	query := r.URL.Query()
+	if query.Has("offset") {
+		h.Logger.Error("parse offset", "err", "offset cannot be used with cursor")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	cursor, err := parseCursor(query)
+	if err != nil {
+		h.Logger.Error("parse cursor", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	includeTotal, err := parseIncludeTotal(query)
+	if err != nil {
+		h.Logger.Error("parse include_total", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	samples, err := h.Usecase.SearchByCursor(r.Context(), filter, sort, fields, cursor, limit, includeTotal != nil && *includeTotal)
+	if errors.Is(err, sample.ErrInvalid) {
+		h.Logger.Error("search samples", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, sample.ErrUnauthorized) {
+		h.Logger.Info("search samples", "err", err)
+		writeProblem(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if errors.Is(err, sample.ErrForbidden) {
+		h.Logger.Info("search samples", "err", err)
+		writeProblem(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		h.Logger.Error("search samples", "err", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	if err := json.NewEncoder(w).Encode(sample); err != nil {
+	h.writePage(w, r, projectPage(samples, fields))
+}
+
+// writePage writes page as JSON with ETag derived from the body, or 304 Not Modified if If-None-Match matches it.
+func (h *InternalSampleHandler) writePage(w http.ResponseWriter, r *http.Request, page interface{}) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(page); err != nil {
 		h.Logger.Error("encode sample to JSON", "err", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	etag := bodyETag(body.Bytes())
+	w.Header().Set("ETag", etag)
+	if noneMatch(r.Header, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if _, err := body.WriteTo(w); err != nil {
+		h.Logger.Error("write samples", "err", err)
+	}
+}
+
+// FullTextSearch responds samples whose names are relevant to q in order of relevance with highlighted names.
+func (h *InternalSampleHandler) FullTextSearch(w http.ResponseWriter, r *http.Request) {
+	var (
+		parseQ      = parser.QueryString().Required().Key("q")
+		parseLimit  = parser.QueryInt().OrNil().Key("limit")
+		parseOffset = parser.QueryInt().OrNil().Key("offset")
+	)
+
+	query := r.URL.Query()
+	q, err := parseQ(query)
+	if err != nil {
+		h.Logger.Error("parse q", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	limit, err := parseLimit(query)
+	if err != nil {
+		h.Logger.Error("parse limit", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	offset, err := parseOffset(query)
+	if err != nil {
+		h.Logger.Error("parse offset", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.Usecase.FullTextSearch(r.Context(), q, limit, offset)
+	switch {
+	case errors.Is(err, sample.ErrInvalid):
+		h.Logger.Error("full-text search samples", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	case errors.Is(err, errors.ErrUnsupported):
+		h.Logger.Error("full-text search samples", "err", err)
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	case errors.Is(err, sample.ErrUnauthorized):
+		h.Logger.Info("full-text search samples", "err", err)
+		writeProblem(w, http.StatusUnauthorized, err.Error())
+		return
+	case errors.Is(err, sample.ErrForbidden):
+		h.Logger.Info("full-text search samples", "err", err)
+		writeProblem(w, http.StatusForbidden, err.Error())
+		return
+	case err != nil:
+		h.Logger.Error("full-text search samples", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	h.writePage(w, r, result)
 }
 
 func (h *InternalSampleHandler) Add(w http.ResponseWriter, r *http.Request) {
@@ -110,7 +305,16 @@ func (h *InternalSampleHandler) Add(w http.ResponseWriter, r *http.Request) {
 		Birthday:   birthday,
 		IsJapanese: isJapanese,
 	})
-	if err != nil {
+	switch {
+	case errors.Is(err, sample.ErrUnauthorized):
+		h.Logger.Info("add new sample", "err", err)
+		writeProblem(w, http.StatusUnauthorized, err.Error())
+		return
+	case errors.Is(err, sample.ErrForbidden):
+		h.Logger.Info("add new sample", "err", err)
+		writeProblem(w, http.StatusForbidden, err.Error())
+		return
+	case err != nil:
 		h.Logger.Error("add new sample", "err", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -157,17 +361,47 @@ func (h *InternalSampleHandler) Edit(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
+	ifMatch, err := parseIfMatch(r.Header)
+	if err != nil {
+		h.Logger.Error("parse If-Match", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
 
-	if err := h.Usecase.Edit(r.Context(), sample.UpdateQuery{
+	updated, err := h.Usecase.Edit(r.Context(), sample.UpdateQuery{
 		ID:         id,
 		Name:       name,
 		Birthday:   birthday,
 		IsJapanese: isJapanese,
-	}); err != nil {
+		IfMatch:    ifMatch,
+	})
+	switch {
+	case errors.Is(err, sample.ErrUnauthorized):
+		h.Logger.Info("edit sample", "err", err)
+		writeProblem(w, http.StatusUnauthorized, err.Error())
+		return
+	case errors.Is(err, sample.ErrForbidden):
+		h.Logger.Info("edit sample", "err", err)
+		writeProblem(w, http.StatusForbidden, err.Error())
+		return
+	case errors.Is(err, sample.ErrNotFound):
+		h.Logger.Error("edit sample", "err", err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case errors.Is(err, sample.ErrConflict) && ifMatch != nil:
+		h.Logger.Error("edit sample", "err", err)
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	case errors.Is(err, sample.ErrConflict):
+		h.Logger.Error("edit sample", "err", err)
+		w.WriteHeader(http.StatusConflict)
+		return
+	case err != nil:
 		h.Logger.Error("edit sample", "err", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	w.Header().Set("ETag", sampleETag(updated))
 	if err := json.NewEncoder(w).Encode(map[string]interface{}{
 		"id": id,
 	}); err != nil {
@@ -177,8 +411,79 @@ func (h *InternalSampleHandler) Edit(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h *InternalSampleHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.Logger.Error("parse id", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	mediaType, err := patchMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		h.Logger.Error("parse Content-Type", "err", err)
+		w.Header().Set("Accept-Patch", ContentTypeMergePatch+", "+ContentTypeJSONPatch)
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+	ifMatch, err := parseIfMatch(r.Header)
+	if err != nil {
+		h.Logger.Error("parse If-Match", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	patch, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchBytes))
+	if err != nil {
+		h.Logger.Error("read patch", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	patched, err := h.Usecase.Patch(r.Context(), id, ifMatch, func(s model.Sample) (*model.Sample, error) {
+		return applyPatch(mediaType, patch, s)
+	})
+	switch {
+	case errors.Is(err, sample.ErrUnauthorized):
+		h.Logger.Info("patch sample", "err", err)
+		writeProblem(w, http.StatusUnauthorized, err.Error())
+		return
+	case errors.Is(err, sample.ErrForbidden):
+		h.Logger.Info("patch sample", "err", err)
+		writeProblem(w, http.StatusForbidden, err.Error())
+		return
+	case errors.Is(err, sample.ErrNotFound):
+		h.Logger.Error("patch sample", "err", err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case errors.Is(err, sample.ErrConflict) && ifMatch != nil:
+		h.Logger.Error("patch sample", "err", err)
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	case errors.Is(err, sample.ErrConflict):
+		h.Logger.Error("patch sample", "err", err)
+		w.WriteHeader(http.StatusConflict)
+		return
+	case errors.Is(err, ErrInvalidPatch), errors.Is(err, sample.ErrInvalid):
+		h.Logger.Error("patch sample", "err", err)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		h.Logger.Error("patch sample", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", sampleETag(patched))
+	if err := json.NewEncoder(w).Encode(patched); err != nil {
+		h.Logger.Error("encode sample to JSON", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
 func (h *InternalSampleHandler) Delete(w http.ResponseWriter, r *http.Request) {
-	parseID := parser.QueryUUID().Required().Key("id")
+	var (
+		parseID   = parser.QueryUUID().Required().Key("id")
+		parseHard = parser.QueryBool().OrNil().Key("hard")
+	)
 
 	query := r.URL.Query()
 	uid, err := parseID(query)
@@ -187,13 +492,269 @@ func (h *InternalSampleHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
+	hard, err := parseHard(query)
+	if err != nil {
+		h.Logger.Error("parse hard", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
 
-	if err := h.Usecase.Delete(r.Context(), uid); err != nil {
+	ifMatch, err := parseIfMatch(r.Header)
+	if err != nil {
+		h.Logger.Error("parse If-Match", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if hard != nil && *hard {
+		err = h.Usecase.HardDelete(r.Context(), uid, ifMatch)
+	} else {
+		err = h.Usecase.Delete(r.Context(), uid, ifMatch)
+	}
+	switch {
+	case errors.Is(err, sample.ErrNotFound) && h.IdempotentDelete:
+		h.Logger.Info("delete sample", "err", err)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case errors.Is(err, sample.ErrNotFound):
+		h.Logger.Error("delete sample", "err", err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case errors.Is(err, sample.ErrConflict):
 		h.Logger.Error("delete sample", "err", err)
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	case errors.Is(err, sample.ErrUnauthorized):
+		h.Logger.Info("delete sample", "err", err)
+		writeProblem(w, http.StatusUnauthorized, err.Error())
+		return
+	case errors.Is(err, sample.ErrForbidden):
+		h.Logger.Info("delete sample", "err", err)
+		writeProblem(w, http.StatusForbidden, err.Error())
+		return
+	case err != nil:
+		h.Logger.Error("delete sample", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeleted responds soft-deleted samples with the time when they are deleted.
+func (h *InternalSampleHandler) ListDeleted(w http.ResponseWriter, r *http.Request) {
+	var (
+		parseLimit  = parser.QueryInt().OrNil().Key("limit")
+		parseOffset = parser.QueryInt().OrNil().Key("offset")
+	)
+
+	query := r.URL.Query()
+	limit, err := parseLimit(query)
+	if err != nil {
+		h.Logger.Error("parse limit", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	offset, err := parseOffset(query)
+	if err != nil {
+		h.Logger.Error("parse offset", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	samples, err := h.Usecase.ListDeleted(r.Context(), limit, offset)
+	switch {
+	case errors.Is(err, sample.ErrUnauthorized):
+		h.Logger.Info("list deleted samples", "err", err)
+		writeProblem(w, http.StatusUnauthorized, err.Error())
+		return
+	case errors.Is(err, sample.ErrForbidden):
+		h.Logger.Info("list deleted samples", "err", err)
+		writeProblem(w, http.StatusForbidden, err.Error())
+		return
+	case err != nil:
+		h.Logger.Error("list deleted samples", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	h.writePage(w, r, samples)
+}
+
+// Restore restores the soft-deleted sample and responds it.
+func (h *InternalSampleHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.Logger.Error("parse id", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	restored, err := h.Usecase.Restore(r.Context(), id)
+	switch {
+	case errors.Is(err, sample.ErrUnauthorized):
+		h.Logger.Info("restore sample", "err", err)
+		writeProblem(w, http.StatusUnauthorized, err.Error())
+		return
+	case errors.Is(err, sample.ErrForbidden):
+		h.Logger.Info("restore sample", "err", err)
+		writeProblem(w, http.StatusForbidden, err.Error())
+		return
+	case errors.Is(err, sample.ErrNotFound):
+		h.Logger.Error("restore sample", "err", err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case err != nil:
+		h.Logger.Error("restore sample", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", sampleETag(restored))
+	if err := json.NewEncoder(w).Encode(restored); err != nil {
+		h.Logger.Error("encode sample to JSON", "err", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
+}
+
+// History responds the history events of the sample in descending order of time.
+func (h *InternalSampleHandler) History(w http.ResponseWriter, r *http.Request) {
+	var (
+		parseLimit  = parser.QueryInt().OrNil().Key("limit")
+		parseOffset = parser.QueryInt().OrNil().Key("offset")
+	)
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.Logger.Error("parse id", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	query := r.URL.Query()
+	limit, err := parseLimit(query)
+	if err != nil {
+		h.Logger.Error("parse limit", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	offset, err := parseOffset(query)
+	if err != nil {
+		h.Logger.Error("parse offset", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	history, err := h.Usecase.SampleHistory(r.Context(), id, limit, offset)
+	switch {
+	case errors.Is(err, errors.ErrUnsupported):
+		h.Logger.Error("find sample history", "err", err)
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	case errors.Is(err, sample.ErrUnauthorized):
+		h.Logger.Info("find sample history", "err", err)
+		writeProblem(w, http.StatusUnauthorized, err.Error())
+		return
+	case errors.Is(err, sample.ErrForbidden):
+		h.Logger.Info("find sample history", "err", err)
+		writeProblem(w, http.StatusForbidden, err.Error())
+		return
+	case errors.Is(err, sample.ErrNotFound):
+		h.Logger.Error("find sample history", "err", err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case err != nil:
+		h.Logger.Error("find sample history", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	h.writePage(w, r, history)
+}
+
+// SearchHistory responds the history events of all samples including deleted ones for audit.
+func (h *InternalSampleHandler) SearchHistory(w http.ResponseWriter, r *http.Request) {
+	var (
+		parseSampleID  = parser.QueryUUID().OrNil().Key("sample_id")
+		parseActorID   = parser.QueryUUID().OrNil().Key("actor_id")
+		parseAction    = parser.QueryString().Key("action")
+		parseRequestID = parser.QueryString().Key("request_id")
+		parseSince     = parser.QueryDateTime().OrNil().Key("since")
+		parseUntil     = parser.QueryDateTime().OrNil().Key("until")
+		parseLimit     = parser.QueryInt().OrNil().Key("limit")
+		parseOffset    = parser.QueryInt().OrNil().Key("offset")
+	)
+
+	query := r.URL.Query()
+	var (
+		q   sample.HistoryQuery
+		err error
+	)
+	if q.SampleID, err = parseSampleID(query); err != nil {
+		h.Logger.Error("parse sample_id", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if q.ActorID, err = parseActorID(query); err != nil {
+		h.Logger.Error("parse actor_id", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	action, err := parseAction(query)
+	if err != nil {
+		h.Logger.Error("parse action", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	q.Action = model.Action(action)
+	if q.RequestID, err = parseRequestID(query); err != nil {
+		h.Logger.Error("parse request_id", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if q.Since, err = parseSince(query); err != nil {
+		h.Logger.Error("parse since", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if q.Until, err = parseUntil(query); err != nil {
+		h.Logger.Error("parse until", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	limit, err := parseLimit(query)
+	if err != nil {
+		h.Logger.Error("parse limit", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	offset, err := parseOffset(query)
+	if err != nil {
+		h.Logger.Error("parse offset", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	history, err := h.Usecase.SearchHistory(r.Context(), q, limit, offset)
+	switch {
+	case errors.Is(err, sample.ErrInvalid):
+		h.Logger.Error("search history", "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	case errors.Is(err, errors.ErrUnsupported):
+		h.Logger.Error("search history", "err", err)
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	case errors.Is(err, sample.ErrUnauthorized):
+		h.Logger.Info("search history", "err", err)
+		writeProblem(w, http.StatusUnauthorized, err.Error())
+		return
+	case errors.Is(err, sample.ErrForbidden):
+		h.Logger.Info("search history", "err", err)
+		writeProblem(w, http.StatusForbidden, err.Error())
+		return
+	case err != nil:
+		h.Logger.Error("search history", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	h.writePage(w, r, history)
 }
 
 // Route registers routes to mux.Router.
@@ -203,6 +764,11 @@ func (h *InternalSampleHandler) Delete(w http.ResponseWriter, r *http.Request) {
 //	PUT    /sample
 //	DELETE /sample
 //	GET    /samples
+//	GET    /samples/search
+//	GET    /samples/deleted
+//	PATCH  /samples/{id}
+//	POST   /samples/{id}/restore
+//	GET    /samples/{id}/history
 func (h *InternalSampleHandler) Route(mux *mux.Router) {
 	h.Logger.Info(`expose GET "/sample"`)
 	mux.HandleFunc("/sample", h.Get).Methods(http.MethodGet)
@@ -214,4 +780,23 @@ func (h *InternalSampleHandler) Route(mux *mux.Router) {
 	mux.HandleFunc("/sample", h.Delete).Methods(http.MethodDelete)
 	h.Logger.Info(`expose GET "/samples"`)
 	mux.HandleFunc("/samples", h.Search).Methods(http.MethodGet)
+	h.Logger.Info(`expose GET "/samples/search"`)
+	mux.HandleFunc("/samples/search", h.FullTextSearch).Methods(http.MethodGet)
+	h.Logger.Info(`expose GET "/samples/deleted"`)
+	mux.HandleFunc("/samples/deleted", h.ListDeleted).Methods(http.MethodGet)
+	h.Logger.Info(`expose PATCH "/samples/{id}"`)
+	mux.HandleFunc("/samples/{id}", h.Patch).Methods(http.MethodPatch)
+	h.Logger.Info(`expose POST "/samples/{id}/restore"`)
+	mux.HandleFunc("/samples/{id}/restore", h.Restore).Methods(http.MethodPost)
+	h.Logger.Info(`expose GET "/samples/{id}/history"`)
+	mux.HandleFunc("/samples/{id}/history", h.History).Methods(http.MethodGet)
+}
+
+// RouteAudit registers the route to search the history of all samples to mux.Router,
+// which must authenticate callers because the usecase allows anyone without Policy.
+//
+//	GET    /admin/audit
+func (h *InternalSampleHandler) RouteAudit(mux *mux.Router) {
+	h.Logger.Info(`expose GET "/admin/audit"`)
+	mux.HandleFunc("/admin/audit", h.SearchHistory).Methods(http.MethodGet)
 }
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/auth"
+	"github.com/Accel-Hack/go-api/internal/app/usercase/sample"
+	authmodel "github.com/Accel-Hack/go-api/internal/domain/auth/model"
+	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInternalSampleHandler_Get(t *testing.T) {
+	var (
+		owner = uuid.MustParse("00000000-0000-0000-0000-000000000101")
+		other = uuid.MustParse("00000000-0000-0000-0000-000000000102")
+		owned = model.Sample{ID: uuid.MustParse("00000000-0000-0000-0000-000000000000"), Name: "test-japanese", Birthday: time.Date(1994, 9, 14, 0, 0, 0, 0, time.UTC), Version: 1, OwnerID: &owner}
+	)
+	h := InternalSampleHandler{
+		Usecase: sample.Usecase{
+			Repository: &memorySampleRepository{samples: []model.Sample{owned}},
+			Policy:     auth.DefaultActorPolicy(),
+		},
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	user := func(id uuid.UUID) *authmodel.User {
+		u, err := authmodel.NewUser(id, "user-"+id.String(), "hash", authmodel.ActorUser, nil, nil, nil)
+		assert.NoError(t, err)
+		return u
+	}
+
+	tests := map[string]struct {
+		user     *authmodel.User
+		id       uuid.UUID
+		wantCode int
+	}{
+		"return the sample of the owner": {
+			user:     user(owner),
+			id:       owned.ID,
+			wantCode: http.StatusOK,
+		},
+		"return 404 when the sample is not owned": {
+			user:     user(other),
+			id:       owned.ID,
+			wantCode: http.StatusNotFound,
+		},
+		"return 404 when the sample does not exist": {
+			user:     user(owner),
+			id:       uuid.MustParse("00000000-0000-0000-0000-000000000009"),
+			wantCode: http.StatusNotFound,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/sample?id="+tt.id.String(), nil)
+			req = req.WithContext(auth.WithUser(req.Context(), tt.user))
+			rec := httptest.NewRecorder()
+			h.Get(rec, req)
+			assert.Equal(t, tt.wantCode, rec.Code)
+			if tt.wantCode == http.StatusOK {
+				var got model.Sample
+				assert.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+				assert.Equal(t, owned.ID, got.ID)
+			}
+		})
+	}
+}
+
+func TestInternalSampleHandler_Patch(t *testing.T) {
+	stored := model.Sample{ID: uuid.MustParse("00000000-0000-0000-0000-000000000000"), Name: "test-japanese", Birthday: time.Date(1994, 9, 14, 0, 0, 0, 0, time.UTC), Version: 1}
+	h := InternalSampleHandler{
+		Usecase: sample.Usecase{Repository: &memorySampleRepository{samples: []model.Sample{stored}}},
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	tests := map[string]struct {
+		id        uuid.UUID
+		mediaType string
+		patch     string
+		ifMatch   string
+		wantCode  int
+	}{
+		"return 422 when merge patch empties name": {
+			mediaType: ContentTypeMergePatch,
+			patch:     `{"Name":""}`,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		"return 422 when merge patch removes name": {
+			mediaType: ContentTypeMergePatch,
+			patch:     `{"name":null}`,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		"return 422 when json patch empties name": {
+			mediaType: ContentTypeJSONPatch,
+			patch:     `[{"op":"replace","path":"/Name","value":""}]`,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		"return 400 when If-Match is malformed": {
+			mediaType: ContentTypeMergePatch,
+			patch:     `{"name":"patched"}`,
+			ifMatch:   `"1" "2"`,
+			wantCode:  http.StatusBadRequest,
+		},
+		"return 412 when If-Match has only a weak etag": {
+			mediaType: ContentTypeMergePatch,
+			patch:     `{"name":"patched"}`,
+			ifMatch:   `W/"1"`,
+			wantCode:  http.StatusPreconditionFailed,
+		},
+		"return 412 when If-Match is any and the sample does not exist": {
+			id:        uuid.MustParse("00000000-0000-0000-0000-000000000009"),
+			mediaType: ContentTypeMergePatch,
+			patch:     `{"name":"patched"}`,
+			ifMatch:   "*",
+			wantCode:  http.StatusPreconditionFailed,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			id := stored.ID
+			if tt.id != uuid.Nil {
+				id = tt.id
+			}
+			req := httptest.NewRequest(http.MethodPatch, "/samples/"+id.String(), strings.NewReader(tt.patch))
+			req.Header.Set("Content-Type", tt.mediaType)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			req = mux.SetURLVars(req, map[string]string{"id": id.String()})
+			rec := httptest.NewRecorder()
+			h.Patch(rec, req)
+			assert.Equal(t, tt.wantCode, rec.Code)
+		})
+	}
+}
+
+// memorySampleRepository finds samples limited by the access in ctx as repositories do in queries.
+// The other methods are not implemented so that tests fail if samples are saved.
+type memorySampleRepository struct {
+	sample.SampleRepository
+	samples []model.Sample
+}
+
+// FindByID implements sample.SampleRepository.
+func (r *memorySampleRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Sample, error) {
+	for _, s := range r.samples {
+		if s.ID == id && sample.AccessFrom(ctx).Allows(&s) {
+			return &s, nil
+		}
+	}
+	return nil, sample.ErrNotFound
+}
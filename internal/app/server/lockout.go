@@ -0,0 +1,68 @@
+package server
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/auth"
+)
+
+// clientIP returns the IP of the client of r, by which login failures are counted.
+func (h *AuthHandler) clientIP(r *http.Request) string {
+	value := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		value = host
+	}
+	if h.ClientIPHeader != "" {
+		if header := r.Header.Get(h.ClientIPHeader); header != "" {
+			// the last value is appended by the nearest proxy, and the others can be forged by the client.
+			values := strings.Split(header, ",")
+			value = strings.TrimSpace(values[len(values)-1])
+		}
+	}
+	ip, ok := normalizeIP(value)
+	if !ok {
+		return ""
+	}
+	return ip
+}
+
+// normalizeIP returns the IP of value, whose IPv6 address is masked to /64 because a client usually has the whole prefix.
+func normalizeIP(value string) (string, bool) {
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return "", false
+	}
+	addr = addr.Unmap()
+	if addr.Is6() {
+		return netip.PrefixFrom(addr, 64).Masked().String(), true
+	}
+	return addr.String(), true
+}
+
+// retryAfter returns the value of Retry-After header in seconds until until, which is at least 1.
+func retryAfter(until time.Time) string {
+	return strconv.Itoa(max(1, int(math.Ceil(time.Until(until).Seconds()))))
+}
+
+// UnlockLogin unlocks login of the user name of "user_name" parameter and the client IP of "ip" parameter.
+func (h *AuthHandler) UnlockLogin(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	ip := query.Get("ip")
+	if normalized, ok := normalizeIP(ip); ok {
+		ip = normalized
+	}
+	err := h.Usecase.UnlockLogin(r.Context(), auth.UnlockQuery{
+		UserName: query.Get("user_name"),
+		IP:       ip,
+	})
+	if h.writeAdminError(w, "unlock login", err) {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Accel-Hack/go-api/internal/app/infra/repository"
+	"github.com/Accel-Hack/go-api/internal/app/usercase/auth"
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAuthHandler_clientIP(t *testing.T) {
+	tests := map[string]struct {
+		header     string
+		remoteAddr string
+		value      string
+		want       string
+	}{
+		"use the remote address": {
+			remoteAddr: "192.0.2.1:12345",
+			want:       "192.0.2.1",
+		},
+		"mask IPv6 address to /64": {
+			remoteAddr: "[2001:db8:1:2:3:4:5:6]:12345",
+			want:       "2001:db8:1:2::/64",
+		},
+		"use the last value of the header": {
+			header:     "X-Forwarded-For",
+			remoteAddr: "10.0.0.1:12345",
+			value:      "198.51.100.1, 192.0.2.1",
+			want:       "192.0.2.1",
+		},
+		"use the remote address without the header": {
+			header:     "X-Forwarded-For",
+			remoteAddr: "10.0.0.1:12345",
+			want:       "10.0.0.1",
+		},
+		"ignore the header unless it is configured": {
+			remoteAddr: "10.0.0.1:12345",
+			value:      "192.0.2.1",
+			want:       "10.0.0.1",
+		},
+		"return empty for an invalid address": {
+			header:     "X-Forwarded-For",
+			remoteAddr: "10.0.0.1:12345",
+			value:      "unknown",
+			want:       "",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.value != "" {
+				req.Header.Set("X-Forwarded-For", tt.value)
+			}
+			assert.Equal(t, tt.want, (&AuthHandler{ClientIPHeader: tt.header}).clientIP(req))
+		})
+	}
+}
+
+func TestAuthHandler_Login_Lockout(t *testing.T) {
+	users := repository.NewUserMemory()
+	signer, err := auth.NewHS256(nil, "test")
+	assert.NoError(t, err)
+	hasher := auth.Bcrypt{Cost: bcrypt.MinCost}
+	for name, actor := range map[string]model.Actor{"kawamura": model.ActorUser, "system": model.ActorSystem} {
+		hash, err := hasher.Hash("password")
+		assert.NoError(t, err)
+		user, err := model.NewUser(uuid.New(), name, hash, actor, nil, nil, nil)
+		assert.NoError(t, err)
+		assert.NoError(t, users.Insert(context.Background(), user))
+	}
+	h := &AuthHandler{
+		Usecase: auth.Usecase{
+			Repository: users,
+			Hasher:     hasher,
+			Tokens:     &auth.TokenService{Repository: repository.NewTokenMemory(), Users: users, JWT: signer},
+			Throttle:   &auth.LoginThrottle{Repository: repository.NewLoginAttemptMemory(), MaxUserFailures: 2},
+		},
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	router := mux.NewRouter()
+	h.Route(router)
+	login := func(userName, password string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/auth/login",
+			strings.NewReader(`{"user_name":"`+userName+`","password":"`+password+`"}`)))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, login("kawamura", "wrong-password").Code)
+	assert.Equal(t, http.StatusUnauthorized, login("kawamura", "wrong-password").Code)
+	rec := login("kawamura", "password")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+
+	unlock := func(token string) int {
+		req := httptest.NewRequest(http.MethodDelete, "/admin/lockouts?user_name=kawamura", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	user, err := users.FindByUserName(context.Background(), "kawamura")
+	assert.NoError(t, err)
+	issued, err := h.Usecase.Tokens.Issue(context.Background(), user)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, unlock(issued.AccessToken()))
+	system, err := users.FindByUserName(context.Background(), "system")
+	assert.NoError(t, err)
+	issued, err = h.Usecase.Tokens.Issue(context.Background(), system)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, unlock(issued.AccessToken()))
+	assert.Equal(t, http.StatusNotFound, unlock(issued.AccessToken()))
+
+	assert.Equal(t, http.StatusOK, login("kawamura", "password").Code)
+}
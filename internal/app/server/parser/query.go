@@ -92,3 +92,10 @@ func QueryTime() Parse[time.Time] {
 		return time.ParseInLocation(time.DateOnly, s, time.Local)
 	}
 }
+
+func QueryDateTime() Parse[time.Time] {
+	return func(key string, vs url.Values) (time.Time, error) {
+		s := vs.Get(key)
+		return time.Parse(time.RFC3339, s)
+	}
+}
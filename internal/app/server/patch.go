@@ -0,0 +1,111 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// ContentTypeMergePatch is the media type of JSON Merge Patch defined in RFC 7396.
+	ContentTypeMergePatch = "application/merge-patch+json"
+	// ContentTypeJSONPatch is the media type of JSON Patch defined in RFC 6902.
+	ContentTypeJSONPatch = "application/json-patch+json"
+)
+
+var (
+	ErrUnsupportedPatch = errors.New("unsupported patch media type")
+	ErrInvalidPatch     = errors.New("invalid patch")
+)
+
+// patchedSample is a JSON document of model.Sample after patched. All fields are required.
+type patchedSample struct {
+	ID         *uuid.UUID
+	Name       *string
+	Birthday   *time.Time
+	IsJapanese *bool
+}
+
+// sampleMembers are the members of the JSON document of model.Sample.
+// They are matched case-sensitively unlike encoding/json so that patches cannot set fields by other names such as "name".
+var sampleMembers = map[string]bool{"ID": true, "Name": true, "Birthday": true, "IsJapanese": true}
+
+// checkMembers returns an error if doc is not a JSON object or has a member other than sampleMembers.
+func checkMembers(doc []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(doc, &members); err != nil {
+		return err
+	}
+	for name := range members {
+		if !sampleMembers[name] {
+			return fmt.Errorf("unknown member %q", name)
+		}
+	}
+	return nil
+}
+
+// patchMediaType returns the media type of contentType if it is one of supported patch formats.
+func patchMediaType(contentType string) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrUnsupportedPatch, err)
+	}
+	switch mediaType {
+	case ContentTypeMergePatch, ContentTypeJSONPatch:
+		return mediaType, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedPatch, mediaType)
+	}
+}
+
+// applyPatch applies patch of mediaType to the JSON document of s.
+func applyPatch(mediaType string, patch []byte, s model.Sample) (*model.Sample, error) {
+	doc, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	switch mediaType {
+	case ContentTypeMergePatch:
+		// members of the patch are checked as well because null removes nothing if the member is unknown.
+		if err = checkMembers(patch); err == nil {
+			doc, err = jsonpatch.MergePatch(doc, patch)
+		}
+	case ContentTypeJSONPatch:
+		var p jsonpatch.Patch
+		if p, err = jsonpatch.DecodePatch(patch); err == nil {
+			doc, err = p.Apply(doc)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedPatch, mediaType)
+	}
+	if err == nil {
+		err = checkMembers(doc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+	}
+
+	var patched patchedSample
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&patched); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+	}
+	if patched.ID == nil || patched.Name == nil || patched.Birthday == nil || patched.IsJapanese == nil {
+		return nil, fmt.Errorf("%w: ID, Name, Birthday and IsJapanese cannot be removed", ErrInvalidPatch)
+	}
+	return &model.Sample{
+		ID:         *patched.ID,
+		Name:       *patched.Name,
+		Birthday:   *patched.Birthday,
+		IsJapanese: *patched.IsJapanese,
+		Version:    s.Version,
+	}, nil
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyPatch(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	s := model.Sample{
+		ID:         uuid.MustParse("00000000-0000-0000-0000-000000000000"),
+		Name:       "test-japanese",
+		Birthday:   time.Date(1994, 9, 14, 0, 0, 0, 0, jst),
+		IsJapanese: true,
+		Version:    2,
+	}
+	tests := map[string]struct {
+		mediaType string
+		patch     string
+		want      *model.Sample
+		wantErr   error
+	}{
+		"merge patch sets fields": {
+			mediaType: ContentTypeMergePatch,
+			patch:     `{"Name":"","IsJapanese":false}`,
+			want:      &model.Sample{ID: s.ID, Name: "", Birthday: s.Birthday, IsJapanese: false, Version: 2},
+		},
+		"merge patch cannot remove field": {
+			mediaType: ContentTypeMergePatch,
+			patch:     `{"Name":null}`,
+			wantErr:   ErrInvalidPatch,
+		},
+		"merge patch cannot set field by another case": {
+			mediaType: ContentTypeMergePatch,
+			patch:     `{"name":""}`,
+			wantErr:   ErrInvalidPatch,
+		},
+		"merge patch cannot remove field by another case": {
+			mediaType: ContentTypeMergePatch,
+			patch:     `{"name":null}`,
+			wantErr:   ErrInvalidPatch,
+		},
+		"merge patch cannot add unknown field": {
+			mediaType: ContentTypeMergePatch,
+			patch:     `{"Unknown":1}`,
+			wantErr:   ErrInvalidPatch,
+		},
+		"json patch replaces field": {
+			mediaType: ContentTypeJSONPatch,
+			patch:     `[{"op":"test","path":"/Name","value":"test-japanese"},{"op":"replace","path":"/Birthday","value":"2000-01-01T00:00:00+09:00"}]`,
+			want:      &model.Sample{ID: s.ID, Name: s.Name, Birthday: time.Date(2000, 1, 1, 0, 0, 0, 0, jst), IsJapanese: true, Version: 2},
+		},
+		"json patch fails test operation": {
+			mediaType: ContentTypeJSONPatch,
+			patch:     `[{"op":"test","path":"/Name","value":"other"},{"op":"replace","path":"/Name","value":"patched"}]`,
+			wantErr:   ErrInvalidPatch,
+		},
+		"json patch cannot remove field": {
+			mediaType: ContentTypeJSONPatch,
+			patch:     `[{"op":"replace","path":"/Name","value":null}]`,
+			wantErr:   ErrInvalidPatch,
+		},
+		"json patch cannot add field by another case": {
+			mediaType: ContentTypeJSONPatch,
+			patch:     `[{"op":"add","path":"/name","value":""}]`,
+			wantErr:   ErrInvalidPatch,
+		},
+		"json patch with invalid type": {
+			mediaType: ContentTypeJSONPatch,
+			patch:     `[{"op":"replace","path":"/IsJapanese","value":"yes"}]`,
+			wantErr:   ErrInvalidPatch,
+		},
+		"unsupported media type": {
+			mediaType: "application/json",
+			patch:     `{"Name":"patched"}`,
+			wantErr:   ErrUnsupportedPatch,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := applyPatch(tt.mediaType, []byte(tt.patch), s)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "want %v but got %v", tt.wantErr, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.ID, got.ID)
+			assert.Equal(t, tt.want.Name, got.Name)
+			assert.True(t, tt.want.Birthday.Equal(got.Birthday), "want %v but got %v", tt.want.Birthday, got.Birthday)
+			assert.Equal(t, tt.want.IsJapanese, got.IsJapanese)
+			assert.Equal(t, tt.want.Version, got.Version)
+		})
+	}
+}
+
+func TestPatchMediaType(t *testing.T) {
+	tests := map[string]struct {
+		contentType string
+		want        string
+		wantErr     error
+	}{
+		"merge patch":             {contentType: "application/merge-patch+json", want: ContentTypeMergePatch},
+		"json patch with charset": {contentType: "application/json-patch+json; charset=utf-8", want: ContentTypeJSONPatch},
+		"json":                    {contentType: "application/json", wantErr: ErrUnsupportedPatch},
+		"empty":                   {contentType: "", wantErr: ErrUnsupportedPatch},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := patchMediaType(tt.contentType)
+			assert.True(t, errors.Is(err, tt.wantErr), "want %v but got %v", tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const ContentTypeProblem = "application/problem+json"
+
+// Problem is a problem detail of RFC 9457.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// writeProblem writes a problem of status with detail.
+// 401 Unauthorized asks for a bearer token by WWW-Authenticate header.
+func writeProblem(w http.ResponseWriter, status int, detail string) {
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="go-api"`)
+	}
+	w.Header().Set("Content-Type", ContentTypeProblem)
+	w.WriteHeader(status)
+	// the status is already written so that the error cannot be responded.
+	_ = json.NewEncoder(w).Encode(Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	})
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/sample"
+	"github.com/google/uuid"
+)
+
+const (
+	HeaderRequestID = "X-Request-ID"
+	// maxRequestIDLength is the maximum length of request IDs given by clients, which is the size of the history column.
+	maxRequestIDLength = 128
+)
+
+// RequestID is a middleware which puts the ID of the request into the request context and X-Request-ID response header.
+// The ID is X-Request-ID request header so that callers can trace their requests,
+// or a random UUID if the header is absent or is not printable ASCII of at most 128 characters.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(HeaderRequestID)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(HeaderRequestID, id)
+		next.ServeHTTP(w, r.WithContext(sample.WithRequestID(r.Context(), id)))
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/sample"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := map[string]struct {
+		header   string
+		want     string
+		generate bool
+	}{
+		"use the header": {
+			header: "req-1",
+			want:   "req-1",
+		},
+		"generate an ID without the header": {
+			generate: true,
+		},
+		"generate an ID when the header is not printable": {
+			header:   "req 1\n",
+			generate: true,
+		},
+		"generate an ID when the header is too long": {
+			header:   strings.Repeat("a", 129),
+			generate: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got string
+			handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = sample.RequestIDFrom(r.Context())
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/samples", nil)
+			if tt.header != "" {
+				r.Header.Set(HeaderRequestID, tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if tt.generate {
+				_, err := uuid.Parse(got)
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, tt.want, got)
+			}
+			assert.Equal(t, got, w.Header().Get(HeaderRequestID))
+		})
+	}
+}
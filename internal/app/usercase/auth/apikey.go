@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/sample"
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+	"github.com/google/uuid"
+)
+
+// Scope limits operations on samples with an API key in addition to the actor.
+type Scope string
+
+const (
+	// ScopeSamplesRead allows reading samples including deleted ones.
+	ScopeSamplesRead Scope = "samples:read"
+	// ScopeSamplesWrite allows adding, editing, deleting and restoring samples.
+	ScopeSamplesWrite Scope = "samples:write"
+)
+
+var scopes = map[Scope]bool{
+	ScopeSamplesRead:  true,
+	ScopeSamplesWrite: true,
+}
+
+// scopeOf returns the scope needed to do op.
+func scopeOf(op sample.Operation) Scope {
+	switch op {
+	case sample.OperationRead, sample.OperationListDeleted, sample.OperationIncludeDeleted, sample.OperationAudit:
+		return ScopeSamplesRead
+	default:
+		return ScopeSamplesWrite
+	}
+}
+
+const (
+	// APIKeyPrefix is the beginning of API keys, which tells secret scanners what they are.
+	APIKeyPrefix = "gak_"
+	// apiKeyIDLength is the length of the visible part of API keys after APIKeyPrefix.
+	apiKeyIDLength = 8
+	// MaxAPIKeyNameLength is the maximum length of API key names.
+	MaxAPIKeyNameLength = 100
+)
+
+// APIKey is a stored API key of a machine client. The key is "gak_<8 hex>_<secret>",
+// whose first 12 characters are stored as Prefix to find it and shown to identify it.
+type APIKey struct {
+	ID        uuid.UUID
+	Name      string
+	Prefix    string
+	Hash      string
+	Actor     model.Actor
+	Scopes    []Scope
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// Allows reports whether the key has the scope needed to do op.
+func (k *APIKey) Allows(op sample.Operation) bool {
+	need := scopeOf(op)
+	for _, s := range k.Scopes {
+		if s == need {
+			return true
+		}
+	}
+	return false
+}
+
+type APIKeyRepository interface {
+	// INSERT INTO `API_KEYS` (`ID`, `NAME`, `PREFIX`, `ENCRYPT_KEY`, `ACTOR`, `SCOPES`, `CREATED_AT`) VALUES (...)
+	Insert(ctx context.Context, key *APIKey) error
+	// SELECT ... FROM `API_KEYS` WHERE `PREFIX` = @prefix
+	//
+	// FindByPrefix returns ErrNotFound if the key does not exist.
+	FindByPrefix(ctx context.Context, prefix string) (*APIKey, error)
+	// SELECT ... FROM `API_KEYS` ORDER BY `CREATED_AT`, `ID`
+	List(ctx context.Context) ([]APIKey, error)
+	// UPDATE `API_KEYS` SET `REVOKED_AT` = @at WHERE `ID` = @id AND `REVOKED_AT` IS NULL
+	//
+	// Revoke returns ErrNotFound if the key does not exist or is already revoked.
+	Revoke(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+// CreateAPIKeyQuery is a request to create an API key.
+type CreateAPIKeyQuery struct {
+	Name   string
+	Actor  model.Actor
+	Scopes []Scope
+}
+
+// APIKeyService creates and verifies API keys for machine clients which cannot log in interactively.
+type APIKeyService struct {
+	Repository APIKeyRepository
+	// Now returns the current time. time.Now is used if it is nil.
+	Now func() time.Time
+}
+
+func (s *APIKeyService) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// Create creates an API key and returns the key, which is told only once because only the hash is stored.
+// It returns ErrInvalid unless the actor is ActorSystem or ActorManager and the scopes are known.
+func (s *APIKeyService) Create(ctx context.Context, q CreateAPIKeyQuery) (string, *APIKey, error) {
+	if q.Name == "" || len([]rune(q.Name)) > MaxAPIKeyNameLength {
+		return "", nil, fmt.Errorf("%w: API key name must be 1 to %d characters", ErrInvalid, MaxAPIKeyNameLength)
+	}
+	if q.Actor != model.ActorSystem && q.Actor != model.ActorManager {
+		return "", nil, fmt.Errorf("%w: API keys cannot be bound to %v", ErrInvalid, q.Actor)
+	}
+	if len(q.Scopes) == 0 {
+		return "", nil, fmt.Errorf("%w: API key needs scopes", ErrInvalid)
+	}
+	for _, scope := range q.Scopes {
+		if !scopes[scope] {
+			return "", nil, fmt.Errorf("%w: unknown scope %q", ErrInvalid, scope)
+		}
+	}
+	b := make([]byte, apiKeyIDLength/2)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("generate API key: %w", err)
+	}
+	secret, err := randomToken()
+	if err != nil {
+		return "", nil, err
+	}
+	prefix := APIKeyPrefix + hex.EncodeToString(b)
+	key := prefix + "_" + secret
+	stored := &APIKey{
+		ID:        uuid.New(),
+		Name:      q.Name,
+		Prefix:    prefix,
+		Hash:      HashToken(key),
+		Actor:     q.Actor,
+		Scopes:    q.Scopes,
+		CreatedAt: s.now(),
+	}
+	if err := s.Repository.Insert(ctx, stored); err != nil {
+		return "", nil, err
+	}
+	return key, stored, nil
+}
+
+// List returns all API keys including revoked ones.
+func (s *APIKeyService) List(ctx context.Context) ([]APIKey, error) {
+	return s.Repository.List(ctx)
+}
+
+// Revoke revokes the API key. It returns ErrNotFound if the key does not exist or is already revoked.
+func (s *APIKeyService) Revoke(ctx context.Context, id uuid.UUID) error {
+	return s.Repository.Revoke(ctx, id, s.now())
+}
+
+// Authenticate returns the stored API key of key. It returns ErrUnauthorized if key is unknown or revoked.
+func (s *APIKeyService) Authenticate(ctx context.Context, key string) (*APIKey, error) {
+	prefix, _, ok := strings.Cut(strings.TrimPrefix(key, APIKeyPrefix), "_")
+	if !ok || !strings.HasPrefix(key, APIKeyPrefix) || len(prefix) != apiKeyIDLength {
+		return nil, fmt.Errorf("%w: malformed API key", ErrUnauthorized)
+	}
+	stored, err := s.Repository.FindByPrefix(ctx, APIKeyPrefix+prefix)
+	if errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("%w: API key is unknown", ErrUnauthorized)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(stored.Hash), []byte(HashToken(key))) != 1 {
+		return nil, fmt.Errorf("%w: API key is unknown", ErrUnauthorized)
+	}
+	if stored.RevokedAt != nil {
+		return nil, fmt.Errorf("%w: API key is revoked", ErrUnauthorized)
+	}
+	return stored, nil
+}
+
+// AuthenticateAPIKey returns the stored API key of key. See APIKeyService.Authenticate.
+func (u *Usecase) AuthenticateAPIKey(ctx context.Context, key string) (*APIKey, error) {
+	if u.APIKeys == nil {
+		return nil, fmt.Errorf("%w: API keys are unsupported", ErrUnauthorized)
+	}
+	return u.APIKeys.Authenticate(ctx, key)
+}
+
+// CreateAPIKey creates an API key if the caller is a user of ActorSystem. See APIKeyService.Create.
+func (u *Usecase) CreateAPIKey(ctx context.Context, q CreateAPIKeyQuery) (string, *APIKey, error) {
+	if err := u.authorizeAPIKeys(ctx); err != nil {
+		return "", nil, err
+	}
+	return u.APIKeys.Create(ctx, q)
+}
+
+// ListAPIKeys lists API keys if the caller is a user of ActorSystem.
+func (u *Usecase) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	if err := u.authorizeAPIKeys(ctx); err != nil {
+		return nil, err
+	}
+	return u.APIKeys.List(ctx)
+}
+
+// RevokeAPIKey revokes the API key if the caller is a user of ActorSystem. See APIKeyService.Revoke.
+func (u *Usecase) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	if err := u.authorizeAPIKeys(ctx); err != nil {
+		return err
+	}
+	return u.APIKeys.Revoke(ctx, id)
+}
+
+// authorizeAPIKeys authorizes the caller to manage API keys. It returns errors.ErrUnsupported if APIKeys is nil.
+func (u *Usecase) authorizeAPIKeys(ctx context.Context) error {
+	if u.APIKeys == nil {
+		return fmt.Errorf("API keys: %w", errors.ErrUnsupported)
+	}
+	return u.authorizeAdmin(ctx)
+}
+
+// authorizeAdmin returns ErrUnauthorized if the caller is not a user, and ErrForbidden unless the user is ActorSystem.
+// API keys cannot administrate so that a leaked key cannot create other keys.
+func (u *Usecase) authorizeAdmin(ctx context.Context) error {
+	user, ok := UserFrom(ctx)
+	if !ok {
+		return fmt.Errorf("%w: administration needs a user", ErrUnauthorized)
+	}
+	if user.Actor() != model.ActorSystem {
+		return fmt.Errorf("%w: %v cannot administrate", ErrForbidden, user.Actor())
+	}
+	return nil
+}
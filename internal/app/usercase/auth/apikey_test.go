@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/sample"
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyService_Create(t *testing.T) {
+	tests := map[string]struct {
+		query   CreateAPIKeyQuery
+		wantErr error
+	}{
+		"create system key": {
+			query: CreateAPIKeyQuery{Name: "batch", Actor: model.ActorSystem, Scopes: []Scope{ScopeSamplesRead, ScopeSamplesWrite}},
+		},
+		"create manager key": {
+			query: CreateAPIKeyQuery{Name: "report", Actor: model.ActorManager, Scopes: []Scope{ScopeSamplesRead}},
+		},
+		"return ErrInvalid when name is empty": {
+			query:   CreateAPIKeyQuery{Actor: model.ActorSystem, Scopes: []Scope{ScopeSamplesRead}},
+			wantErr: ErrInvalid,
+		},
+		"return ErrInvalid when actor is user": {
+			query:   CreateAPIKeyQuery{Name: "batch", Actor: model.ActorUser, Scopes: []Scope{ScopeSamplesRead}},
+			wantErr: ErrInvalid,
+		},
+		"return ErrInvalid when scopes are empty": {
+			query:   CreateAPIKeyQuery{Name: "batch", Actor: model.ActorSystem},
+			wantErr: ErrInvalid,
+		},
+		"return ErrInvalid when scope is unknown": {
+			query:   CreateAPIKeyQuery{Name: "batch", Actor: model.ActorSystem, Scopes: []Scope{"samples:admin"}},
+			wantErr: ErrInvalid,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := APIKeyService{Repository: &fakeAPIKeyRepository{keys: map[uuid.UUID]APIKey{}}}
+			key, stored, err := s.Create(context.Background(), tt.query)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Regexp(t, `^gak_[0-9a-f]{8}_[A-Za-z0-9_-]{43}$`, key)
+			assert.Equal(t, key[:12], stored.Prefix)
+			assert.Equal(t, HashToken(key), stored.Hash)
+		})
+	}
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	s := APIKeyService{Repository: &fakeAPIKeyRepository{keys: map[uuid.UUID]APIKey{}}}
+	ctx := context.Background()
+	key, stored, err := s.Create(ctx, CreateAPIKeyQuery{Name: "batch", Actor: model.ActorManager, Scopes: []Scope{ScopeSamplesRead}})
+	assert.NoError(t, err)
+
+	got, err := s.Authenticate(ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, stored.ID, got.ID)
+	for _, wrong := range []string{"", "gak_", stored.Prefix, stored.Prefix + "_wrong", "gak_ffffffff_" + key[13:], key[4:]} {
+		_, err = s.Authenticate(ctx, wrong)
+		assert.ErrorIs(t, err, ErrUnauthorized, wrong)
+	}
+
+	assert.NoError(t, s.Revoke(ctx, stored.ID))
+	_, err = s.Authenticate(ctx, key)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.ErrorIs(t, s.Revoke(ctx, stored.ID), ErrNotFound)
+}
+
+func TestActorPolicy_Authorize_APIKey(t *testing.T) {
+	tests := map[string]struct {
+		key     APIKey
+		op      sample.Operation
+		wantErr error
+	}{
+		"allow read with samples:read": {
+			key: APIKey{Actor: model.ActorManager, Scopes: []Scope{ScopeSamplesRead}},
+			op:  sample.OperationRead,
+		},
+		"return ErrForbidden when editing without samples:write": {
+			key:     APIKey{Actor: model.ActorManager, Scopes: []Scope{ScopeSamplesRead}},
+			op:      sample.OperationEdit,
+			wantErr: sample.ErrForbidden,
+		},
+		"allow edit with samples:write": {
+			key: APIKey{Actor: model.ActorManager, Scopes: []Scope{ScopeSamplesWrite}},
+			op:  sample.OperationEdit,
+		},
+		"return ErrForbidden when actor is not allowed in spite of scope": {
+			key:     APIKey{Actor: model.ActorManager, Scopes: []Scope{ScopeSamplesWrite}},
+			op:      sample.OperationHardDelete,
+			wantErr: sample.ErrForbidden,
+		},
+		"allow system to hard-delete with samples:write": {
+			key: APIKey{Actor: model.ActorSystem, Scopes: []Scope{ScopeSamplesWrite}},
+			op:  sample.OperationHardDelete,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := DefaultActorPolicy().Authorize(WithAPIKey(context.Background(), &tt.key), tt.op)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUsecase_CreateAPIKey(t *testing.T) {
+	u := Usecase{APIKeys: &APIKeyService{Repository: &fakeAPIKeyRepository{keys: map[uuid.UUID]APIKey{}}}}
+	q := CreateAPIKeyQuery{Name: "batch", Actor: model.ActorSystem, Scopes: []Scope{ScopeSamplesRead}}
+	system, err := model.NewUser(uuid.New(), "system", "hash", model.ActorSystem, nil, nil, nil)
+	assert.NoError(t, err)
+	manager, err := model.NewUser(uuid.New(), "manager", "hash", model.ActorManager, nil, nil, nil)
+	assert.NoError(t, err)
+
+	_, _, err = u.CreateAPIKey(context.Background(), q)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	_, _, err = u.CreateAPIKey(WithAPIKey(context.Background(), &APIKey{Actor: model.ActorSystem, Scopes: []Scope{ScopeSamplesWrite}}), q)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	_, _, err = u.CreateAPIKey(WithUser(context.Background(), manager), q)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, _, err = u.CreateAPIKey(WithUser(context.Background(), system), q)
+	assert.NoError(t, err)
+
+	_, _, err = (&Usecase{}).CreateAPIKey(WithUser(context.Background(), system), q)
+	assert.True(t, errors.Is(err, errors.ErrUnsupported))
+}
+
+// fakeAPIKeyRepository stores API keys in the map.
+type fakeAPIKeyRepository struct {
+	keys map[uuid.UUID]APIKey
+}
+
+// Insert implements APIKeyRepository.
+func (r *fakeAPIKeyRepository) Insert(ctx context.Context, key *APIKey) error {
+	r.keys[key.ID] = *key
+	return nil
+}
+
+// FindByPrefix implements APIKeyRepository.
+func (r *fakeAPIKeyRepository) FindByPrefix(ctx context.Context, prefix string) (*APIKey, error) {
+	for _, k := range r.keys {
+		if k.Prefix == prefix {
+			return &k, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// List implements APIKeyRepository.
+func (r *fakeAPIKeyRepository) List(ctx context.Context) ([]APIKey, error) {
+	keys := make([]APIKey, 0, len(r.keys))
+	for _, k := range r.keys {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// Revoke implements APIKeyRepository.
+func (r *fakeAPIKeyRepository) Revoke(ctx context.Context, id uuid.UUID, at time.Time) error {
+	k, ok := r.keys[id]
+	if !ok || k.RevokedAt != nil {
+		return ErrNotFound
+	}
+	k.RevokedAt = &at
+	r.keys[id] = k
+	return nil
+}
+
+var _ APIKeyRepository = (*fakeAPIKeyRepository)(nil)
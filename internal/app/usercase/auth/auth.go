@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/transaction"
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+	"github.com/google/uuid"
+)
+
+const (
+	MaxUserNameLength = 100
+	MinPasswordLength = 8
+	// MaxPasswordLength is the limit in bytes of bcrypt.
+	MaxPasswordLength = 72
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+	// ErrDuplicate is returned when the user name is already registered.
+	ErrDuplicate = errors.New("duplicate user name")
+	// ErrInvalid is returned when the user name or the password does not satisfy the requirements.
+	ErrInvalid = errors.New("invalid user")
+	// ErrUnauthorized is returned when the user name or the password is wrong.
+	// It does not tell which is wrong so that registered user names are not revealed.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden is returned when the caller is not allowed to administrate.
+	ErrForbidden = errors.New("forbidden")
+)
+
+type UserRepository interface {
+	// SELECT `ID`, `USER_NAME`, `ENCRYPT_PASSWORD`, `ACTOR` FROM `USERS` WHERE `ID` = @id
+	//
+	// FindByID returns ErrNotFound if the user does not exist.
+	FindByID(ctx context.Context, id uuid.UUID) (*model.User, error)
+	// SELECT `ID`, `USER_NAME`, `ENCRYPT_PASSWORD`, `ACTOR` FROM `USERS` WHERE `USER_NAME` = @userName
+	//
+	// FindByUserName returns ErrNotFound if the user does not exist.
+	FindByUserName(ctx context.Context, userName string) (*model.User, error)
+	// INSERT INTO `USERS` (`ID`, `USER_NAME`, `ENCRYPT_PASSWORD`, `ACTOR`) VALUES (@user.ID, @user.UserName, @user.EncryptPassword, @user.Actor)
+	//
+	// Insert returns ErrDuplicate if the user name is already registered.
+	Insert(ctx context.Context, user *model.User) error
+	// UPDATE `USERS` SET `ENCRYPT_PASSWORD` = @user.EncryptPassword, `RESET_CODE` = @user.ResetCode, `RESET_UNTIL` = @user.ResetUntil WHERE `ID` = @user.ID
+	//
+	// Update returns ErrNotFound if the user does not exist.
+	Update(ctx context.Context, user *model.User) error
+	// UPDATE `USERS` SET `ENCRYPT_PASSWORD` = @user.EncryptPassword, `RESET_CODE` = NULL, `RESET_UNTIL` = NULL WHERE `ID` = @user.ID AND `RESET_CODE` = @resetCode
+	//
+	// ResetPassword consumes resetCode, the hash of the reset code read before, so that it is used only once.
+	// It returns ErrInvalidResetCode if the user no longer has resetCode because it is used or replaced concurrently.
+	ResetPassword(ctx context.Context, user *model.User, resetCode string) error
+}
+
+type RegisterQuery struct {
+	UserName string
+	Password string
+}
+
+type Usecase struct {
+	Repository UserRepository
+	// Hasher hashes passwords. Bcrypt with the default cost is used if it is nil.
+	Hasher PasswordHasher
+	// Tokens issues tokens to users logged in.
+	Tokens *TokenService
+	// APIKeys verifies API keys of machine clients. API keys are unsupported if it is nil.
+	APIKeys *APIKeyService
+	// External verifies tokens issued by an external identity provider. They are rejected if it is nil.
+	External *ExternalVerifier
+	// Throttle locks out logins with too many failures. Logins are not throttled if it is nil.
+	Throttle *LoginThrottle
+	// Notifier tells reset codes to users. Password reset is unsupported if it is nil.
+	Notifier Notifier
+	// ResetTTL is the lifetime of reset codes. DefaultResetTTL is used if it is not positive.
+	ResetTTL time.Duration
+	// Transaction resets passwords atomically. Passwords are reset without transaction if it is nil.
+	Transaction transaction.Manager
+	// Now returns the current time. time.Now is used if it is nil.
+	Now func() time.Time
+}
+
+func (u *Usecase) transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if u.Transaction == nil {
+		return transaction.Nop{}.Do(ctx, fn)
+	}
+	return u.Transaction.Do(ctx, fn)
+}
+
+func (u *Usecase) hasher() PasswordHasher {
+	if u.Hasher == nil {
+		return Bcrypt{}
+	}
+	return u.Hasher
+}
+
+// Register registers a user with ActorUser and returns the ID.
+// It returns ErrInvalid if q does not satisfy the requirements, and ErrDuplicate if the user name is already registered.
+func (u *Usecase) Register(ctx context.Context, q RegisterQuery) (uuid.UUID, error) {
+	if err := validate(q.UserName, q.Password); err != nil {
+		return uuid.UUID{}, err
+	}
+	hash, err := u.hasher().Hash(q.Password)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	user, err := model.NewUser(uuid.New(), q.UserName, hash, model.ActorUser, nil, nil, nil)
+	if err != nil {
+		return uuid.UUID{}, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	if err := u.Repository.Insert(ctx, user); err != nil {
+		return uuid.UUID{}, err
+	}
+	return user.ID(), nil
+}
+
+// Login authenticates the user by the password and issues a token.
+// It returns ErrUnauthorized if the user does not exist or the password is wrong,
+// and LockedError if the user name or the client IP in ctx is locked out by Throttle.
+func (u *Usecase) Login(ctx context.Context, userName, password string) (*IssuedToken, error) {
+	if utf8.RuneCountInString(userName) > MaxUserNameLength {
+		// such a user cannot be registered, and failures of the user name are not counted.
+		return nil, ErrUnauthorized
+	}
+	if u.Throttle == nil {
+		user, err := u.verifyPassword(ctx, userName, password)
+		if err != nil {
+			return nil, err
+		}
+		return u.Tokens.Issue(ctx, user)
+	}
+	ip := ClientIPFrom(ctx)
+	if err := u.Throttle.Check(ctx, userName, ip); err != nil {
+		return nil, err
+	}
+	user, err := u.verifyPassword(ctx, userName, password)
+	if errors.Is(err, ErrUnauthorized) {
+		return nil, errors.Join(err, u.Throttle.Fail(ctx, userName, ip))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := u.Throttle.Succeed(ctx, userName); err != nil {
+		return nil, err
+	}
+	return u.Tokens.Issue(ctx, user)
+}
+
+// verifyPassword returns the user if the password is right.
+// It returns ErrUnauthorized if the user does not exist or the password is wrong.
+func (u *Usecase) verifyPassword(ctx context.Context, userName, password string) (*model.User, error) {
+	user, err := u.Repository.FindByUserName(ctx, userName)
+	if errors.Is(err, ErrNotFound) {
+		// the password is hashed anyway so that the response time does not reveal whether the user exists.
+		if _, err := u.hasher().Hash(password); err != nil {
+			return nil, err
+		}
+		return nil, ErrUnauthorized
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := u.hasher().Compare(user.EncryptPassword(), password); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Refresh exchanges refreshToken for a new token. See TokenService.Refresh.
+func (u *Usecase) Refresh(ctx context.Context, refreshToken string) (*IssuedToken, error) {
+	return u.Tokens.Refresh(ctx, refreshToken)
+}
+
+// Logout revokes refreshToken and all tokens rotated from the same login.
+// Access tokens already issued remain valid until they expire.
+func (u *Usecase) Logout(ctx context.Context, refreshToken string) error {
+	return u.Tokens.Revoke(ctx, refreshToken)
+}
+
+func validate(userName, password string) error {
+	if userName == "" {
+		return fmt.Errorf("%w: user name is required", ErrInvalid)
+	}
+	if utf8.RuneCountInString(userName) > MaxUserNameLength {
+		return fmt.Errorf("%w: user name is longer than %d characters", ErrInvalid, MaxUserNameLength)
+	}
+	return validatePassword(password)
+}
+
+func validatePassword(password string) error {
+	if utf8.RuneCountInString(password) < MinPasswordLength {
+		return fmt.Errorf("%w: password is shorter than %d characters", ErrInvalid, MinPasswordLength)
+	}
+	if len(password) > MaxPasswordLength {
+		return fmt.Errorf("%w: password is longer than %d bytes", ErrInvalid, MaxPasswordLength)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUsecase_Register(t *testing.T) {
+	tests := map[string]struct {
+		query   RegisterQuery
+		insert  func(ctx context.Context, user *model.User) error
+		wantErr error
+	}{
+		"register user": {
+			query: RegisterQuery{UserName: "kawamura", Password: "password"},
+			insert: func(ctx context.Context, user *model.User) error {
+				if user.UserName() != "kawamura" || user.Actor() != model.ActorUser {
+					return assert.AnError
+				}
+				return bcrypt.CompareHashAndPassword([]byte(user.EncryptPassword()), []byte("password"))
+			},
+		},
+		"return ErrInvalid when user name is empty": {
+			query:   RegisterQuery{UserName: "", Password: "password"},
+			wantErr: ErrInvalid,
+		},
+		"return ErrInvalid when password is too short": {
+			query:   RegisterQuery{UserName: "kawamura", Password: "pass"},
+			wantErr: ErrInvalid,
+		},
+		"return ErrInvalid when password is too long": {
+			query:   RegisterQuery{UserName: "kawamura", Password: string(make([]byte, MaxPasswordLength+1))},
+			wantErr: ErrInvalid,
+		},
+		"return ErrDuplicate when user name is registered": {
+			query: RegisterQuery{UserName: "kawamura", Password: "password"},
+			insert: func(ctx context.Context, user *model.User) error {
+				return ErrDuplicate
+			},
+			wantErr: ErrDuplicate,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			u := Usecase{
+				Repository: &stubUserRepository{insert: tt.insert},
+				Hasher:     Bcrypt{Cost: bcrypt.MinCost},
+			}
+			id, err := u.Register(context.Background(), tt.query)
+			assert.ErrorIs(t, err, tt.wantErr)
+			if err == nil {
+				assert.NotEqual(t, uuid.UUID{}, id)
+			}
+		})
+	}
+}
+
+func TestUsecase_Login(t *testing.T) {
+	hasher := Bcrypt{Cost: bcrypt.MinCost}
+	hash, err := hasher.Hash("password")
+	assert.NoError(t, err)
+	user, err := model.NewUser(uuid.New(), "kawamura", hash, model.ActorUser, nil, nil, nil)
+	assert.NoError(t, err)
+	// the current time is used because access tokens are verified with it.
+	now := time.Now()
+
+	tests := map[string]struct {
+		userName string
+		password string
+		wantErr  error
+	}{
+		"issue token": {
+			userName: "kawamura",
+			password: "password",
+		},
+		"return ErrUnauthorized when password is wrong": {
+			userName: "kawamura",
+			password: "wrong-password",
+			wantErr:  ErrUnauthorized,
+		},
+		"return ErrUnauthorized when user does not exist": {
+			userName: "unknown",
+			password: "password",
+			wantErr:  ErrUnauthorized,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			u := Usecase{
+				Repository: &stubUserRepository{
+					findByUserName: func(ctx context.Context, userName string) (*model.User, error) {
+						if userName != user.UserName() {
+							return nil, ErrNotFound
+						}
+						return user, nil
+					},
+				},
+				Hasher: hasher,
+				Tokens: newTestTokenService(func() time.Time { return now }),
+			}
+			got, err := u.Login(context.Background(), tt.userName, tt.password)
+			assert.ErrorIs(t, err, tt.wantErr)
+			if err != nil {
+				return
+			}
+			assert.NotEmpty(t, got.AccessToken())
+			assert.Equal(t, HashToken(got.RefreshToken), got.EncryptRefreshToken())
+			assert.Equal(t, now.Add(time.Minute), got.ExpiresAt())
+			claims, err := u.Tokens.JWT.Verify(got.AccessToken())
+			assert.NoError(t, err)
+			assert.Equal(t, user.ID().String(), claims.Subject)
+			assert.Equal(t, model.ActorUser, claims.Actor)
+		})
+	}
+}
+
+// stubUserRepository calls the function fields. Methods without function panic.
+type stubUserRepository struct {
+	findByID       func(ctx context.Context, id uuid.UUID) (*model.User, error)
+	findByUserName func(ctx context.Context, userName string) (*model.User, error)
+	insert         func(ctx context.Context, user *model.User) error
+	update         func(ctx context.Context, user *model.User) error
+	resetPassword  func(ctx context.Context, user *model.User, resetCode string) error
+}
+
+// FindByID implements UserRepository.
+func (r *stubUserRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	if r.findByID == nil {
+		panic("unimplemented")
+	}
+	return r.findByID(ctx, id)
+}
+
+// FindByUserName implements UserRepository.
+func (r *stubUserRepository) FindByUserName(ctx context.Context, userName string) (*model.User, error) {
+	if r.findByUserName == nil {
+		panic("unimplemented")
+	}
+	return r.findByUserName(ctx, userName)
+}
+
+// Insert implements UserRepository.
+func (r *stubUserRepository) Insert(ctx context.Context, user *model.User) error {
+	if r.insert == nil {
+		panic("unimplemented")
+	}
+	return r.insert(ctx, user)
+}
+
+// Update implements UserRepository.
+func (r *stubUserRepository) Update(ctx context.Context, user *model.User) error {
+	if r.update == nil {
+		panic("unimplemented")
+	}
+	return r.update(ctx, user)
+}
+
+// ResetPassword implements UserRepository.
+func (r *stubUserRepository) ResetPassword(ctx context.Context, user *model.User, resetCode string) error {
+	if r.resetPassword == nil {
+		panic("unimplemented")
+	}
+	return r.resetPassword(ctx, user, resetCode)
+}
+
+var _ UserRepository = (*stubUserRepository)(nil)
@@ -0,0 +1,328 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultJWKSRefreshInterval is the lifetime of cached keys if it is not configured.
+	DefaultJWKSRefreshInterval = time.Hour
+	// minJWKSRefreshInterval limits refetching keys for unknown key IDs so that forged tokens cannot flood the source.
+	minJWKSRefreshInterval = time.Minute
+)
+
+// ErrUnknownKey is returned when no key of a key set verifies a token.
+var ErrUnknownKey = fmt.Errorf("%w: unknown key", ErrUnauthorized)
+
+// KeySource fetches a JSON Web Key Set of RFC 7517.
+type KeySource interface {
+	FetchKeySet(ctx context.Context) ([]byte, error)
+}
+
+// jwk is a public key of a JSON Web Key Set. Private members are ignored.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey is a parsed key with the algorithm which the key is restricted to, which is empty if it is not restricted.
+type publicKey struct {
+	key crypto.PublicKey
+	alg string
+}
+
+// parseKeySet parses a JSON Web Key Set and returns keys for signatures by the key IDs.
+// RSA, EC of P-256, P-384 and P-521, and OKP of Ed25519 are supported, and the other keys are skipped.
+func parseKeySet(b []byte) (map[string]publicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("parse key set: %w", err)
+	}
+	keys := map[string]publicKey{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if errors.Is(err, errors.ErrUnsupported) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = publicKey{key: key, alg: k.Alg}
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("curve %q: %w", k.Crv, errors.ErrUnsupported)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		// ECDH validates that the point is on the curve.
+		if _, err := key.ECDH(); err != nil {
+			return nil, fmt.Errorf("invalid EC point: %w", err)
+		}
+		return key, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("curve %q: %w", k.Crv, errors.ErrUnsupported)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("decode x: %w", err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("key type %q: %w", k.Kty, errors.ErrUnsupported)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("invalid base64url integer %q", s)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// KeySet caches keys of Source, which are fetched again after RefreshInterval,
+// or when a token is signed by an unknown key because the keys may be rotated.
+type KeySet struct {
+	Source KeySource
+	// RefreshInterval is the lifetime of cached keys. DefaultJWKSRefreshInterval is used if it is not positive.
+	RefreshInterval time.Duration
+	// Now returns the current time. time.Now is used if it is nil.
+	Now func() time.Time
+
+	mu   sync.Mutex
+	keys map[string]publicKey
+	// expiresAt is the time when keys are fetched again.
+	expiresAt time.Time
+	// retryAt is the earliest time when keys are fetched again for an unknown key or after failure.
+	retryAt time.Time
+}
+
+func (s *KeySet) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// key returns the key of kid. A token without kid can be verified only if the set has a single key.
+func (s *KeySet) key(ctx context.Context, kid string) (publicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if s.keys == nil || !now.Before(s.expiresAt) {
+		if err := s.refresh(ctx, now); err != nil {
+			return publicKey{}, err
+		}
+	}
+	if key, ok := s.find(kid); ok {
+		return key, nil
+	}
+	if !now.Before(s.retryAt) {
+		if err := s.refresh(ctx, now); err != nil {
+			return publicKey{}, err
+		}
+		if key, ok := s.find(kid); ok {
+			return key, nil
+		}
+	}
+	return publicKey{}, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+}
+
+func (s *KeySet) find(kid string) (publicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// refresh fetches keys unless it failed within minJWKSRefreshInterval.
+// Cached keys are kept if it fails so that an outage of Source does not reject all tokens.
+func (s *KeySet) refresh(ctx context.Context, now time.Time) error {
+	if now.Before(s.retryAt) && s.keys == nil {
+		return fmt.Errorf("%w: key set is unavailable", ErrUnauthorized)
+	}
+	s.retryAt = now.Add(minJWKSRefreshInterval)
+	keys, err := s.fetch(ctx)
+	if err != nil {
+		if s.keys == nil {
+			return err
+		}
+		s.expiresAt = s.retryAt
+		return nil
+	}
+	interval := s.RefreshInterval
+	if interval <= 0 {
+		interval = DefaultJWKSRefreshInterval
+	}
+	s.keys, s.expiresAt = keys, now.Add(interval)
+	return nil
+}
+
+func (s *KeySet) fetch(ctx context.Context) (map[string]publicKey, error) {
+	b, err := s.Source.FetchKeySet(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetch key set: %w", err)
+	}
+	return parseKeySet(b)
+}
+
+// externalMethods are signing methods accepted from identity providers. HMAC is excluded
+// because public keys must not be used as secrets.
+var externalMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// ExternalVerifier verifies tokens issued by an external identity provider such as company SSO,
+// and maps the claims to a user which is not stored in go-api.
+type ExternalVerifier struct {
+	Keys     *KeySet
+	Issuer   string
+	Audience string
+	// UserNameClaim is the claim of the user name. The subject is used if it is empty or absent.
+	UserNameClaim string
+	// ActorClaim is the claim of a string or strings mapped to the actor by Actors.
+	ActorClaim string
+	// Actors maps values of ActorClaim to actors. The most privileged actor of the values is used,
+	// and ActorUser is used if no values are mapped.
+	Actors map[string]model.Actor
+	// Now returns the current time. time.Now is used if it is nil.
+	Now func() time.Time
+}
+
+// Issues reports whether token claims to be issued by Issuer. The signature is not verified.
+func (v *ExternalVerifier) Issues(token string) bool {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, claims); err != nil {
+		return false
+	}
+	iss, err := claims.GetIssuer()
+	return err == nil && iss == v.Issuer
+}
+
+// Verify verifies the signature, the issuer, the audience and the expiration of token, and returns the user.
+// The ID of the user is derived from the issuer and the subject. It returns ErrUnauthorized if token is invalid.
+func (v *ExternalVerifier) Verify(ctx context.Context, token string) (*model.User, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(externalMethods),
+		jwt.WithIssuer(v.Issuer),
+		jwt.WithAudience(v.Audience),
+		jwt.WithExpirationRequired(),
+	}
+	if v.Now != nil {
+		opts = append(opts, jwt.WithTimeFunc(v.Now))
+	}
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := v.Keys.key(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		if key.alg != "" && key.alg != t.Method.Alg() {
+			return nil, fmt.Errorf("%w: key %q is for %s", ErrUnauthorized, kid, key.alg)
+		}
+		return key.key, nil
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+	sub, err := claims.GetSubject()
+	if err != nil || sub == "" {
+		return nil, fmt.Errorf("%w: subject is required", ErrUnauthorized)
+	}
+	userName, _ := claims[v.UserNameClaim].(string)
+	if userName == "" {
+		userName = sub
+	}
+	return model.NewExternalUser(uuid.NewSHA1(uuid.NameSpaceURL, []byte(v.Issuer+"#"+sub)), userName, v.actor(claims[v.ActorClaim]))
+}
+
+// actor returns the most privileged actor mapped from value, which is a string or an array of strings.
+func (v *ExternalVerifier) actor(value interface{}) model.Actor {
+	var values []string
+	switch value := value.(type) {
+	case string:
+		values = []string{value}
+	case []interface{}:
+		for _, e := range value {
+			if s, ok := e.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+	actor := model.ActorUser
+	for _, s := range values {
+		// ActorSystem is the most privileged and ActorUser is the least.
+		if a, ok := v.Actors[s]; ok && a != model.ActorUnknown && a < actor {
+			actor = a
+		}
+	}
+	return actor
+}
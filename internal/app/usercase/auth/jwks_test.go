@@ -0,0 +1,313 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubKeySource struct {
+	keySet  []byte
+	err     error
+	fetched int
+}
+
+func (s *stubKeySource) FetchKeySet(context.Context) ([]byte, error) {
+	s.fetched++
+	return s.keySet, s.err
+}
+
+// testKey is a locally generated key of an identity provider.
+type testKey struct {
+	kid    string
+	alg    string
+	signer crypto.Signer
+}
+
+func newTestKeys(t *testing.T) []testKey {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	return []testKey{
+		{kid: "rsa", alg: "RS256", signer: rsaKey},
+		{kid: "ec", alg: "ES256", signer: ecKey},
+		{kid: "ed", signer: edKey},
+	}
+}
+
+func (k testKey) jwk() map[string]string {
+	b64 := base64.RawURLEncoding.EncodeToString
+	m := map[string]string{"kid": k.kid, "use": "sig"}
+	if k.alg != "" {
+		m["alg"] = k.alg
+	}
+	switch pub := k.signer.Public().(type) {
+	case *rsa.PublicKey:
+		m["kty"], m["n"], m["e"] = "RSA", b64(pub.N.Bytes()), b64(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		m["kty"], m["crv"], m["x"], m["y"] = "EC", "P-256", b64(pub.X.FillBytes(make([]byte, 32))), b64(pub.Y.FillBytes(make([]byte, 32)))
+	case ed25519.PublicKey:
+		m["kty"], m["crv"], m["x"] = "OKP", "Ed25519", b64(pub)
+	}
+	return m
+}
+
+func (k testKey) sign(t *testing.T, method jwt.SigningMethod, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = k.kid
+	signed, err := token.SignedString(k.signer)
+	assert.NoError(t, err)
+	return signed
+}
+
+func keySetOf(t *testing.T, keys ...testKey) []byte {
+	var jwks []map[string]string
+	for _, k := range keys {
+		jwks = append(jwks, k.jwk())
+	}
+	b, err := json.Marshal(map[string]interface{}{"keys": jwks})
+	assert.NoError(t, err)
+	return b
+}
+
+func TestExternalVerifier_Verify(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	keys := newTestKeys(t)
+	rsaKey, ecKey, edKey := keys[0], keys[1], keys[2]
+	v := &ExternalVerifier{
+		Keys:          &KeySet{Source: &stubKeySource{keySet: keySetOf(t, keys...)}, Now: func() time.Time { return now }},
+		Issuer:        "https://sso.example.com",
+		Audience:      "go-api",
+		UserNameClaim: "preferred_username",
+		ActorClaim:    "groups",
+		Actors:        map[string]model.Actor{"admins": model.ActorSystem, "editors": model.ActorManager},
+		Now:           func() time.Time { return now },
+	}
+	claims := func(overrides jwt.MapClaims) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"iss":                "https://sso.example.com",
+			"aud":                []string{"go-api", "other"},
+			"sub":                "u-1",
+			"exp":                now.Add(time.Minute).Unix(),
+			"preferred_username": "kawamura",
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+	unknown := newTestKeys(t)[0]
+	unknown.kid = "rotated"
+
+	tests := map[string]struct {
+		token     string
+		wantName  string
+		wantActor model.Actor
+		wantErr   error
+	}{
+		"verify RSA token": {
+			token:     rsaKey.sign(t, jwt.SigningMethodRS256, claims(nil)),
+			wantName:  "kawamura",
+			wantActor: model.ActorUser,
+		},
+		"verify EC token mapping the group to the actor": {
+			token:     ecKey.sign(t, jwt.SigningMethodES256, claims(jwt.MapClaims{"groups": []string{"staff", "editors"}})),
+			wantName:  "kawamura",
+			wantActor: model.ActorManager,
+		},
+		"verify Ed25519 token using the most privileged actor": {
+			token:     edKey.sign(t, jwt.SigningMethodEdDSA, claims(jwt.MapClaims{"groups": []string{"editors", "admins"}})),
+			wantName:  "kawamura",
+			wantActor: model.ActorSystem,
+		},
+		"use the subject when the user name is absent": {
+			token:     rsaKey.sign(t, jwt.SigningMethodRS256, claims(jwt.MapClaims{"preferred_username": nil, "groups": "editors"})),
+			wantName:  "u-1",
+			wantActor: model.ActorManager,
+		},
+		"return ErrUnauthorized when the token is expired": {
+			token:   rsaKey.sign(t, jwt.SigningMethodRS256, claims(jwt.MapClaims{"exp": now.Add(-time.Minute).Unix()})),
+			wantErr: jwt.ErrTokenExpired,
+		},
+		"return ErrUnauthorized when the token has no expiration": {
+			token:   rsaKey.sign(t, jwt.SigningMethodRS256, claims(jwt.MapClaims{"exp": nil})),
+			wantErr: jwt.ErrTokenRequiredClaimMissing,
+		},
+		"return ErrUnauthorized when the issuer is different": {
+			token:   rsaKey.sign(t, jwt.SigningMethodRS256, claims(jwt.MapClaims{"iss": "https://evil.example.com"})),
+			wantErr: jwt.ErrTokenInvalidIssuer,
+		},
+		"return ErrUnauthorized when the audience is different": {
+			token:   rsaKey.sign(t, jwt.SigningMethodRS256, claims(jwt.MapClaims{"aud": "other"})),
+			wantErr: jwt.ErrTokenInvalidAudience,
+		},
+		"return ErrUnauthorized when the subject is absent": {
+			token:   rsaKey.sign(t, jwt.SigningMethodRS256, claims(jwt.MapClaims{"sub": nil})),
+			wantErr: ErrUnauthorized,
+		},
+		"return ErrUnknownKey when the key is not in the key set": {
+			token:   unknown.sign(t, jwt.SigningMethodRS256, claims(nil)),
+			wantErr: ErrUnknownKey,
+		},
+		"return ErrUnauthorized when the algorithm is not of the key": {
+			token:   rsaKey.sign(t, jwt.SigningMethodPS256, claims(nil)),
+			wantErr: ErrUnauthorized,
+		},
+		"return ErrUnauthorized when the algorithm is HMAC": {
+			token: func() string {
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims(nil))
+				token.Header["kid"] = "rsa"
+				signed, err := token.SignedString([]byte("secret"))
+				assert.NoError(t, err)
+				return signed
+			}(),
+			wantErr: jwt.ErrTokenSignatureInvalid,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := v.Verify(context.Background(), tt.token)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, ErrUnauthorized)
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://sso.example.com#u-1")), got.ID())
+			assert.Equal(t, tt.wantName, got.UserName())
+			assert.Equal(t, tt.wantActor, got.Actor())
+			assert.True(t, got.External())
+		})
+	}
+}
+
+func TestKeySet_key(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	keys := newTestKeys(t)
+	source := &stubKeySource{keySet: keySetOf(t, keys[0])}
+	s := &KeySet{Source: source, RefreshInterval: time.Hour, Now: func() time.Time { return now }}
+	ctx := context.Background()
+
+	_, err := s.key(ctx, "rsa")
+	assert.NoError(t, err)
+	_, err = s.key(ctx, "")
+	assert.NoError(t, err, "a token without kid uses the single key")
+	assert.Equal(t, 1, source.fetched)
+
+	// the provider rotates keys.
+	source.keySet = keySetOf(t, keys[1])
+	_, err = s.key(ctx, "ec")
+	assert.ErrorIs(t, err, ErrUnknownKey, "unknown keys are not refetched within a minute")
+	assert.Equal(t, 1, source.fetched)
+
+	now = now.Add(time.Minute)
+	_, err = s.key(ctx, "ec")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, source.fetched)
+
+	// the provider is down.
+	source.err = errors.New("unavailable")
+	now = now.Add(time.Hour)
+	_, err = s.key(ctx, "ec")
+	assert.NoError(t, err, "cached keys are used while the source is down")
+	assert.Equal(t, 3, source.fetched)
+	_, err = s.key(ctx, "ec")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, source.fetched, "the source is not retried within a minute")
+
+	now = now.Add(time.Minute)
+	source.err = nil
+	_, err = s.key(ctx, "ec")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, source.fetched)
+}
+
+func TestParseKeySet(t *testing.T) {
+	keys := newTestKeys(t)
+	b, err := json.Marshal(map[string]interface{}{"keys": []map[string]string{
+		keys[0].jwk(),
+		keys[1].jwk(),
+		keys[2].jwk(),
+		{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"},
+		{"kty": "OKP", "kid": "x25519", "crv": "X25519", "x": "AAAA"},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+	}})
+	assert.NoError(t, err)
+
+	got, err := parseKeySet(b)
+	assert.NoError(t, err)
+	assert.Len(t, got, 3)
+	for _, k := range keys {
+		assert.Equal(t, k.signer.Public(), got[k.kid].key)
+		assert.Equal(t, k.alg, got[k.kid].alg)
+	}
+
+	invalid := keys[1].jwk()
+	invalid["y"] = invalid["x"]
+	b, err = json.Marshal(map[string]interface{}{"keys": []map[string]string{invalid}})
+	assert.NoError(t, err)
+	_, err = parseKeySet(b)
+	assert.ErrorContains(t, err, "invalid EC point")
+}
+
+func TestUsecase_Authenticate_External(t *testing.T) {
+	user, err := model.NewUser(uuid.New(), "kawamura", "hash", model.ActorManager, nil, nil, nil)
+	assert.NoError(t, err)
+	key := newTestKeys(t)[2]
+	u := Usecase{
+		Repository: &stubUserRepository{
+			findByID: func(ctx context.Context, id uuid.UUID) (*model.User, error) {
+				if id != user.ID() {
+					return nil, ErrNotFound
+				}
+				return user, nil
+			},
+		},
+		// JWT verifies the expiration by the wall clock.
+		Tokens: newTestTokenService(time.Now),
+		External: &ExternalVerifier{
+			Keys:     &KeySet{Source: &stubKeySource{keySet: keySetOf(t, key)}},
+			Issuer:   "https://sso.example.com",
+			Audience: "go-api",
+		},
+	}
+	ctx := context.Background()
+
+	issued, err := u.Tokens.Issue(ctx, user)
+	assert.NoError(t, err)
+	got, err := u.Authenticate(ctx, issued.AccessToken())
+	assert.NoError(t, err)
+	assert.False(t, got.External(), "tokens of go-api are verified locally")
+
+	got, err = u.Authenticate(ctx, key.sign(t, jwt.SigningMethodEdDSA, jwt.MapClaims{
+		"iss": "https://sso.example.com",
+		"aud": "go-api",
+		"sub": "u-1",
+		"exp": time.Now().Add(time.Minute).Unix(),
+	}))
+	assert.NoError(t, err)
+	assert.True(t, got.External())
+	assert.Equal(t, "u-1", got.UserName())
+}
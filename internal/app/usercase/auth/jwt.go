@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims is the payload of access tokens. The subject is the ID of the user.
+type Claims struct {
+	jwt.RegisteredClaims
+	UserName string      `json:"name"`
+	Actor    model.Actor `json:"actor"`
+}
+
+// JWT signs and verifies access tokens with HS256 or EdDSA.
+type JWT struct {
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+	issuer    string
+}
+
+// NewHS256 returns JWT signing tokens with secret. A random secret is used if secret is empty.
+func NewHS256(secret []byte, issuer string) (*JWT, error) {
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("generate secret: %w", err)
+		}
+	}
+	return &JWT{method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret, issuer: issuer}, nil
+}
+
+// NewEdDSA returns JWT signing tokens with key, whose tokens can be verified by others with the public key.
+func NewEdDSA(key ed25519.PrivateKey, issuer string) *JWT {
+	return &JWT{method: jwt.SigningMethodEdDSA, signKey: key, verifyKey: key.Public(), issuer: issuer}
+}
+
+// Sign signs claims with the issuer.
+func (j *JWT) Sign(claims Claims) (string, error) {
+	claims.Issuer = j.issuer
+	signed, err := jwt.NewWithClaims(j.method, claims).SignedString(j.signKey)
+	if err != nil {
+		return "", fmt.Errorf("sign token: %w", err)
+	}
+	return signed, nil
+}
+
+// Verify verifies the signature, the issuer and the expiration of token, and returns the claims.
+// It returns ErrUnauthorized if token is invalid.
+func (j *JWT) Verify(token string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return j.verifyKey, nil
+	},
+		jwt.WithValidMethods([]string{j.method.Alg()}),
+		jwt.WithIssuer(j.issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+	return claims, nil
+}
@@ -0,0 +1,310 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultMaxUserFailures is the number of consecutive login failures of a user name to lock it out.
+	DefaultMaxUserFailures = 5
+	// DefaultMaxIPFailures is the number of consecutive login failures from a client IP to lock it out,
+	// which is larger than DefaultMaxUserFailures because users behind NAT share an IP.
+	DefaultMaxIPFailures = 20
+	// DefaultFailureWindow is the period after which failures are forgotten if it is not configured.
+	DefaultFailureWindow = 15 * time.Minute
+	// DefaultBaseLockout is the first lockout, which doubles on each lockout, if it is not configured.
+	DefaultBaseLockout = time.Minute
+	// DefaultMaxLockout is the limit of lockouts if it is not configured.
+	DefaultMaxLockout = 24 * time.Hour
+)
+
+const (
+	AuditLoginLocked   = "login.locked"
+	AuditLoginUnlocked = "login.unlocked"
+)
+
+// ErrLocked is returned when login is locked out by too many failures.
+var ErrLocked = errors.New("locked out")
+
+// LockedError is ErrLocked with the time when the lockout ends.
+type LockedError struct {
+	Until time.Time
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%v until %s", ErrLocked, e.Until.Format(time.RFC3339))
+}
+
+func (e *LockedError) Unwrap() error {
+	return ErrLocked
+}
+
+// LoginAttempt counts consecutive login failures of Subject, which is "user:<user name>" or "ip:<client IP>".
+type LoginAttempt struct {
+	Subject  string
+	Failures int
+	// Lockouts is the number of lockouts so far, which doubles the next lockout.
+	Lockouts    int
+	LockedUntil *time.Time
+	UpdatedAt   time.Time
+}
+
+type LoginAttemptRepository interface {
+	// SELECT `SUBJECT`, `FAILURES`, `LOCKOUTS`, `LOCKED_UNTIL`, `UPDATED_AT` FROM `LOGIN_ATTEMPTS` WHERE `SUBJECT` IN (@subjects)
+	FindBySubjects(ctx context.Context, subjects []string) ([]LoginAttempt, error)
+	// UPDATE `LOGIN_ATTEMPTS` SET `FAILURES` = 0 WHERE `SUBJECT` = @subject AND `UPDATED_AT` < @since
+	// UPDATE `LOGIN_ATTEMPTS` SET `FAILURES` = `FAILURES` + 1, `UPDATED_AT` = @at WHERE `SUBJECT` = @subject
+	// INSERT INTO `LOGIN_ATTEMPTS` (`SUBJECT`, `FAILURES`, `LOCKOUTS`, `UPDATED_AT`) VALUES (@subject, 1, 0, @at) if the subject is new
+	//
+	// Fail counts a failure of subject atomically among replicas and returns the attempt counted.
+	// Failures before since are forgotten.
+	Fail(ctx context.Context, subject string, since, at time.Time) (*LoginAttempt, error)
+	// UPDATE `LOGIN_ATTEMPTS` SET `FAILURES` = 0, `LOCKOUTS` = `LOCKOUTS` + 1, `LOCKED_UNTIL` = @until
+	// WHERE `SUBJECT` = @subject AND `FAILURES` >= @failures
+	//
+	// Lock returns false if the subject has less failures, which means that another replica has locked it.
+	Lock(ctx context.Context, subject string, failures int, until time.Time) (bool, error)
+	// DELETE FROM `LOGIN_ATTEMPTS` WHERE `SUBJECT` = @subject
+	//
+	// Delete returns ErrNotFound if the subject has no attempts.
+	Delete(ctx context.Context, subject string) error
+	// DELETE FROM `LOGIN_ATTEMPTS` WHERE `UPDATED_AT` < @before AND (`LOCKED_UNTIL` IS NULL OR `LOCKED_UNTIL` < @before)
+	DeleteUpdatedBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+// AuditEvent is a security event such as a lockout. UserName or IP is the subject of login events,
+// and By is the user name of the administrator who caused the event if any.
+type AuditEvent struct {
+	Type     string
+	UserName string
+	IP       string
+	By       string
+	Until    *time.Time
+	At       time.Time
+}
+
+// Auditor records audit events.
+type Auditor interface {
+	Audit(ctx context.Context, e AuditEvent) error
+}
+
+type clientIPKey struct{}
+
+// WithClientIP returns a copy of ctx with the IP of the client, by which LoginThrottle counts failures.
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIPFrom returns the IP of the client in ctx, or an empty string if it is unknown.
+func ClientIPFrom(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+// loginSubject is a user name or a client IP whose failures are counted.
+type loginSubject struct {
+	userName string
+	ip       string
+}
+
+func (s loginSubject) String() string {
+	if s.ip != "" {
+		return "ip:" + s.ip
+	}
+	return "user:" + s.userName
+}
+
+// LoginThrottle locks out user names and client IPs with too many consecutive login failures
+// against brute-force attacks. The failures are stored by Repository so that they are shared among replicas.
+// User names are locked out whether they exist or not so that registered user names are not revealed.
+type LoginThrottle struct {
+	Repository LoginAttemptRepository
+	// Auditor records lockouts and unlocks. They are not recorded if it is nil.
+	Auditor Auditor
+	// MaxUserFailures is the number of failures of a user name to lock it out. User names are not locked out if it is not positive.
+	MaxUserFailures int
+	// MaxIPFailures is the number of failures from a client IP to lock it out. Client IPs are not locked out if it is not positive.
+	MaxIPFailures int
+	// Window is the period after which failures are forgotten. DefaultFailureWindow is used if it is not positive.
+	Window time.Duration
+	// BaseLockout is the first lockout, which doubles on each lockout of the same subject until MaxLockout.
+	// DefaultBaseLockout is used if it is not positive.
+	BaseLockout time.Duration
+	// MaxLockout is the limit of lockouts. DefaultMaxLockout is used if it is not positive.
+	// Lockouts are counted again from BaseLockout after a subject has no failures for MaxLockout.
+	MaxLockout time.Duration
+	// Now returns the current time. time.Now is used if it is nil.
+	Now func() time.Time
+}
+
+func (t *LoginThrottle) now() time.Time {
+	if t.Now == nil {
+		return time.Now()
+	}
+	return t.Now()
+}
+
+// subjects returns the subjects to count failures of, with the number of failures to lock them out.
+func (t *LoginThrottle) subjects(userName, ip string) map[loginSubject]int {
+	subjects := map[loginSubject]int{}
+	if t.MaxUserFailures > 0 {
+		subjects[loginSubject{userName: userName}] = t.MaxUserFailures
+	}
+	if t.MaxIPFailures > 0 && ip != "" {
+		subjects[loginSubject{ip: ip}] = t.MaxIPFailures
+	}
+	return subjects
+}
+
+// Check returns LockedError if the user name or the client IP is locked out.
+func (t *LoginThrottle) Check(ctx context.Context, userName, ip string) error {
+	var keys []string
+	for s := range t.subjects(userName, ip) {
+		keys = append(keys, s.String())
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	attempts, err := t.Repository.FindBySubjects(ctx, keys)
+	if err != nil {
+		return err
+	}
+	now := t.now()
+	var locked *LockedError
+	for _, a := range attempts {
+		if a.LockedUntil != nil && a.LockedUntil.After(now) && (locked == nil || a.LockedUntil.After(locked.Until)) {
+			locked = &LockedError{Until: *a.LockedUntil}
+		}
+	}
+	if locked != nil {
+		return locked
+	}
+	return nil
+}
+
+// Fail counts a login failure of the user name from the client IP, and locks out them if they have too many failures.
+func (t *LoginThrottle) Fail(ctx context.Context, userName, ip string) error {
+	window := t.Window
+	if window <= 0 {
+		window = DefaultFailureWindow
+	}
+	now := t.now()
+	for s, limit := range t.subjects(userName, ip) {
+		attempt, err := t.Repository.Fail(ctx, s.String(), now.Add(-window), now)
+		if err != nil {
+			return err
+		}
+		if attempt.Failures < limit {
+			continue
+		}
+		until := now.Add(t.lockout(attempt.Lockouts))
+		locked, err := t.Repository.Lock(ctx, s.String(), limit, until)
+		if err != nil {
+			return err
+		}
+		if locked {
+			if err := t.audit(ctx, AuditEvent{Type: AuditLoginLocked, UserName: s.userName, IP: s.ip, Until: &until, At: now}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// lockout returns BaseLockout doubled by lockouts so far, which is limited to MaxLockout.
+func (t *LoginThrottle) lockout(lockouts int) time.Duration {
+	d, limit := t.BaseLockout, t.MaxLockout
+	if d <= 0 {
+		d = DefaultBaseLockout
+	}
+	if limit <= 0 {
+		limit = DefaultMaxLockout
+	}
+	for i := 0; i < lockouts && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
+
+// Succeed forgets failures of the user name. Failures from the client IP are kept
+// so that an attacker cannot reset them by logging in to their own account.
+func (t *LoginThrottle) Succeed(ctx context.Context, userName string) error {
+	if t.MaxUserFailures <= 0 {
+		return nil
+	}
+	if err := t.Repository.Delete(ctx, loginSubject{userName: userName}.String()); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// Unlock forgets failures and lockouts of the user name and the client IP, either of which can be empty.
+// It returns ErrInvalid if both are empty, and ErrNotFound if neither has failures.
+func (t *LoginThrottle) Unlock(ctx context.Context, userName, ip, by string) error {
+	if userName == "" && ip == "" {
+		return fmt.Errorf("%w: user name or IP is required to unlock", ErrInvalid)
+	}
+	var subjects []loginSubject
+	if userName != "" {
+		subjects = append(subjects, loginSubject{userName: userName})
+	}
+	if ip != "" {
+		subjects = append(subjects, loginSubject{ip: ip})
+	}
+	found := false
+	for _, s := range subjects {
+		err := t.Repository.Delete(ctx, s.String())
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+		if err := t.audit(ctx, AuditEvent{Type: AuditLoginUnlocked, UserName: s.userName, IP: s.ip, By: by, At: t.now()}); err != nil {
+			return err
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w: no login failures to unlock", ErrNotFound)
+	}
+	return nil
+}
+
+// Cleanup deletes failures which are older than MaxLockout and not locked out, and returns the number of them.
+func (t *LoginThrottle) Cleanup(ctx context.Context) (int, error) {
+	retention := t.MaxLockout
+	if retention <= 0 {
+		retention = DefaultMaxLockout
+	}
+	return t.Repository.DeleteUpdatedBefore(ctx, t.now().Add(-retention))
+}
+
+func (t *LoginThrottle) audit(ctx context.Context, e AuditEvent) error {
+	if t.Auditor == nil {
+		return nil
+	}
+	return t.Auditor.Audit(ctx, e)
+}
+
+// UnlockQuery is a request to unlock login of a user name or a client IP.
+type UnlockQuery struct {
+	UserName string
+	IP       string
+}
+
+// UnlockLogin unlocks login if the caller is a user of ActorSystem. See LoginThrottle.Unlock.
+// It returns errors.ErrUnsupported if Throttle is nil.
+func (u *Usecase) UnlockLogin(ctx context.Context, q UnlockQuery) error {
+	if u.Throttle == nil {
+		return fmt.Errorf("login throttle: %w", errors.ErrUnsupported)
+	}
+	if err := u.authorizeAdmin(ctx); err != nil {
+		return err
+	}
+	admin, _ := UserFrom(ctx)
+	return u.Throttle.Unlock(ctx, q.UserName, q.IP, admin.UserName())
+}
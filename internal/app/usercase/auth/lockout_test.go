@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUsecase_Login_Throttle(t *testing.T) {
+	hasher := Bcrypt{Cost: bcrypt.MinCost}
+	hash, err := hasher.Hash("password")
+	assert.NoError(t, err)
+	users := map[string]*model.User{}
+	for _, name := range []string{"kawamura", "tanaka"} {
+		users[name], err = model.NewUser(uuid.New(), name, hash, model.ActorUser, nil, nil, nil)
+		assert.NoError(t, err)
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	auditor := &fakeAuditor{}
+	u := Usecase{
+		Repository: &stubUserRepository{
+			findByUserName: func(ctx context.Context, userName string) (*model.User, error) {
+				if user, ok := users[userName]; ok {
+					return user, nil
+				}
+				return nil, ErrNotFound
+			},
+		},
+		Hasher: hasher,
+		// JWT verifies the expiration by the wall clock.
+		Tokens: newTestTokenService(time.Now),
+		Throttle: &LoginThrottle{
+			Repository:      &fakeLoginAttemptRepository{attempts: map[string]LoginAttempt{}},
+			Auditor:         auditor,
+			MaxUserFailures: 3,
+			MaxIPFailures:   5,
+			BaseLockout:     time.Minute,
+			MaxLockout:      4 * time.Minute,
+			Now:             func() time.Time { return now },
+		},
+	}
+	// failures are counted only by user names without the client IP.
+	ctx := context.Background()
+	// fail fails login of userName times.
+	fail := func(ctx context.Context, userName string, times int) {
+		t.Helper()
+		for i := 0; i < times; i++ {
+			_, err := u.Login(ctx, userName, "wrong-password")
+			assert.ErrorIs(t, err, ErrUnauthorized)
+		}
+	}
+	assertLocked := func(ctx context.Context, userName string, until time.Time) {
+		t.Helper()
+		_, err := u.Login(ctx, userName, "password")
+		var locked *LockedError
+		if assert.ErrorAs(t, err, &locked) {
+			assert.Equal(t, until, locked.Until)
+		}
+		assert.ErrorIs(t, err, ErrLocked)
+	}
+
+	fail(ctx, "kawamura", 2)
+	_, err = u.Login(ctx, "kawamura", "password")
+	assert.NoError(t, err, "failures are forgotten on success")
+	fail(ctx, "kawamura", 3)
+	assertLocked(ctx, "kawamura", now.Add(time.Minute))
+	if assert.Len(t, auditor.events, 1) {
+		assert.Equal(t, AuditEvent{Type: AuditLoginLocked, UserName: "kawamura", Until: ptr(now.Add(time.Minute)), At: now}, auditor.events[0])
+	}
+
+	// lockouts double until MaxLockout.
+	for _, lockout := range []time.Duration{2 * time.Minute, 4 * time.Minute, 4 * time.Minute} {
+		now = now.Add(5 * time.Minute)
+		fail(ctx, "kawamura", 3)
+		assertLocked(ctx, "kawamura", now.Add(lockout))
+	}
+
+	// the client IP is locked out by failures of any user names.
+	now = now.Add(5 * time.Minute)
+	ip := WithClientIP(context.Background(), "198.51.100.1")
+	fail(ip, "unknown1", 2)
+	fail(ip, "unknown2", 2)
+	fail(ip, "tanaka", 1)
+	assertLocked(ip, "tanaka", now.Add(time.Minute))
+	_, err = u.Login(WithClientIP(context.Background(), "198.51.100.2"), "tanaka", "password")
+	assert.NoError(t, err, "another client IP is not locked out")
+}
+
+func TestUsecase_UnlockLogin(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	auditor := &fakeAuditor{}
+	repo := &fakeLoginAttemptRepository{attempts: map[string]LoginAttempt{}}
+	u := Usecase{Throttle: &LoginThrottle{Repository: repo, Auditor: auditor, MaxUserFailures: 1, MaxIPFailures: 1, Now: func() time.Time { return now }}}
+	system, err := model.NewUser(uuid.New(), "system", "hash", model.ActorSystem, nil, nil, nil)
+	assert.NoError(t, err)
+	manager, err := model.NewUser(uuid.New(), "manager", "hash", model.ActorManager, nil, nil, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, u.Throttle.Fail(context.Background(), "kawamura", "192.0.2.1"))
+	q := UnlockQuery{UserName: "kawamura", IP: "192.0.2.1"}
+
+	assert.ErrorIs(t, u.UnlockLogin(context.Background(), q), ErrUnauthorized)
+	assert.ErrorIs(t, u.UnlockLogin(WithUser(context.Background(), manager), q), ErrForbidden)
+	assert.ErrorIs(t, u.UnlockLogin(WithUser(context.Background(), system), UnlockQuery{}), ErrInvalid)
+	auditor.events = nil
+	assert.NoError(t, u.UnlockLogin(WithUser(context.Background(), system), q))
+	assert.Empty(t, repo.attempts)
+	assert.ElementsMatch(t, []AuditEvent{
+		{Type: AuditLoginUnlocked, UserName: "kawamura", By: "system", At: now},
+		{Type: AuditLoginUnlocked, IP: "192.0.2.1", By: "system", At: now},
+	}, auditor.events)
+	assert.ErrorIs(t, u.UnlockLogin(WithUser(context.Background(), system), q), ErrNotFound)
+
+	err = (&Usecase{}).UnlockLogin(WithUser(context.Background(), system), q)
+	assert.True(t, errors.Is(err, errors.ErrUnsupported))
+}
+
+// fakeAuditor records events.
+type fakeAuditor struct {
+	events []AuditEvent
+}
+
+// Audit implements Auditor.
+func (a *fakeAuditor) Audit(ctx context.Context, e AuditEvent) error {
+	a.events = append(a.events, e)
+	return nil
+}
+
+// fakeLoginAttemptRepository stores login attempts in the map.
+type fakeLoginAttemptRepository struct {
+	attempts map[string]LoginAttempt
+}
+
+// FindBySubjects implements LoginAttemptRepository.
+func (r *fakeLoginAttemptRepository) FindBySubjects(ctx context.Context, subjects []string) ([]LoginAttempt, error) {
+	var attempts []LoginAttempt
+	for _, s := range subjects {
+		if a, ok := r.attempts[s]; ok {
+			attempts = append(attempts, a)
+		}
+	}
+	return attempts, nil
+}
+
+// Fail implements LoginAttemptRepository.
+func (r *fakeLoginAttemptRepository) Fail(ctx context.Context, subject string, since, at time.Time) (*LoginAttempt, error) {
+	a := r.attempts[subject]
+	a.Subject = subject
+	if a.UpdatedAt.Before(since) {
+		a.Failures = 0
+	}
+	a.Failures++
+	a.UpdatedAt = at
+	r.attempts[subject] = a
+	return &a, nil
+}
+
+// Lock implements LoginAttemptRepository.
+func (r *fakeLoginAttemptRepository) Lock(ctx context.Context, subject string, failures int, until time.Time) (bool, error) {
+	a, ok := r.attempts[subject]
+	if !ok || a.Failures < failures {
+		return false, nil
+	}
+	a.Failures, a.Lockouts, a.LockedUntil = 0, a.Lockouts+1, &until
+	r.attempts[subject] = a
+	return true, nil
+}
+
+// Delete implements LoginAttemptRepository.
+func (r *fakeLoginAttemptRepository) Delete(ctx context.Context, subject string) error {
+	if _, ok := r.attempts[subject]; !ok {
+		return ErrNotFound
+	}
+	delete(r.attempts, subject)
+	return nil
+}
+
+// DeleteUpdatedBefore implements LoginAttemptRepository.
+func (r *fakeLoginAttemptRepository) DeleteUpdatedBefore(ctx context.Context, before time.Time) (int, error) {
+	panic("not implemented")
+}
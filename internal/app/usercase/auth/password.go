@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher hashes passwords to store and compares them.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Compare returns ErrUnauthorized if password does not match hash.
+	Compare(hash, password string) error
+}
+
+// Bcrypt is a PasswordHasher with bcrypt. bcrypt.DefaultCost is used if Cost is zero.
+type Bcrypt struct {
+	Cost int
+}
+
+// Hash implements PasswordHasher.
+func (b Bcrypt) Hash(password string) (string, error) {
+	cost := b.Cost
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return "", fmt.Errorf("hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// Compare implements PasswordHasher.
+func (Bcrypt) Compare(hash, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrUnauthorized
+	}
+	if err != nil {
+		return fmt.Errorf("compare password: %w", err)
+	}
+	return nil
+}
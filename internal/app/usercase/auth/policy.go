@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/sample"
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+	"github.com/google/uuid"
+)
+
+type (
+	userKey   struct{}
+	apiKeyKey struct{}
+)
+
+// WithUser returns a copy of ctx with the authenticated user, who is the caller authorized by ActorPolicy.
+func WithUser(ctx context.Context, user *model.User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFrom returns the authenticated user in ctx, or false if the caller is anonymous.
+func UserFrom(ctx context.Context) (*model.User, bool) {
+	user, ok := ctx.Value(userKey{}).(*model.User)
+	return user, ok && user != nil
+}
+
+// WithAPIKey returns a copy of ctx with the API key of the caller, which is authorized by ActorPolicy with the scopes.
+func WithAPIKey(ctx context.Context, key *APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyKey{}, key)
+}
+
+// APIKeyFrom returns the API key of the caller in ctx, or false unless the caller uses an API key.
+func APIKeyFrom(ctx context.Context) (*APIKey, bool) {
+	key, ok := ctx.Value(apiKeyKey{}).(*APIKey)
+	return key, ok && key != nil
+}
+
+// Authenticate verifies accessToken and returns the user of it. A token of the issuer of External is verified
+// by External, and the user is not looked up because external users are not stored.
+// It returns ErrUnauthorized if accessToken is invalid or the user no longer exists.
+func (u *Usecase) Authenticate(ctx context.Context, accessToken string) (*model.User, error) {
+	if u.External != nil && u.External.Issues(accessToken) {
+		return u.External.Verify(ctx, accessToken)
+	}
+	claims, err := u.Tokens.JWT.Verify(accessToken)
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid subject: %w", ErrUnauthorized, err)
+	}
+	user, err := u.Repository.FindByID(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("%w: user is not found", ErrUnauthorized)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ActorPolicy is a sample.Policy allowing operations by the actor of the user or the API key in the context.
+// API keys need the scopes of operations as well. Anonymous callers are not allowed any operations.
+type ActorPolicy map[model.Actor][]sample.Operation
+
+var _ sample.Policy = ActorPolicy{}
+
+// DefaultActorPolicy allows ActorUser to read, add, edit and delete their own samples, ActorManager to do so
+// on all samples and list deleted ones as well, and ActorSystem to restore and hard-delete them and search the history in addition.
+//
+// ActorUser was read-only before samples had owners. Ownership replaces the rule because users edit only samples they added,
+// which Access limits them to, and MANAGER is still needed to edit samples of others.
+func DefaultActorPolicy() ActorPolicy {
+	user := []sample.Operation{
+		sample.OperationRead,
+		sample.OperationAdd,
+		sample.OperationEdit,
+		sample.OperationDelete,
+	}
+	manager := append(user, sample.OperationListDeleted, sample.OperationIncludeDeleted)
+	return ActorPolicy{
+		model.ActorUser:    user,
+		model.ActorManager: manager,
+		model.ActorSystem:  append(manager, sample.OperationRestore, sample.OperationHardDelete, sample.OperationAudit),
+	}
+}
+
+// Authorize implements sample.Policy.
+func (p ActorPolicy) Authorize(ctx context.Context, op sample.Operation) error {
+	actor, ok := callerActor(ctx)
+	if !ok {
+		return fmt.Errorf("%w: %s needs authentication", sample.ErrUnauthorized, op)
+	}
+	if key, ok := APIKeyFrom(ctx); ok && !key.Allows(op) {
+		return fmt.Errorf("%w: API key %s does not have %s scope", sample.ErrForbidden, key.Prefix, scopeOf(op))
+	}
+	for _, allowed := range p[actor] {
+		if allowed == op {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %v cannot %s", sample.ErrForbidden, actor, op)
+}
+
+// Access implements sample.Policy. Callers of ActorUser can access only samples owned by the user,
+// and API keys of ActorUser cannot access any samples because they are not users.
+func (p ActorPolicy) Access(ctx context.Context) (sample.Access, error) {
+	actor, ok := callerActor(ctx)
+	if !ok {
+		return sample.Access{}, fmt.Errorf("%w: access to samples needs authentication", sample.ErrUnauthorized)
+	}
+	access := sample.Access{OwnedOnly: actor == model.ActorUser}
+	if user, ok := UserFrom(ctx); ok {
+		id := user.ID()
+		access.UserID, access.Caller = &id, "user:"+user.UserName()
+	} else if key, ok := APIKeyFrom(ctx); ok {
+		access.Caller = "apikey:" + key.Prefix
+	}
+	return access, nil
+}
+
+// callerActor returns the actor of the user or the API key in ctx, or false if the caller is anonymous.
+func callerActor(ctx context.Context) (model.Actor, bool) {
+	if user, ok := UserFrom(ctx); ok {
+		return user.Actor(), true
+	}
+	if key, ok := APIKeyFrom(ctx); ok {
+		return key.Actor, true
+	}
+	return model.ActorUnknown, false
+}
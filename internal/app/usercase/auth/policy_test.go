@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/sample"
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActorPolicy_Authorize(t *testing.T) {
+	tests := map[string]struct {
+		actor   *model.Actor
+		op      sample.Operation
+		wantErr error
+	}{
+		"return ErrUnauthorized to anonymous": {
+			op:      sample.OperationRead,
+			wantErr: sample.ErrUnauthorized,
+		},
+		"allow user to read": {
+			actor: ptr(model.ActorUser),
+			op:    sample.OperationRead,
+		},
+		"allow user to edit": {
+			actor: ptr(model.ActorUser),
+			op:    sample.OperationEdit,
+		},
+		"return ErrForbidden when user lists deleted samples": {
+			actor:   ptr(model.ActorUser),
+			op:      sample.OperationListDeleted,
+			wantErr: sample.ErrForbidden,
+		},
+		"allow manager to edit": {
+			actor: ptr(model.ActorManager),
+			op:    sample.OperationEdit,
+		},
+		"allow manager to delete": {
+			actor: ptr(model.ActorManager),
+			op:    sample.OperationDelete,
+		},
+		"return ErrForbidden when manager hard-deletes": {
+			actor:   ptr(model.ActorManager),
+			op:      sample.OperationHardDelete,
+			wantErr: sample.ErrForbidden,
+		},
+		"return ErrForbidden when manager restores": {
+			actor:   ptr(model.ActorManager),
+			op:      sample.OperationRestore,
+			wantErr: sample.ErrForbidden,
+		},
+		"allow system to hard-delete": {
+			actor: ptr(model.ActorSystem),
+			op:    sample.OperationHardDelete,
+		},
+		"allow system to restore": {
+			actor: ptr(model.ActorSystem),
+			op:    sample.OperationRestore,
+		},
+		"allow system to search the history": {
+			actor: ptr(model.ActorSystem),
+			op:    sample.OperationAudit,
+		},
+		"return ErrForbidden when manager searches the history": {
+			actor:   ptr(model.ActorManager),
+			op:      sample.OperationAudit,
+			wantErr: sample.ErrForbidden,
+		},
+		"return ErrForbidden to unknown actor": {
+			actor:   ptr(model.ActorUnknown),
+			op:      sample.OperationRead,
+			wantErr: sample.ErrForbidden,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.actor != nil {
+				user, err := model.NewUser(uuid.New(), "kawamura", "hash", *tt.actor, nil, nil, nil)
+				assert.NoError(t, err)
+				ctx = WithUser(ctx, user)
+			}
+			err := DefaultActorPolicy().Authorize(ctx, tt.op)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestActorPolicy_Access(t *testing.T) {
+	user, err := model.NewUser(uuid.New(), "kawamura", "hash", model.ActorUser, nil, nil, nil)
+	assert.NoError(t, err)
+	manager, err := model.NewUser(uuid.New(), "manager", "hash", model.ActorManager, nil, nil, nil)
+	assert.NoError(t, err)
+	tests := map[string]struct {
+		ctx     context.Context
+		want    sample.Access
+		wantErr error
+	}{
+		"limit user to their own samples": {
+			ctx:  WithUser(context.Background(), user),
+			want: sample.Access{UserID: ptr(user.ID()), OwnedOnly: true, Caller: "user:kawamura"},
+		},
+		"allow manager to access all samples": {
+			ctx:  WithUser(context.Background(), manager),
+			want: sample.Access{UserID: ptr(manager.ID()), Caller: "user:manager"},
+		},
+		"limit API key of user to samples owned by no one": {
+			ctx:  WithAPIKey(context.Background(), &APIKey{Prefix: "gak_1f3c9a0b", Actor: model.ActorUser}),
+			want: sample.Access{OwnedOnly: true, Caller: "apikey:gak_1f3c9a0b"},
+		},
+		"allow API key of manager to access all samples without owner": {
+			ctx:  WithAPIKey(context.Background(), &APIKey{Prefix: "gak_1f3c9a0b", Actor: model.ActorManager}),
+			want: sample.Access{Caller: "apikey:gak_1f3c9a0b"},
+		},
+		"return ErrUnauthorized to anonymous": {
+			ctx:     context.Background(),
+			wantErr: sample.ErrUnauthorized,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := DefaultActorPolicy().Access(tt.ctx)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestUsecase_Authenticate(t *testing.T) {
+	user, err := model.NewUser(uuid.New(), "kawamura", "hash", model.ActorManager, nil, nil, nil)
+	assert.NoError(t, err)
+	u := Usecase{
+		Repository: &stubUserRepository{
+			findByID: func(ctx context.Context, id uuid.UUID) (*model.User, error) {
+				if id != user.ID() {
+					return nil, ErrNotFound
+				}
+				return user, nil
+			},
+		},
+		// JWT verifies the expiration by the wall clock.
+		Tokens: newTestTokenService(time.Now),
+	}
+	ctx := context.Background()
+	issued, err := u.Tokens.Issue(ctx, user)
+	assert.NoError(t, err)
+
+	got, err := u.Authenticate(ctx, issued.AccessToken())
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID(), got.ID())
+
+	_, err = u.Authenticate(ctx, issued.AccessToken()+"x")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	deleted, err := model.NewUser(uuid.New(), "deleted", "hash", model.ActorManager, nil, nil, nil)
+	assert.NoError(t, err)
+	issued, err = u.Tokens.Issue(ctx, deleted)
+	assert.NoError(t, err)
+	_, err = u.Authenticate(ctx, issued.AccessToken())
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
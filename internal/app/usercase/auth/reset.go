@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+)
+
+// DefaultResetTTL is the lifetime of reset codes if it is not configured.
+const DefaultResetTTL = 30 * time.Minute
+
+// ErrInvalidResetCode is returned when the reset code is wrong, expired or already used.
+var ErrInvalidResetCode = model.ErrInvalidResetCode
+
+// Notifier tells reset codes to users.
+type Notifier interface {
+	// NotifyResetCode tells code to user, which is valid until until.
+	NotifyResetCode(ctx context.Context, user *model.User, code string, until time.Time) error
+}
+
+func (u *Usecase) now() time.Time {
+	if u.Now == nil {
+		return time.Now()
+	}
+	return u.Now()
+}
+
+// RequestPasswordReset generates a reset code of the user and notifies it by Notifier.
+// It returns nil even if the user does not exist so that registered user names are not revealed,
+// and errors.ErrUnsupported if Notifier is nil.
+func (u *Usecase) RequestPasswordReset(ctx context.Context, userName string) error {
+	if u.Notifier == nil {
+		return errors.ErrUnsupported
+	}
+	user, err := u.Repository.FindByUserName(ctx, userName)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	code, err := randomToken()
+	if err != nil {
+		return err
+	}
+	ttl := u.ResetTTL
+	if ttl <= 0 {
+		ttl = DefaultResetTTL
+	}
+	until := u.now().Add(ttl)
+	// only the hash is stored so that a leak of the database does not allow to reset passwords.
+	user.RequestReset(HashToken(code), until)
+	if err := u.Repository.Update(ctx, user); err != nil {
+		return err
+	}
+	return u.Notifier.NotifyResetCode(ctx, user, code, until)
+}
+
+// ConfirmPasswordReset sets password of the user if code is valid, and revokes all tokens of the user.
+// It returns ErrInvalid if password does not satisfy the requirements, and ErrInvalidResetCode if code is invalid.
+func (u *Usecase) ConfirmPasswordReset(ctx context.Context, userName, code, password string) error {
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+	now := u.now()
+	return u.transaction(ctx, func(ctx context.Context) error {
+		user, err := u.Repository.FindByUserName(ctx, userName)
+		if errors.Is(err, ErrNotFound) {
+			return ErrInvalidResetCode
+		}
+		if err != nil {
+			return err
+		}
+		resetCode := HashToken(code)
+		if err := user.VerifyResetCode(resetCode, now); err != nil {
+			return err
+		}
+		hash, err := u.hasher().Hash(password)
+		if err != nil {
+			return err
+		}
+		if err := user.ChangePassword(hash); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalid, err)
+		}
+		// the code is consumed on the condition that it is not changed since verified,
+		// so that concurrent confirmations with the same code do not both succeed.
+		if err := u.Repository.ResetPassword(ctx, user, resetCode); err != nil {
+			return err
+		}
+		return u.Tokens.RevokeUser(ctx, user.ID())
+	})
+}
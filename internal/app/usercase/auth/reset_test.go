@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUsecase_PasswordReset(t *testing.T) {
+	hasher := Bcrypt{Cost: bcrypt.MinCost}
+	hash, err := hasher.Hash("password")
+	assert.NoError(t, err)
+	stored, err := model.NewUser(uuid.New(), "kawamura", hash, model.ActorUser, nil, nil, nil)
+	assert.NoError(t, err)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	notifier := &stubNotifier{}
+	u := Usecase{
+		Repository: &stubUserRepository{
+			findByUserName: func(ctx context.Context, userName string) (*model.User, error) {
+				if userName != stored.UserName() {
+					return nil, ErrNotFound
+				}
+				copied := *stored
+				return &copied, nil
+			},
+			update: func(ctx context.Context, user *model.User) error {
+				stored = user
+				return nil
+			},
+			resetPassword: func(ctx context.Context, user *model.User, resetCode string) error {
+				if stored.ResetCode() == nil || *stored.ResetCode() != resetCode {
+					return ErrInvalidResetCode
+				}
+				stored = user
+				return nil
+			},
+		},
+		Hasher:   hasher,
+		Tokens:   newTestTokenService(func() time.Time { return now }),
+		Notifier: notifier,
+		ResetTTL: time.Minute,
+		Now:      func() time.Time { return now },
+	}
+	ctx := context.Background()
+	issued, err := u.Tokens.Issue(ctx, stored)
+	assert.NoError(t, err)
+
+	// unknown users are not revealed.
+	assert.NoError(t, u.RequestPasswordReset(ctx, "unknown"))
+	assert.Empty(t, notifier.codes)
+
+	assert.NoError(t, u.RequestPasswordReset(ctx, "kawamura"))
+	assert.Len(t, notifier.codes, 1)
+	code := notifier.codes[0]
+	assert.Equal(t, HashToken(code), *stored.ResetCode())
+	assert.Equal(t, now.Add(time.Minute), *stored.ResetUntil())
+
+	assert.ErrorIs(t, u.ConfirmPasswordReset(ctx, "kawamura", "wrong", "new-password"), ErrInvalidResetCode)
+	assert.ErrorIs(t, u.ConfirmPasswordReset(ctx, "kawamura", code, "short"), ErrInvalid)
+	assert.NoError(t, u.ConfirmPasswordReset(ctx, "kawamura", code, "new-password"))
+	assert.NoError(t, hasher.Compare(stored.EncryptPassword(), "new-password"))
+	assert.Nil(t, stored.ResetCode())
+	// the code is used only once, and tokens issued before are revoked.
+	assert.ErrorIs(t, u.ConfirmPasswordReset(ctx, "kawamura", code, "another-password"), ErrInvalidResetCode)
+	_, err = u.Tokens.Refresh(ctx, issued.RefreshToken)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	// a confirmation which read the code before another confirmation consumes it fails.
+	assert.NoError(t, u.RequestPasswordReset(ctx, "kawamura"))
+	code = notifier.codes[1]
+	read := *stored
+	assert.NoError(t, u.ConfirmPasswordReset(ctx, "kawamura", code, "new-password"))
+	u.Repository.(*stubUserRepository).findByUserName = func(ctx context.Context, userName string) (*model.User, error) {
+		copied := read
+		return &copied, nil
+	}
+	assert.ErrorIs(t, u.ConfirmPasswordReset(ctx, "kawamura", code, "another-password"), ErrInvalidResetCode)
+	assert.NoError(t, hasher.Compare(stored.EncryptPassword(), "new-password"))
+	u.Repository.(*stubUserRepository).findByUserName = func(ctx context.Context, userName string) (*model.User, error) {
+		copied := *stored
+		return &copied, nil
+	}
+
+	// the code expires.
+	assert.NoError(t, u.RequestPasswordReset(ctx, "kawamura"))
+	now = now.Add(time.Minute)
+	assert.ErrorIs(t, u.ConfirmPasswordReset(ctx, "kawamura", notifier.codes[2], "new-password"), ErrInvalidResetCode)
+}
+
+func TestUsecase_RequestPasswordReset_Unsupported(t *testing.T) {
+	u := Usecase{Repository: &stubUserRepository{}}
+	err := u.RequestPasswordReset(context.Background(), "kawamura")
+	assert.True(t, errors.Is(err, errors.ErrUnsupported))
+}
+
+// stubNotifier records notified codes.
+type stubNotifier struct {
+	codes []string
+}
+
+// NotifyResetCode implements Notifier.
+func (n *stubNotifier) NotifyResetCode(ctx context.Context, user *model.User, code string, until time.Time) error {
+	n.codes = append(n.codes, code)
+	return nil
+}
+
+var _ Notifier = (*stubNotifier)(nil)
@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/transaction"
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultTokenTTL is the lifetime of access tokens if it is not configured.
+	DefaultTokenTTL = 15 * time.Minute
+	// DefaultRefreshTTL is the lifetime of refresh tokens if it is not configured.
+	DefaultRefreshTTL = 30 * 24 * time.Hour
+)
+
+// ErrTokenReused is returned when a rotated refresh token is presented again,
+// which means that the token has been stolen. All tokens of the family are revoked.
+var ErrTokenReused = fmt.Errorf("%w: refresh token is reused", ErrUnauthorized)
+
+// IssuedToken is a token with the plain refresh token, which is told to the user only once.
+type IssuedToken struct {
+	*model.Token
+	RefreshToken string
+}
+
+// RefreshToken is a stored refresh token. Tokens rotated from the same login belong to the same Family.
+type RefreshToken struct {
+	ID        uuid.UUID
+	Family    uuid.UUID
+	UserID    uuid.UUID
+	Hash      string
+	ExpiresAt time.Time
+	// RotatedAt is the time when the token is exchanged for a new one.
+	RotatedAt *time.Time
+	RevokedAt *time.Time
+}
+
+type TokenRepository interface {
+	// INSERT INTO `TOKENS` (`ID`, `FAMILY_ID`, `USER_ID`, `ENCRYPT_REFRESH_TOKEN`, `EXPIRES_AT`) VALUES (...)
+	Insert(ctx context.Context, token *RefreshToken) error
+	// SELECT ... FROM `TOKENS` WHERE `ENCRYPT_REFRESH_TOKEN` = @hash
+	//
+	// FindByHash returns ErrNotFound if the token does not exist.
+	FindByHash(ctx context.Context, hash string) (*RefreshToken, error)
+	// UPDATE `TOKENS` SET `ROTATED_AT` = @at WHERE `ID` = @id AND `ROTATED_AT` IS NULL
+	//
+	// MarkRotated returns false if the token is already rotated.
+	MarkRotated(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+	// UPDATE `TOKENS` SET `REVOKED_AT` = @at WHERE `FAMILY_ID` = @family AND `REVOKED_AT` IS NULL
+	RevokeFamily(ctx context.Context, family uuid.UUID, at time.Time) error
+	// UPDATE `TOKENS` SET `REVOKED_AT` = @at WHERE `USER_ID` = @userID AND `REVOKED_AT` IS NULL
+	RevokeUser(ctx context.Context, userID uuid.UUID, at time.Time) error
+	// DELETE FROM `TOKENS` WHERE `EXPIRES_AT` < @before
+	//
+	// DeleteExpiredBefore returns the number of deleted tokens.
+	DeleteExpiredBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+// TokenService issues signed access tokens and rotating refresh tokens.
+type TokenService struct {
+	Repository TokenRepository
+	Users      UserRepository
+	JWT        *JWT
+	// Transaction rotates refresh tokens atomically. Tokens are rotated without transaction if it is nil.
+	Transaction transaction.Manager
+	// AccessTTL is the lifetime of access tokens. DefaultTokenTTL is used if it is not positive.
+	AccessTTL time.Duration
+	// RefreshTTL is the lifetime of refresh tokens. DefaultRefreshTTL is used if it is not positive.
+	RefreshTTL time.Duration
+	// Now returns the current time. time.Now is used if it is nil.
+	Now func() time.Time
+}
+
+func (s *TokenService) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+func (s *TokenService) transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.Transaction == nil {
+		return transaction.Nop{}.Do(ctx, fn)
+	}
+	return s.Transaction.Do(ctx, fn)
+}
+
+// Issue issues a token of a new family to user.
+func (s *TokenService) Issue(ctx context.Context, user *model.User) (*IssuedToken, error) {
+	return s.issue(ctx, user, uuid.New())
+}
+
+func (s *TokenService) issue(ctx context.Context, user *model.User, family uuid.UUID) (*IssuedToken, error) {
+	var (
+		id         = uuid.New()
+		now        = s.now()
+		accessTTL  = s.AccessTTL
+		refreshTTL = s.RefreshTTL
+	)
+	if accessTTL <= 0 {
+		accessTTL = DefaultTokenTTL
+	}
+	if refreshTTL <= 0 {
+		refreshTTL = DefaultRefreshTTL
+	}
+	expiresAt := now.Add(accessTTL)
+	access, err := s.JWT.Sign(Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID().String(),
+			ID:        id.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		UserName: user.UserName(),
+		Actor:    user.Actor(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	token, err := user.IssueToken(id, access, HashToken(refresh), expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Repository.Insert(ctx, &RefreshToken{
+		ID:        id,
+		Family:    family,
+		UserID:    user.ID(),
+		Hash:      token.EncryptRefreshToken(),
+		ExpiresAt: now.Add(refreshTTL),
+	}); err != nil {
+		return nil, err
+	}
+	return &IssuedToken{
+		Token:        token,
+		RefreshToken: refresh,
+	}, nil
+}
+
+// Refresh exchanges refreshToken for a new token of the same family. refreshToken cannot be used again.
+// It returns ErrUnauthorized if refreshToken is unknown, expired or revoked,
+// and ErrTokenReused after revoking the family if refreshToken is already exchanged.
+func (s *TokenService) Refresh(ctx context.Context, refreshToken string) (*IssuedToken, error) {
+	var (
+		issued *IssuedToken
+		family uuid.UUID
+		now    = s.now()
+	)
+	err := s.transaction(ctx, func(ctx context.Context) error {
+		stored, err := s.find(ctx, refreshToken, now)
+		if err != nil {
+			return err
+		}
+		family = stored.Family
+		if stored.RotatedAt != nil {
+			return ErrTokenReused
+		}
+		// the token may be rotated concurrently after found.
+		ok, err := s.Repository.MarkRotated(ctx, stored.ID, now)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrTokenReused
+		}
+		user, err := s.Users.FindByID(ctx, stored.UserID)
+		if errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("%w: user is not found", ErrUnauthorized)
+		}
+		if err != nil {
+			return err
+		}
+		issued, err = s.issue(ctx, user, stored.Family)
+		return err
+	})
+	if errors.Is(err, ErrTokenReused) {
+		// the family is revoked out of the transaction which is rolled back.
+		if err := s.Repository.RevokeFamily(ctx, family, now); err != nil {
+			return nil, err
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return issued, nil
+}
+
+// Revoke revokes all tokens of the family of refreshToken.
+// It returns ErrUnauthorized if refreshToken is unknown, expired or revoked.
+func (s *TokenService) Revoke(ctx context.Context, refreshToken string) error {
+	now := s.now()
+	stored, err := s.find(ctx, refreshToken, now)
+	if err != nil {
+		return err
+	}
+	return s.Repository.RevokeFamily(ctx, stored.Family, now)
+}
+
+// RevokeUser revokes all tokens of the user.
+func (s *TokenService) RevokeUser(ctx context.Context, userID uuid.UUID) error {
+	return s.Repository.RevokeUser(ctx, userID, s.now())
+}
+
+// find returns the stored token of refreshToken unless it is expired or revoked at now.
+func (s *TokenService) find(ctx context.Context, refreshToken string, now time.Time) (*RefreshToken, error) {
+	stored, err := s.Repository.FindByHash(ctx, HashToken(refreshToken))
+	if errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("%w: refresh token is unknown", ErrUnauthorized)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stored.RevokedAt != nil {
+		return nil, fmt.Errorf("%w: refresh token is revoked", ErrUnauthorized)
+	}
+	if !now.Before(stored.ExpiresAt) {
+		return nil, fmt.Errorf("%w: refresh token is expired", ErrUnauthorized)
+	}
+	return stored, nil
+}
+
+// Cleanup deletes expired tokens and returns the number of them.
+func (s *TokenService) Cleanup(ctx context.Context) (int, error) {
+	return s.Repository.DeleteExpiredBefore(ctx, s.now())
+}
+
+// randomToken returns a URL-safe random string of 256 bits.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the hash of a random token to store.
+// A fast hash is enough unlike passwords because random tokens cannot be guessed.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/domain/auth/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenService_Refresh(t *testing.T) {
+	user, err := model.NewUser(uuid.New(), "kawamura", "hash", model.ActorUser, nil, nil, nil)
+	assert.NoError(t, err)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newTestTokenService(func() time.Time { return now })
+	s.Users = &stubUserRepository{
+		findByID: func(ctx context.Context, id uuid.UUID) (*model.User, error) {
+			return user, nil
+		},
+	}
+	ctx := context.Background()
+
+	first, err := s.Issue(ctx, user)
+	assert.NoError(t, err)
+
+	second, err := s.Refresh(ctx, first.RefreshToken)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+	// the first token is reused by an attacker, which revokes the second token as well.
+	_, err = s.Refresh(ctx, first.RefreshToken)
+	assert.ErrorIs(t, err, ErrTokenReused)
+	_, err = s.Refresh(ctx, second.RefreshToken)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	// another login is not affected.
+	other, err := s.Issue(ctx, user)
+	assert.NoError(t, err)
+	_, err = s.Refresh(ctx, other.RefreshToken)
+	assert.NoError(t, err)
+
+	_, err = s.Refresh(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestTokenService_Refresh_Expired(t *testing.T) {
+	user, err := model.NewUser(uuid.New(), "kawamura", "hash", model.ActorUser, nil, nil, nil)
+	assert.NoError(t, err)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newTestTokenService(func() time.Time { return now })
+	ctx := context.Background()
+
+	issued, err := s.Issue(ctx, user)
+	assert.NoError(t, err)
+	now = now.Add(time.Hour)
+	_, err = s.Refresh(ctx, issued.RefreshToken)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	deleted, err := s.Cleanup(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+}
+
+func TestTokenService_Revoke(t *testing.T) {
+	user, err := model.NewUser(uuid.New(), "kawamura", "hash", model.ActorUser, nil, nil, nil)
+	assert.NoError(t, err)
+	s := newTestTokenService(nil)
+	ctx := context.Background()
+
+	issued, err := s.Issue(ctx, user)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Revoke(ctx, issued.RefreshToken))
+	_, err = s.Refresh(ctx, issued.RefreshToken)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.ErrorIs(t, s.Revoke(ctx, issued.RefreshToken), ErrUnauthorized)
+}
+
+func TestJWT_Verify(t *testing.T) {
+	signer, err := NewHS256([]byte("secret"), "go-api")
+	assert.NoError(t, err)
+	other, err := NewHS256([]byte("other"), "go-api")
+	assert.NoError(t, err)
+	user, err := model.NewUser(uuid.New(), "kawamura", "hash", model.ActorManager, nil, nil, nil)
+	assert.NoError(t, err)
+	s := newTestTokenService(nil)
+	s.JWT = signer
+	issued, err := s.Issue(context.Background(), user)
+	assert.NoError(t, err)
+
+	claims, err := signer.Verify(issued.AccessToken())
+	assert.NoError(t, err)
+	assert.Equal(t, "kawamura", claims.UserName)
+	assert.Equal(t, model.ActorManager, claims.Actor)
+
+	_, err = other.Verify(issued.AccessToken())
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	_, err = signer.Verify("invalid")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+// newTestTokenService returns TokenService whose access tokens live for a minute and refresh tokens for 10 minutes.
+func newTestTokenService(now func() time.Time) *TokenService {
+	signer, err := NewHS256(nil, "test")
+	if err != nil {
+		panic(err)
+	}
+	return &TokenService{
+		Repository: &fakeTokenRepository{tokens: map[uuid.UUID]RefreshToken{}},
+		JWT:        signer,
+		AccessTTL:  time.Minute,
+		RefreshTTL: 10 * time.Minute,
+		Now:        now,
+	}
+}
+
+// fakeTokenRepository stores tokens in the map.
+type fakeTokenRepository struct {
+	tokens map[uuid.UUID]RefreshToken
+}
+
+// Insert implements TokenRepository.
+func (r *fakeTokenRepository) Insert(ctx context.Context, token *RefreshToken) error {
+	r.tokens[token.ID] = *token
+	return nil
+}
+
+// FindByHash implements TokenRepository.
+func (r *fakeTokenRepository) FindByHash(ctx context.Context, hash string) (*RefreshToken, error) {
+	for _, t := range r.tokens {
+		if t.Hash == hash {
+			return &t, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// MarkRotated implements TokenRepository.
+func (r *fakeTokenRepository) MarkRotated(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	t := r.tokens[id]
+	if t.RotatedAt != nil {
+		return false, nil
+	}
+	t.RotatedAt = &at
+	r.tokens[id] = t
+	return true, nil
+}
+
+// RevokeFamily implements TokenRepository.
+func (r *fakeTokenRepository) RevokeFamily(ctx context.Context, family uuid.UUID, at time.Time) error {
+	for id, t := range r.tokens {
+		if t.Family == family {
+			t.RevokedAt = &at
+			r.tokens[id] = t
+		}
+	}
+	return nil
+}
+
+// RevokeUser implements TokenRepository.
+func (r *fakeTokenRepository) RevokeUser(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	for id, t := range r.tokens {
+		if t.UserID == userID {
+			t.RevokedAt = &at
+			r.tokens[id] = t
+		}
+	}
+	return nil
+}
+
+// DeleteExpiredBefore implements TokenRepository.
+func (r *fakeTokenRepository) DeleteExpiredBefore(ctx context.Context, before time.Time) (int, error) {
+	deleted := 0
+	for id, t := range r.tokens {
+		if t.ExpiresAt.Before(before) {
+			delete(r.tokens, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+var _ TokenRepository = (*fakeTokenRepository)(nil)
@@ -0,0 +1,20 @@
+package lock
+
+import "context"
+
+// Locker is a named lock shared among processes so that only one of replicas runs a job at a time.
+type Locker interface {
+	// TryLock acquires the lock of name without waiting.
+	// It returns false if another holder has the lock. unlock must be called if the lock is acquired.
+	TryLock(ctx context.Context, name string) (unlock func() error, ok bool, err error)
+}
+
+// Local is a Locker which always acquires the lock. It is for a single process.
+type Local struct{}
+
+// TryLock implements Locker.
+func (Local) TryLock(ctx context.Context, name string) (func() error, bool, error) {
+	return func() error { return nil }, true, nil
+}
+
+var _ Locker = Local{}
@@ -0,0 +1,98 @@
+package sample
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
+	"github.com/google/uuid"
+)
+
+// Keyset is a position of a sample in the order of sort keys.
+// Values are the formatted values of the sort keys other than ID.
+type Keyset struct {
+	Values []string
+	ID     uuid.UUID
+}
+
+// KeysetQuery is a query to find samples next to After in the order of Sort.
+// Samples before After are found if Backward is true.
+type KeysetQuery struct {
+	Filter       SampleFilter
+	Sort         []SortKey
+	Fields       Fields
+	After        *Keyset
+	Backward     bool
+	Limit        int
+	IncludeTotal bool
+}
+
+// KeysetPage is samples found by KeysetQuery in the order of sort keys regardless of the direction.
+// Keysets[i] is the keyset of Samples[i]. Total is nil unless KeysetQuery.IncludeTotal is true.
+type KeysetPage struct {
+	Samples []model.Sample
+	Keysets []Keyset
+	Total   *int
+}
+
+// Cursor is a position of a page which is passed to clients as an opaque string.
+type Cursor struct {
+	Sort     string    `json:"s"`
+	Values   []string  `json:"v"`
+	ID       uuid.UUID `json:"id"`
+	Backward bool      `json:"b,omitempty"`
+}
+
+// CursorCodec encodes cursors to signed strings so that clients cannot forge them.
+type CursorCodec struct {
+	secret []byte
+}
+
+// ErrEmptySecret is returned by NewCursorCodec if secret is empty.
+var ErrEmptySecret = errors.New("cursor secret is empty")
+
+// NewCursorCodec returns CursorCodec signing cursors with secret.
+// secret must be shared among processes so that a cursor is valid on any of them.
+func NewCursorCodec(secret []byte) (*CursorCodec, error) {
+	if len(secret) == 0 {
+		return nil, ErrEmptySecret
+	}
+	return &CursorCodec{secret: secret}, nil
+}
+
+func (c *CursorCodec) sign(payload string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (c *CursorCodec) Encode(cursor Cursor) (string, error) {
+	b, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	return payload + "." + c.sign(payload), nil
+}
+
+// Decode returns ErrInvalid if s is not a cursor encoded by c.
+func (c *CursorCodec) Decode(s string) (*Cursor, error) {
+	payload, sig, ok := strings.Cut(s, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(c.sign(payload))) {
+		return nil, fmt.Errorf("%w: cursor is malformed or tampered", ErrInvalid)
+	}
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cursor: %w", ErrInvalid, err)
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(b, &cursor); err != nil {
+		return nil, fmt.Errorf("%w: cursor: %w", ErrInvalid, err)
+	}
+	return &cursor, nil
+}
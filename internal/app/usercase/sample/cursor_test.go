@@ -0,0 +1,114 @@
+package sample
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCursorCodec(t *testing.T) {
+	_, err := NewCursorCodec(nil)
+	assert.ErrorIs(t, err, ErrEmptySecret)
+	_, err = NewCursorCodec([]byte{})
+	assert.ErrorIs(t, err, ErrEmptySecret)
+}
+
+func TestCursorCodec_Decode(t *testing.T) {
+	codec, err := NewCursorCodec([]byte("secret"))
+	assert.NoError(t, err)
+	other, err := NewCursorCodec([]byte("other"))
+	assert.NoError(t, err)
+	cursor := Cursor{Sort: "-birthday", Values: []string{"1994-09-14T00:00:00+09:00"}, ID: uuid.New(), Backward: true}
+	encoded, err := codec.Encode(cursor)
+	assert.NoError(t, err)
+	forged, err := other.Encode(cursor)
+	assert.NoError(t, err)
+
+	tests := map[string]struct {
+		s       string
+		want    *Cursor
+		wantErr error
+	}{
+		"encoded cursor":      {s: encoded, want: &cursor},
+		"cursor of other key": {s: forged, wantErr: ErrInvalid},
+		"cursor without sign": {s: encoded[:len(encoded)-2], wantErr: ErrInvalid},
+		"not a cursor":        {s: "cursor", wantErr: ErrInvalid},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := codec.Decode(tt.s)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestUsecase_SearchByCursor(t *testing.T) {
+	samples := make([]model.Sample, 5)
+	for i := range samples {
+		samples[i] = model.Sample{ID: uuid.MustParse(fmt.Sprintf("00000000-0000-0000-0000-%012d", i))}
+	}
+	// repo finds samples in order of ID.
+	repo := &stubRepository{
+		findByKeyset: func(ctx context.Context, q KeysetQuery) (*KeysetPage, error) {
+			found := []model.Sample{}
+			for _, s := range samples {
+				after := q.After == nil ||
+					(!q.Backward && s.ID.String() > q.After.ID.String()) ||
+					(q.Backward && s.ID.String() < q.After.ID.String())
+				if after {
+					found = append(found, s)
+				}
+			}
+			if q.Backward {
+				found = found[max(len(found)-q.Limit, 0):]
+			} else {
+				found = found[:min(q.Limit, len(found))]
+			}
+			page := &KeysetPage{Samples: found, Keysets: make([]Keyset, len(found))}
+			for i, s := range found {
+				page.Keysets[i] = Keyset{Values: []string{}, ID: s.ID}
+			}
+			return page, nil
+		},
+	}
+	codec, err := NewCursorCodec([]byte("secret"))
+	assert.NoError(t, err)
+	u := Usecase{Repository: repo, Cursor: codec}
+	ctx := context.Background()
+	limit := 2
+
+	// walk forward through all pages
+	first, err := u.SearchByCursor(ctx, SampleFilter{}, nil, nil, "", &limit, false)
+	assert.NoError(t, err)
+	assert.Equal(t, samples[0:2], first.Samples)
+	assert.Empty(t, first.Prev)
+	second, err := u.SearchByCursor(ctx, SampleFilter{}, nil, nil, first.Next, &limit, false)
+	assert.NoError(t, err)
+	assert.Equal(t, samples[2:4], second.Samples)
+	last, err := u.SearchByCursor(ctx, SampleFilter{}, nil, nil, second.Next, &limit, false)
+	assert.NoError(t, err)
+	assert.Equal(t, samples[4:5], last.Samples)
+	assert.Empty(t, last.Next)
+
+	// walk backward from the last page
+	prev, err := u.SearchByCursor(ctx, SampleFilter{}, nil, nil, last.Prev, &limit, false)
+	assert.NoError(t, err)
+	assert.Equal(t, samples[2:4], prev.Samples)
+	assert.NotEmpty(t, prev.Next)
+	prev, err = u.SearchByCursor(ctx, SampleFilter{}, nil, nil, prev.Prev, &limit, false)
+	assert.NoError(t, err)
+	assert.Equal(t, samples[0:2], prev.Samples)
+	assert.Empty(t, prev.Prev)
+	next, err := u.SearchByCursor(ctx, SampleFilter{}, nil, nil, prev.Next, &limit, false)
+	assert.NoError(t, err)
+	assert.Equal(t, samples[2:4], next.Samples)
+
+	// cursor made with another sort
+	_, err = u.SearchByCursor(ctx, SampleFilter{}, []SortKey{{Field: SortByName}}, nil, first.Next, &limit, false)
+	assert.ErrorIs(t, err, ErrInvalid)
+}
@@ -0,0 +1,64 @@
+package sample
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Field is a field of samples which can be selected as a sparse fieldset.
+type Field string
+
+const (
+	FieldID         Field = "id"
+	FieldName       Field = "name"
+	FieldBirthday   Field = "birthday"
+	FieldIsJapanese Field = "is_japanese"
+)
+
+var fields = map[Field]bool{
+	FieldID:         true,
+	FieldName:       true,
+	FieldBirthday:   true,
+	FieldIsJapanese: true,
+}
+
+// Fields is a sparse fieldset of samples. Nil Fields means all fields.
+// Repositories may fill fields out of Fields, which callers must ignore.
+type Fields []Field
+
+// ParseFields parses comma separated fields such as "id,name". An empty string means all fields.
+// It returns ErrInvalid if a field is unknown or duplicated.
+func ParseFields(s string) (Fields, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var (
+		fs   = Fields{}
+		seen = map[Field]bool{}
+	)
+	for _, f := range strings.Split(s, ",") {
+		field := Field(f)
+		if !fields[field] {
+			return nil, fmt.Errorf("%w: %q is not a field", ErrInvalid, field)
+		}
+		if seen[field] {
+			return nil, fmt.Errorf("%w: %q is duplicated", ErrInvalid, field)
+		}
+		seen[field] = true
+		fs = append(fs, field)
+	}
+	return fs, nil
+}
+
+// Has reports whether field is in fs. It is always true if fs is nil.
+func (fs Fields) Has(field Field) bool {
+	if fs == nil {
+		return true
+	}
+	for _, f := range fs {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package sample
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFields(t *testing.T) {
+	tests := map[string]struct {
+		s       string
+		want    Fields
+		wantErr error
+	}{
+		"empty": {
+			s:    "",
+			want: nil,
+		},
+		"fields": {
+			s:    "name,id",
+			want: Fields{FieldName, FieldID},
+		},
+		"unknown field": {
+			s:       "password",
+			wantErr: ErrInvalid,
+		},
+		"duplicated": {
+			s:       "name,name",
+			wantErr: ErrInvalid,
+		},
+		"empty field": {
+			s:       "name,",
+			wantErr: ErrInvalid,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseFields(tt.s)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFields_Has(t *testing.T) {
+	assert.True(t, Fields(nil).Has(FieldName))
+	assert.True(t, Fields{FieldID, FieldName}.Has(FieldName))
+	assert.False(t, Fields{FieldID}.Has(FieldName))
+}
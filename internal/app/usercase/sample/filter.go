@@ -0,0 +1,79 @@
+package sample
+
+import (
+	"fmt"
+	"time"
+)
+
+// SampleFilter is a criteria of samples to search. Nil fields are not used as conditions,
+// and all of non-nil fields must be satisfied.
+type SampleFilter struct {
+	// Name matches samples whose name matches it in NameMatch mode.
+	// Both are normalized by NormalizeName so that width, kana and case are ignored.
+	Name *string
+	// NameMatch is how Name is matched. The zero value means MatchContains.
+	NameMatch MatchMode
+	// ExactName matches samples whose name is equal to it including case.
+	ExactName  *string
+	IsJapanese *bool
+	// BornAfter and BornBefore match samples born after and before the time exclusively.
+	BornAfter  *time.Time
+	BornBefore *time.Time
+	// BirthMonth matches samples born in the month (1-12) of any year.
+	BirthMonth *int
+	// BirthDay matches samples born on the day (1-31) of any month.
+	BirthDay *int
+	// CreatedAfter and CreatedBefore match samples created after and before the time exclusively.
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// UpdatedAfter and UpdatedBefore match samples updated after and before the time exclusively.
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	// IncludeDeleted includes soft-deleted samples, which needs OperationIncludeDeleted.
+	IncludeDeleted bool
+}
+
+// Validate returns ErrInvalid if f never matches any samples due to out of range values.
+func (f SampleFilter) Validate() error {
+	if !matchModes[f.NameMatch] {
+		return fmt.Errorf("%w: %q is not a match mode", ErrInvalid, f.NameMatch)
+	}
+	if f.BirthMonth != nil && (*f.BirthMonth < 1 || 12 < *f.BirthMonth) {
+		return fmt.Errorf("%w: birth month %d is out of range", ErrInvalid, *f.BirthMonth)
+	}
+	if f.BirthDay != nil && (*f.BirthDay < 1 || 31 < *f.BirthDay) {
+		return fmt.Errorf("%w: birth day %d is out of range", ErrInvalid, *f.BirthDay)
+	}
+	return nil
+}
+
+// MatchMode is how a string field matches a pattern. Wildcard characters in a pattern match themselves.
+type MatchMode string
+
+const (
+	MatchContains MatchMode = "contains"
+	MatchPrefix   MatchMode = "prefix"
+	MatchSuffix   MatchMode = "suffix"
+	MatchExact    MatchMode = "exact"
+)
+
+var matchModes = map[MatchMode]bool{
+	"":            true,
+	MatchContains: true,
+	MatchPrefix:   true,
+	MatchSuffix:   true,
+	MatchExact:    true,
+}
+
+// ParseMatchMode parses one of "contains", "prefix", "suffix" and "exact".
+// An empty string means MatchContains. It returns ErrInvalid for the others.
+func ParseMatchMode(s string) (MatchMode, error) {
+	if s == "" {
+		return MatchContains, nil
+	}
+	mode := MatchMode(s)
+	if !matchModes[mode] {
+		return "", fmt.Errorf("%w: %q is not a match mode", ErrInvalid, s)
+	}
+	return mode, nil
+}
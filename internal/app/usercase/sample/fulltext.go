@@ -0,0 +1,115 @@
+package sample
+
+import (
+	"context"
+	"html"
+	"strings"
+
+	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
+	"github.com/google/uuid"
+	"golang.org/x/text/unicode/norm"
+)
+
+// SearchIndex is a full-text index of names of samples.
+// Names are tokenized into SearchTerms of NormalizeName.
+type SearchIndex interface {
+	// Search returns non-deleted samples whose names contain any of terms of q
+	// in descending order of score and then by ID. Highlight of the samples can be empty.
+	Search(ctx context.Context, q string, offset, limit int) (*model.ScoredSamples, error)
+	// Put adds or replaces the sample. Indexes maintained by the database can ignore it.
+	Put(ctx context.Context, sample *model.Sample) error
+	// Remove removes the sample. Indexes maintained by the database can ignore it.
+	Remove(ctx context.Context, id uuid.UUID) error
+}
+
+// ngramSize is the number of characters of a search term, which is the default ngram_token_size of MySQL.
+const ngramSize = 2
+
+// SearchTerms returns distinct bigrams of the normalized s.
+// s itself is the only term if it is shorter than a bigram.
+func SearchTerms(s string) []string {
+	runes := []rune(NormalizeName(s))
+	if len(runes) < ngramSize {
+		if len(runes) == 0 {
+			return nil
+		}
+		return []string{string(runes)}
+	}
+	var (
+		terms = []string{}
+		seen  = map[string]bool{}
+	)
+	for i := 0; i+ngramSize <= len(runes); i++ {
+		term := string(runes[i : i+ngramSize])
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// highlight escapes name as HTML and encloses the parts matching any of terms in <em> tags.
+// Characters are matched after normalized by NormalizeName segment by segment,
+// so that a half-width katakana with a voiced sound mark is highlighted as a whole.
+func highlight(name string, terms []string) string {
+	var (
+		segments []string
+		// runes are the normalized segments and origins are the indexes of segments of them.
+		runes   []rune
+		origins []int
+	)
+	for i := 0; i < len(name); {
+		n := norm.NFKC.NextBoundaryInString(name[i:], true)
+		if n <= 0 {
+			n = len(name) - i
+		}
+		segments = append(segments, name[i:i+n])
+		for _, r := range NormalizeName(name[i : i+n]) {
+			runes = append(runes, r)
+			origins = append(origins, len(segments)-1)
+		}
+		i += n
+	}
+
+	matched := make([]bool, len(segments))
+	for _, term := range terms {
+		size := len([]rune(term))
+		for i := 0; i+size <= len(runes); i++ {
+			if string(runes[i:i+size]) != term {
+				continue
+			}
+			for _, origin := range origins[i : i+size] {
+				matched[origin] = true
+			}
+		}
+	}
+
+	var b strings.Builder
+	for i, segment := range segments {
+		if matched[i] && (i == 0 || !matched[i-1]) {
+			b.WriteString("<em>")
+		}
+		b.WriteString(html.EscapeString(segment))
+		if matched[i] && (i == len(segments)-1 || !matched[i+1]) {
+			b.WriteString("</em>")
+		}
+	}
+	return b.String()
+}
+
+// putIndex puts sample to SearchIndex if it is configured.
+func (u *Usecase) putIndex(ctx context.Context, sample *model.Sample) error {
+	if u.SearchIndex == nil {
+		return nil
+	}
+	return u.SearchIndex.Put(ctx, sample)
+}
+
+// removeIndex removes the sample from SearchIndex if it is configured.
+func (u *Usecase) removeIndex(ctx context.Context, id uuid.UUID) error {
+	if u.SearchIndex == nil {
+		return nil
+	}
+	return u.SearchIndex.Remove(ctx, id)
+}
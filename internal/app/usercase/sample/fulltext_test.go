@@ -0,0 +1,148 @@
+package sample
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchTerms(t *testing.T) {
+	tests := map[string]struct {
+		s    string
+		want []string
+	}{
+		"bigrams of normalized string": {
+			s:    "ニンジャ",
+			want: []string{"にん", "んじ", "じゃ"},
+		},
+		"distinct bigrams": {
+			s:    "aaaa",
+			want: []string{"aa"},
+		},
+		"a character": {
+			s:    "Ａ",
+			want: []string{"a"},
+		},
+		"empty": {
+			s:    "",
+			want: nil,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SearchTerms(tt.s))
+		})
+	}
+}
+
+func Test_highlight(t *testing.T) {
+	tests := map[string]struct {
+		name  string
+		terms []string
+		want  string
+	}{
+		"enclose matched parts": {
+			name:  "test-ninja",
+			terms: SearchTerms("nin"),
+			want:  "test-<em>nin</em>ja",
+		},
+		"match regardless of case and width": {
+			name:  "ＴＥＳＴ-test",
+			terms: SearchTerms("Test"),
+			want:  "<em>ＴＥＳＴ</em>-<em>test</em>",
+		},
+		"half-width katakana with voiced sound mark": {
+			name:  "ﾆﾝｼﾞｬ",
+			terms: SearchTerms("じゃ"),
+			want:  "ﾆﾝ<em>ｼﾞｬ</em>",
+		},
+		"escape HTML": {
+			name:  "<b>ninja</b>",
+			terms: SearchTerms("ninja"),
+			want:  "&lt;b&gt;<em>ninja</em>&lt;/b&gt;",
+		},
+		"no match": {
+			name:  "test-ninja",
+			terms: SearchTerms("samurai"),
+			want:  "test-ninja",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, highlight(tt.name, tt.terms))
+		})
+	}
+}
+
+func TestUsecase_FullTextSearch(t *testing.T) {
+	id := uuid.MustParse("00000000-0000-0000-0000-000000000004")
+	index := &stubSearchIndex{
+		search: func(ctx context.Context, q string, offset, limit int) (*model.ScoredSamples, error) {
+			return &model.ScoredSamples{
+				Total: 1,
+				Hits:  []model.ScoredSample{{Sample: model.Sample{ID: id, Name: "test-ninja"}, Score: 1.5}},
+			}, nil
+		},
+	}
+	tests := map[string]struct {
+		index   SearchIndex
+		q       string
+		want    *model.ScoredSamples
+		wantErr error
+	}{
+		"return hits with highlight": {
+			index: index,
+			q:     "NIN",
+			want: &model.ScoredSamples{
+				Total: 1,
+				Hits:  []model.ScoredSample{{Sample: model.Sample{ID: id, Name: "test-ninja"}, Score: 1.5, Highlight: "test-<em>nin</em>ja"}},
+			},
+		},
+		"return ErrInvalid when query is empty": {
+			index:   index,
+			q:       "",
+			wantErr: ErrInvalid,
+		},
+		"return ErrUnsupported when index is not configured": {
+			q:       "ninja",
+			wantErr: errors.ErrUnsupported,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			u := Usecase{Repository: &stubRepository{}, SearchIndex: tt.index}
+			got, err := u.FullTextSearch(context.Background(), tt.q, nil, nil)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// stubSearchIndex calls the function fields. Methods without function panic.
+type stubSearchIndex struct {
+	search func(ctx context.Context, q string, offset, limit int) (*model.ScoredSamples, error)
+}
+
+// Search implements SearchIndex.
+func (i *stubSearchIndex) Search(ctx context.Context, q string, offset, limit int) (*model.ScoredSamples, error) {
+	if i.search == nil {
+		panic("unimplemented")
+	}
+	return i.search(ctx, q, offset, limit)
+}
+
+// Put implements SearchIndex.
+func (*stubSearchIndex) Put(ctx context.Context, sample *model.Sample) error {
+	panic("unimplemented")
+}
+
+// Remove implements SearchIndex.
+func (*stubSearchIndex) Remove(ctx context.Context, id uuid.UUID) error {
+	panic("unimplemented")
+}
+
+var _ SearchIndex = (*stubSearchIndex)(nil)
@@ -0,0 +1,181 @@
+package sample
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
+	"github.com/google/uuid"
+)
+
+// HistoryQuery is a condition of history events. Nil and empty fields are not conditions.
+type HistoryQuery struct {
+	SampleID  *uuid.UUID
+	ActorID   *uuid.UUID
+	Action    model.Action
+	RequestID string
+	// Since and Until limit events to those at or after Since and before Until.
+	Since *time.Time
+	Until *time.Time
+}
+
+var actions = map[model.Action]bool{
+	model.ActionCreate:     true,
+	model.ActionEdit:       true,
+	model.ActionDelete:     true,
+	model.ActionHardDelete: true,
+	model.ActionRestore:    true,
+}
+
+// Validate returns ErrInvalid if Action is unknown or Since is not before Until.
+func (q HistoryQuery) Validate() error {
+	if q.Action != "" && !actions[q.Action] {
+		return fmt.Errorf("%w: %q is not an action", ErrInvalid, q.Action)
+	}
+	if q.Since != nil && q.Until != nil && !q.Since.Before(*q.Until) {
+		return fmt.Errorf("%w: since must be before until", ErrInvalid)
+	}
+	return nil
+}
+
+type HistoryRepository interface {
+	// INSERT INTO `SAMPLE_HISTORY` (`ID`, `SAMPLE_ID`, `ACTION`, `ACTOR_ID`, `ACTOR`, `REQUEST_ID`, `AT`, `CHANGES`) VALUES (...)
+	//
+	// Insert must join the transaction of ctx so that events are recorded only if the mutations are committed.
+	Insert(ctx context.Context, event *model.HistoryEvent) error
+	// SELECT ... FROM `SAMPLE_HISTORY` WHERE {{if q.SampleID != nil}} `SAMPLE_ID` = @q.SampleID {{end}} ... ORDER BY `AT` DESC, `ID` LIMIT @limit OFFSET @offset
+	Search(ctx context.Context, q HistoryQuery, offset, limit int) (*model.PagedHistory, error)
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx with the ID of the request, which is recorded in history events.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFrom returns the ID of the request in ctx, or an empty string if it is unknown.
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func (u *Usecase) now() time.Time {
+	if u.Now == nil {
+		return time.Now()
+	}
+	return u.Now()
+}
+
+// record adds an event of action on the sample to History in the transaction of ctx with the caller in ctx.
+// The changes are fields different between before and after, where before is nil if the sample appears by the action
+// and after is nil if the sample disappears by it. It does nothing if History is nil.
+func (u *Usecase) record(ctx context.Context, id uuid.UUID, action model.Action, before, after *model.Sample) error {
+	if u.History == nil {
+		return nil
+	}
+	changes, err := diff(before, after)
+	if err != nil {
+		return err
+	}
+	access := AccessFrom(ctx)
+	return u.History.Insert(ctx, &model.HistoryEvent{
+		ID:        uuid.New(),
+		SampleID:  id,
+		Action:    action,
+		ActorID:   access.UserID,
+		Actor:     access.Caller,
+		RequestID: RequestIDFrom(ctx),
+		At:        u.now(),
+		Changes:   changes,
+	})
+}
+
+// historyFields are the fields of samples compared by diff in order.
+var historyFields = []struct {
+	field Field
+	value func(s *model.Sample) interface{}
+}{
+	{FieldName, func(s *model.Sample) interface{} { return s.Name }},
+	{FieldBirthday, func(s *model.Sample) interface{} { return s.Birthday }},
+	{FieldIsJapanese, func(s *model.Sample) interface{} { return s.IsJapanese }},
+}
+
+// diff returns the fields whose JSON values are different between before and after.
+// All fields are changed from or to nothing if before or after is nil.
+func diff(before, after *model.Sample) ([]model.Change, error) {
+	changes := []model.Change{}
+	for _, f := range historyFields {
+		b, err := marshalField(before, f.value)
+		if err != nil {
+			return nil, fmt.Errorf("diff %s: %w", f.field, err)
+		}
+		a, err := marshalField(after, f.value)
+		if err != nil {
+			return nil, fmt.Errorf("diff %s: %w", f.field, err)
+		}
+		if bytes.Equal(a, b) {
+			continue
+		}
+		changes = append(changes, model.Change{Field: string(f.field), Before: b, After: a})
+	}
+	return changes, nil
+}
+
+// marshalField returns the JSON value of the field of s, or nil if s is nil.
+func marshalField(s *model.Sample, value func(s *model.Sample) interface{}) (json.RawMessage, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return json.Marshal(value(s))
+}
+
+// SampleHistory returns events of the sample in descending order of time.
+// It returns ErrNotFound if the sample is deleted or the caller cannot access it, and errors.ErrUnsupported if History is nil.
+// The history of deleted samples is searched by SearchHistory.
+func (u *Usecase) SampleHistory(ctx context.Context, id uuid.UUID, limit, offset *int) (*model.PagedHistory, error) {
+	if u.History == nil {
+		return nil, fmt.Errorf("sample history: %w", errors.ErrUnsupported)
+	}
+	ctx, err := u.authorize(ctx, OperationRead)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := u.Repository.FindByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return u.searchHistory(ctx, HistoryQuery{SampleID: &id}, limit, offset)
+}
+
+// SearchHistory returns events matching q in descending order of time including those of deleted samples.
+// It returns ErrForbidden if Policy does not allow OperationAudit, ErrInvalid if q is invalid,
+// and errors.ErrUnsupported if History is nil.
+func (u *Usecase) SearchHistory(ctx context.Context, q HistoryQuery, limit, offset *int) (*model.PagedHistory, error) {
+	if u.History == nil {
+		return nil, fmt.Errorf("sample history: %w", errors.ErrUnsupported)
+	}
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	ctx, err := u.authorize(ctx, OperationAudit)
+	if err != nil {
+		return nil, err
+	}
+	return u.searchHistory(ctx, q, limit, offset)
+}
+
+func (u *Usecase) searchHistory(ctx context.Context, q HistoryQuery, limit, offset *int) (*model.PagedHistory, error) {
+	l := DefaultLimit
+	if limit != nil {
+		l = *limit
+	}
+	o := DefaultOffset
+	if offset != nil {
+		o = *offset
+	}
+	return u.History.Search(ctx, q, o, l)
+}
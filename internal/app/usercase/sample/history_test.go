@@ -0,0 +1,161 @@
+package sample
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsecase_record(t *testing.T) {
+	var (
+		id       = uuid.MustParse("00000000-0000-0000-0000-000000000000")
+		owner    = uuid.MustParse("00000000-0000-0000-0000-000000000101")
+		birthday = time.Date(1994, 9, 14, 0, 0, 0, 0, time.UTC)
+		now      = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		name     = "edited"
+	)
+	history := &fakeHistoryRepository{}
+	u := Usecase{
+		Repository: &stubRepository{
+			findByID: func(ctx context.Context, id uuid.UUID) (*model.Sample, error) {
+				return &model.Sample{ID: id, Name: "test-japanese", Birthday: birthday, IsJapanese: true, Version: 1}, nil
+			},
+			insert:     func(ctx context.Context, sample *model.Sample) error { return nil },
+			update:     func(ctx context.Context, sample *model.Sample) error { return nil },
+			deleteByID: func(ctx context.Context, id uuid.UUID) error { return nil },
+			restore:    func(ctx context.Context, id uuid.UUID) error { return nil },
+		},
+		Policy:  accessPolicy{UserID: &owner, Caller: "user:kawamura"},
+		History: history,
+		Now:     func() time.Time { return now },
+	}
+	ctx := WithRequestID(context.Background(), "req-1")
+
+	added, err := u.Add(ctx, AddQuery{Name: "test-japanese", Birthday: birthday, IsJapanese: true})
+	assert.NoError(t, err)
+	_, err = u.Edit(ctx, UpdateQuery{ID: id, Name: &name})
+	assert.NoError(t, err)
+	assert.NoError(t, u.Delete(ctx, id, nil))
+	_, err = u.Restore(ctx, id)
+	assert.NoError(t, err)
+
+	event := func(id uuid.UUID, action model.Action, changes ...model.Change) model.HistoryEvent {
+		return model.HistoryEvent{SampleID: id, Action: action, ActorID: &owner, Actor: "user:kawamura", RequestID: "req-1", At: now, Changes: append([]model.Change{}, changes...)}
+	}
+	created := []model.Change{
+		{Field: "name", After: json.RawMessage(`"test-japanese"`)},
+		{Field: "birthday", After: json.RawMessage(`"1994-09-14T00:00:00Z"`)},
+		{Field: "is_japanese", After: json.RawMessage(`true`)},
+	}
+	want := []model.HistoryEvent{
+		event(added, model.ActionCreate, created...),
+		event(id, model.ActionEdit, model.Change{Field: "name", Before: json.RawMessage(`"test-japanese"`), After: json.RawMessage(`"edited"`)}),
+		event(id, model.ActionDelete,
+			model.Change{Field: "name", Before: json.RawMessage(`"test-japanese"`)},
+			model.Change{Field: "birthday", Before: json.RawMessage(`"1994-09-14T00:00:00Z"`)},
+			model.Change{Field: "is_japanese", Before: json.RawMessage(`true`)},
+		),
+		event(id, model.ActionRestore, created...),
+	}
+	for i := range history.events {
+		history.events[i].ID = uuid.UUID{}
+	}
+	assert.Equal(t, want, history.events)
+
+	// the mutation fails if the event is not recorded so that the transaction is rolled back.
+	history.err = errors.New("insert failed")
+	assert.ErrorIs(t, u.Delete(ctx, id, nil), history.err)
+}
+
+func TestUsecase_SearchHistory(t *testing.T) {
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		policy  Policy
+		query   HistoryQuery
+		wantErr error
+	}{
+		"search history": {
+			query: HistoryQuery{Action: model.ActionEdit, Since: &since},
+		},
+		"return ErrInvalid when action is unknown": {
+			query:   HistoryQuery{Action: "update"},
+			wantErr: ErrInvalid,
+		},
+		"return ErrInvalid when since is not before until": {
+			query:   HistoryQuery{Since: &since, Until: &since},
+			wantErr: ErrInvalid,
+		},
+		"return ErrForbidden when audit is not allowed": {
+			policy:  denyPolicy{},
+			wantErr: ErrForbidden,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			history := &fakeHistoryRepository{}
+			u := Usecase{Repository: &stubRepository{}, Policy: tt.policy, History: history}
+			_, err := u.SearchHistory(context.Background(), tt.query, nil, nil)
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.query, history.query)
+			}
+		})
+	}
+
+	_, err := (&Usecase{}).SearchHistory(context.Background(), HistoryQuery{}, nil, nil)
+	assert.ErrorIs(t, err, errors.ErrUnsupported)
+}
+
+func TestUsecase_SampleHistory(t *testing.T) {
+	id := uuid.MustParse("00000000-0000-0000-0000-000000000000")
+	history := &fakeHistoryRepository{}
+	u := Usecase{
+		Repository: &stubRepository{
+			findByID: func(ctx context.Context, found uuid.UUID) (*model.Sample, error) {
+				if found != id {
+					return nil, ErrNotFound
+				}
+				return &model.Sample{ID: id}, nil
+			},
+		},
+		History: history,
+	}
+
+	_, err := u.SampleHistory(context.Background(), id, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, HistoryQuery{SampleID: &id}, history.query)
+
+	_, err = u.SampleHistory(context.Background(), uuid.New(), nil, nil)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// fakeHistoryRepository records inserted events and the last query searched.
+type fakeHistoryRepository struct {
+	events []model.HistoryEvent
+	query  HistoryQuery
+	// err is returned by Insert if it is not nil.
+	err error
+}
+
+// Insert implements HistoryRepository.
+func (r *fakeHistoryRepository) Insert(ctx context.Context, event *model.HistoryEvent) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.events = append(r.events, *event)
+	return nil
+}
+
+// Search implements HistoryRepository.
+func (r *fakeHistoryRepository) Search(ctx context.Context, q HistoryQuery, offset, limit int) (*model.PagedHistory, error) {
+	r.query = q
+	return &model.PagedHistory{Total: len(r.events), Events: r.events}, nil
+}
+
+var _ HistoryRepository = (*fakeHistoryRepository)(nil)
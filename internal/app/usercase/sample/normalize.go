@@ -0,0 +1,27 @@
+package sample
+
+import (
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+var caseFolder = cases.Fold()
+
+// NormalizeName normalizes a name for search so that names match regardless of
+// full-width or half-width characters, hiragana or katakana, and letter case.
+// It applies NFKC, folds case and then replaces katakana with hiragana.
+func NormalizeName(name string) string {
+	name = caseFolder.String(norm.NFKC.String(name))
+	return strings.Map(toHiragana, name)
+}
+
+// toHiragana maps katakana from ァ to ヶ to corresponding hiragana.
+// The other characters including the prolonged sound mark ー are kept as is.
+func toHiragana(r rune) rune {
+	if 'ァ' <= r && r <= 'ヶ' {
+		return r - ('ァ' - 'ぁ')
+	}
+	return r
+}
@@ -0,0 +1,40 @@
+package sample
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := map[string]struct {
+		name string
+		want string
+	}{
+		"fold case": {
+			name: "Test-Ninja",
+			want: "test-ninja",
+		},
+		"full-width alphanumerics to half-width": {
+			name: "ＴＥＳＴ１２３",
+			want: "test123",
+		},
+		"katakana to hiragana": {
+			name: "ニンジャ",
+			want: "にんじゃ",
+		},
+		"half-width katakana to hiragana": {
+			name: "ﾆﾝｼﾞｬ",
+			want: "にんじゃ",
+		},
+		"keep prolonged sound mark and kanji": {
+			name: "忍者スーパー",
+			want: "忍者すーぱー",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NormalizeName(tt.name))
+		})
+	}
+}
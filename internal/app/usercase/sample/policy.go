@@ -0,0 +1,101 @@
+package sample
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrUnauthorized is returned when the caller is not authenticated and Policy needs it.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden is returned when Policy does not allow the operation.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Operation is an operation on samples which needs authorization.
+type Operation string
+
+const (
+	// OperationRead gets and searches samples.
+	OperationRead Operation = "read"
+	// OperationAdd adds a new sample.
+	OperationAdd Operation = "add"
+	// OperationEdit edits or patches a sample.
+	OperationEdit Operation = "edit"
+	// OperationDelete soft-deletes a sample.
+	OperationDelete Operation = "delete"
+	// OperationListDeleted lists soft-deleted samples.
+	OperationListDeleted Operation = "list_deleted"
+	// OperationRestore restores a soft-deleted sample.
+	OperationRestore Operation = "restore"
+	// OperationIncludeDeleted searches samples including soft-deleted ones.
+	OperationIncludeDeleted Operation = "include_deleted"
+	// OperationHardDelete deletes a sample permanently.
+	OperationHardDelete Operation = "hard_delete"
+	// OperationAudit searches the history of all samples including deleted ones.
+	OperationAudit Operation = "audit"
+)
+
+// Policy authorizes operations of the caller identified by ctx.
+type Policy interface {
+	// Authorize returns an error wrapping ErrForbidden if the caller is not allowed to do op,
+	// or wrapping ErrUnauthorized if the caller is unknown.
+	Authorize(ctx context.Context, op Operation) error
+	// Access returns the samples which the caller can access. It is called after the caller is authorized.
+	Access(ctx context.Context) (Access, error)
+}
+
+// Access is the samples which the caller can access, and the user who is recorded as their owner and editor.
+type Access struct {
+	// UserID is the caller, who owns samples added by them and is recorded as the editor of samples saved by them.
+	// It is nil if the caller is not a user such as an API key.
+	UserID *uuid.UUID
+	// OwnedOnly limits the caller to samples owned by UserID.
+	OwnedOnly bool
+	// Caller describes the caller in history events such as "user:<user name>". It is empty if the caller is anonymous.
+	Caller string
+}
+
+// Allows reports whether the caller can access s. Repositories which cannot limit samples in queries use it.
+func (a Access) Allows(s *model.Sample) bool {
+	if !a.OwnedOnly {
+		return true
+	}
+	return a.UserID != nil && s.OwnerID != nil && *s.OwnerID == *a.UserID
+}
+
+type accessKey struct{}
+
+// WithAccess returns a copy of ctx with the access of the caller, by which repositories limit samples and record the owner.
+func WithAccess(ctx context.Context, access Access) context.Context {
+	return context.WithValue(ctx, accessKey{}, access)
+}
+
+// AccessFrom returns the access of the caller in ctx. The caller can access all samples if it is not in ctx.
+func AccessFrom(ctx context.Context) Access {
+	access, _ := ctx.Value(accessKey{}).(Access)
+	return access
+}
+
+// authorize authorizes op by Policy and returns a copy of ctx with the Access of the caller.
+// All operations on all samples are allowed if Policy is nil.
+func (u *Usecase) authorize(ctx context.Context, op Operation) (context.Context, error) {
+	if u.Policy == nil {
+		return ctx, nil
+	}
+	if err := u.Policy.Authorize(ctx, op); err != nil {
+		return nil, err
+	}
+	access, err := u.Policy.Access(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if access.OwnedOnly && access.UserID == nil {
+		return nil, fmt.Errorf("%w: %s needs a user to own samples", ErrForbidden, op)
+	}
+	return WithAccess(ctx, access), nil
+}
@@ -0,0 +1,120 @@
+package sample
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"time"
+
+	"github.com/Accel-Hack/go-api/internal/app/usercase/lock"
+)
+
+// PurgeRepository hard-deletes soft-deleted samples.
+type PurgeRepository interface {
+	// SELECT COUNT(*) FROM @@table WHERE `IS_DELETED` IS TRUE AND `DELETED_AT` < @before
+	CountDeletedBefore(ctx context.Context, before time.Time) (int, error)
+	// DELETE FROM @@table WHERE `IS_DELETED` IS TRUE AND `DELETED_AT` < @before ORDER BY `DELETED_AT` LIMIT @limit
+	//
+	// PurgeDeletedBefore returns the number of deleted rows.
+	PurgeDeletedBefore(ctx context.Context, before time.Time, limit int) (int, error)
+}
+
+const DefaultPurgeBatchSize = 500
+
+// ErrInvalidRetention is returned by Purger.Purge if Retention is not positive,
+// which would purge samples deleted just now.
+var ErrInvalidRetention = errors.New("retention must be positive")
+
+// purgeMetrics are published at /debug/vars as "purge".
+var purgeMetrics = expvar.NewMap("purge")
+
+// Purger hard-deletes samples soft-deleted more than Retention ago in batches of BatchSize.
+// Purge is skipped while another replica holds the lock of Locker.
+type Purger struct {
+	Repository PurgeRepository
+	// Locker is the lock among replicas. Purge always runs if it is nil.
+	Locker lock.Locker
+	// LockName is the name of the lock of Locker.
+	LockName string
+	// Retention is how long soft-deleted samples are kept. It must be positive.
+	Retention time.Duration
+	// BatchSize is the maximum number of samples deleted at once. DefaultPurgeBatchSize is used if it is not positive.
+	BatchSize int
+	// DryRun counts samples to purge without deleting them.
+	DryRun bool
+	// Now returns the current time. time.Now is used if it is nil.
+	Now func() time.Time
+}
+
+// PurgeResult is the result of Purger.Purge.
+type PurgeResult struct {
+	// Purged is the number of purged samples, or the number of samples to purge in dry run.
+	Purged int
+	// Skipped is true if another replica holds the lock.
+	Skipped bool
+	DryRun  bool
+}
+
+// Purge purges samples deleted before Retention ago until no samples remain or ctx is done.
+func (p *Purger) Purge(ctx context.Context) (PurgeResult, error) {
+	purgeMetrics.Add("runs", 1)
+	result, err := p.purge(ctx)
+	if err != nil {
+		purgeMetrics.Add("errors", 1)
+	}
+	if result.Skipped {
+		purgeMetrics.Add("skipped", 1)
+	}
+	if !result.DryRun {
+		purgeMetrics.Add("purged", int64(result.Purged))
+	}
+	return result, err
+}
+
+func (p *Purger) purge(ctx context.Context) (PurgeResult, error) {
+	result := PurgeResult{DryRun: p.DryRun}
+	if p.Retention <= 0 {
+		return result, fmt.Errorf("%w: %s", ErrInvalidRetention, p.Retention)
+	}
+	var locker lock.Locker = lock.Local{}
+	if p.Locker != nil {
+		locker = p.Locker
+	}
+	unlock, ok, err := locker.TryLock(ctx, p.LockName)
+	if err != nil {
+		return result, err
+	}
+	if !ok {
+		result.Skipped = true
+		return result, nil
+	}
+	defer unlock()
+
+	now := time.Now
+	if p.Now != nil {
+		now = p.Now
+	}
+	before := now().Add(-p.Retention)
+	if p.DryRun {
+		result.Purged, err = p.Repository.CountDeletedBefore(ctx, before)
+		return result, err
+	}
+	batchSize := p.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultPurgeBatchSize
+	}
+	for {
+		n, err := p.Repository.PurgeDeletedBefore(ctx, before, batchSize)
+		result.Purged += n
+		if err != nil {
+			return result, err
+		}
+		if n < batchSize {
+			return result, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return result, fmt.Errorf("purge is interrupted: %w", err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package sample
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurger_Purge(t *testing.T) {
+	var (
+		now       = time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+		retention = 30 * 24 * time.Hour
+		errDB     = errors.New("db error")
+	)
+	tests := map[string]struct {
+		repo       *stubPurgeRepository
+		locker     *stubLocker
+		dryRun     bool
+		want       PurgeResult
+		wantErr    error
+		wantCalls  int
+		wantBefore time.Time
+	}{
+		"purge in batches until fewer than batch size": {
+			repo:       &stubPurgeRepository{remaining: 5},
+			want:       PurgeResult{Purged: 5},
+			wantCalls:  3,
+			wantBefore: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		"count only in dry run": {
+			repo:       &stubPurgeRepository{remaining: 5},
+			dryRun:     true,
+			want:       PurgeResult{Purged: 5, DryRun: true},
+			wantCalls:  0,
+			wantBefore: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		"skip when another replica holds lock": {
+			repo:   &stubPurgeRepository{remaining: 5},
+			locker: &stubLocker{held: true},
+			want:   PurgeResult{Skipped: true},
+		},
+		"return purged count with error": {
+			repo:       &stubPurgeRepository{remaining: 5, failAt: 2, err: errDB},
+			want:       PurgeResult{Purged: 2},
+			wantErr:    errDB,
+			wantCalls:  2,
+			wantBefore: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			locker := tt.locker
+			if locker == nil {
+				locker = &stubLocker{}
+			}
+			p := Purger{
+				Repository: tt.repo,
+				Locker:     locker,
+				LockName:   "purge",
+				Retention:  retention,
+				BatchSize:  2,
+				DryRun:     tt.dryRun,
+				Now:        func() time.Time { return now },
+			}
+			got, err := p.Purge(context.Background())
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantCalls, tt.repo.calls)
+			assert.Equal(t, tt.wantBefore, tt.repo.before)
+			assert.False(t, locker.locked, "lock must be released")
+		})
+	}
+
+	for _, retention := range []time.Duration{0, -time.Hour} {
+		repo := &stubPurgeRepository{remaining: 5}
+		p := Purger{Repository: repo, Locker: &stubLocker{}, Retention: retention, Now: func() time.Time { return now }}
+		_, err := p.Purge(context.Background())
+		assert.ErrorIs(t, err, ErrInvalidRetention)
+		assert.Equal(t, 5, repo.remaining, "nothing must be purged")
+	}
+}
+
+// stubPurgeRepository has remaining samples to purge. PurgeDeletedBefore fails with err at the failAt th call if err is not nil.
+type stubPurgeRepository struct {
+	remaining int
+	failAt    int
+	err       error
+	calls     int
+	before    time.Time
+}
+
+// CountDeletedBefore implements PurgeRepository.
+func (r *stubPurgeRepository) CountDeletedBefore(ctx context.Context, before time.Time) (int, error) {
+	r.before = before
+	return r.remaining, nil
+}
+
+// PurgeDeletedBefore implements PurgeRepository.
+func (r *stubPurgeRepository) PurgeDeletedBefore(ctx context.Context, before time.Time, limit int) (int, error) {
+	r.before = before
+	r.calls++
+	if r.err != nil && r.calls == r.failAt {
+		return 0, r.err
+	}
+	n := min(limit, r.remaining)
+	r.remaining -= n
+	return n, nil
+}
+
+var _ PurgeRepository = (*stubPurgeRepository)(nil)
+
+// stubLocker fails to lock if held is true.
+type stubLocker struct {
+	held   bool
+	locked bool
+}
+
+// TryLock implements lock.Locker.
+func (l *stubLocker) TryLock(ctx context.Context, name string) (func() error, bool, error) {
+	if l.held {
+		return nil, false, nil
+	}
+	l.locked = true
+	return func() error {
+		l.locked = false
+		return nil
+	}, true, nil
+}
@@ -2,9 +2,14 @@ package sample
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
 	"time"
 
+	"github.com/Accel-Hack/go-api/internal/app/usercase/transaction"
 	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
+	"github.com/Accel-Hack/go-api/internal/domain/sample/service"
 	"github.com/google/uuid"
 )
 
@@ -13,35 +18,119 @@ const (
 	DefaultOffset = 0
 )
 
+var (
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when the sample has been updated by another writer.
+	ErrConflict = errors.New("conflict")
+	// ErrInvalid is returned when the sample to save is invalid.
+	ErrInvalid = errors.New("invalid sample")
+)
+
 type UpdateQuery struct {
 	ID         uuid.UUID
 	Name       *string
 	Birthday   *time.Time
 	IsJapanese *bool
+	// IfMatch is the precondition on the version of the sample. The version is not checked if it is nil.
+	IfMatch *IfMatch
+}
+
+// IfMatch is a precondition on the version of the sample to save such as If-Match header of HTTP.
+// It holds only if the sample exists and Any is true or the sample has one of Versions.
+type IfMatch struct {
+	// Any holds for any version of an existing sample.
+	Any      bool
+	Versions []int
 }
 
+// Matches reports whether the sample of version satisfies m. The nil *IfMatch always holds.
+func (m *IfMatch) Matches(version int) bool {
+	if m == nil || m.Any {
+		return true
+	}
+	return slices.Contains(m.Versions, version)
+}
+
+// SampleRepository stores samples. Methods limit samples to those which the caller can access by AccessFrom(ctx)
+// in their queries, which is written as {{if access.OwnedOnly}} AND `OWNER_ID` = @access.UserID {{end}} below.
 type SampleRepository interface {
-	// SELECT `ID`, `NAME` , `BIRTHDAY`, `IS_JAPANESE` FROM @@tablew WHERE `IS_DELETED` IS FALSE AND `ID` = @id
+	// SELECT `ID`, `NAME` , `BIRTHDAY`, `IS_JAPANESE` FROM @@tablew WHERE `IS_DELETED` IS FALSE AND `ID` = @id {{if access.OwnedOnly}} ... {{end}}
 	FindByID(ctx context.Context, id uuid.UUID) (*model.Sample, error)
-	// SELECT `ID`, `NAME` , `BIRTHDAY`, `IS_JAPANESE`, COUNT(*) OVER () AS TOTAL FROM @@table WHERE `IS_DELETED` IS FALSE {{if name != ""}} AND `NAME` LIKE concat("%",@name,"%") {{end}} LIMIT @limit OFFSET @offset
-	FindByNameLike(ctx context.Context, name string, offset, limit int) (*model.PagedSamples, error)
-	// INSERT INTO @@table (`ID`, `NAME`, `BIRTHDAY`, `IS_JAPANESE`) VALUES (@sample.id, @sample.name, @sample.birthday, @sample.isJapanese) ON DUPLICATE KEY UPDATE `NAME` = @sample.name, `BIRTHDAY` = @sample.birthday, `IS_JAPANESE` = @sample.isJapanese
+	// SELECT `ID`, `NAME` , `BIRTHDAY`, `IS_JAPANESE` FROM @@table WHERE `ID` = @id {{if access.OwnedOnly}} ... {{end}}
+	//
+	// FindByIDIncludeDeleted finds the sample even if it is soft-deleted, and returns ErrNotFound if it does not exist.
+	FindByIDIncludeDeleted(ctx context.Context, id uuid.UUID) (*model.Sample, error)
+	// SELECT `ID`, `NAME` , `BIRTHDAY`, `IS_JAPANESE`, COUNT(*) OVER () AS TOTAL FROM @@table WHERE `IS_DELETED` IS FALSE {{if filter.Name != nil}} AND `NAME` LIKE concat("%",@filter.Name,"%") {{end}} ... ORDER BY @sort..., `ID` LIMIT @limit OFFSET @offset
+	//
+	// Samples are ordered by sort and then by ID so that pages are stable.
+	// Only fields are selected in addition to ID.
+	FindByFilter(ctx context.Context, filter SampleFilter, sort []SortKey, fields Fields, offset, limit int) (*model.PagedSamples, error)
+	// SELECT `ID`, `NAME` , `BIRTHDAY`, `IS_JAPANESE` FROM @@table WHERE `IS_DELETED` IS FALSE ... AND (@sort..., `ID`) > (@after.Values..., @after.ID) ORDER BY @sort..., `ID` LIMIT @limit
+	//
+	// The comparison and the order are reversed if query.Backward is true, but samples are returned in the order of sort.
+	FindByKeyset(ctx context.Context, query KeysetQuery) (*KeysetPage, error)
+	// INSERT INTO @@table (`ID`, `NAME`, `BIRTHDAY`, `IS_JAPANESE`, `OWNER_ID`, `CREATED_BY`, `UPDATED_BY`) VALUES (@sample.id, @sample.name, @sample.birthday, @sample.isJapanese, @access.UserID, @access.UserID, @access.UserID)
+	//
+	// Insert sets OwnerID, CreatedBy and UpdatedBy of sample to the caller.
 	Insert(ctx context.Context, sample *model.Sample) error
-	// UPDATE @@table SET `NAME` = @sample.Name, `BIRTHDAY` = @sample.Birthday, `IS_JAPANESE` = @sample.isJapanese WHERE `ID` = @sample.ID
-	Update(ctx context.Context, sample UpdateQuery) error
-	// UPDATE @@table SET `IS_DELETED` = true, `DELETED_AT` = CURRENT_TIMESTAMP WHERE `ID` = @id
+	// UPDATE @@table SET `NAME` = @sample.Name, `BIRTHDAY` = @sample.Birthday, `IS_JAPANESE` = @sample.isJapanese, `UPDATED_BY` = @access.UserID, `VERSION` = `VERSION` + 1 WHERE `ID` = @sample.ID AND `VERSION` = @sample.Version AND `IS_DELETED` IS FALSE
+	//
+	// Update returns ErrConflict if no row is updated, and increments sample.Version and sets sample.UpdatedBy if updated.
+	Update(ctx context.Context, sample *model.Sample) error
+	// UPDATE @@table SET `IS_DELETED` = true, `DELETED_AT` = CURRENT_TIMESTAMP, `VERSION` = `VERSION` + 1 WHERE `ID` = @id AND `IS_DELETED` IS FALSE
+	//
+	// DeleteByID returns ErrNotFound if the sample does not exist or is already deleted.
 	DeleteByID(ctx context.Context, id uuid.UUID) error
+	// DELETE FROM @@table WHERE `ID` = @id
+	//
+	// HardDeleteByID deletes the sample even if it is soft-deleted, and returns ErrNotFound if the sample does not exist.
+	HardDeleteByID(ctx context.Context, id uuid.UUID) error
+	// SELECT `ID`, `NAME` , `BIRTHDAY`, `IS_JAPANESE`, `DELETED_AT` FROM @@table WHERE `IS_DELETED` IS TRUE ORDER BY `DELETED_AT` DESC, `ID` LIMIT @limit OFFSET @offset
+	FindDeleted(ctx context.Context, offset, limit int) (*model.PagedDeletedSamples, error)
+	// UPDATE @@table SET `IS_DELETED` = false, `DELETED_AT` = NULL, `VERSION` = `VERSION` + 1 WHERE `ID` = @id AND `IS_DELETED` IS TRUE
+	//
+	// Restore returns ErrNotFound if the sample does not exist or is not deleted.
+	Restore(ctx context.Context, id uuid.UUID) error
 }
 
 type Usecase struct {
 	Repository SampleRepository
+	// Cursor signs cursors of SearchByCursor.
+	Cursor *CursorCodec
+	// Transaction runs read-modify-write operations atomically. Operations run without transaction if it is nil.
+	Transaction transaction.Manager
+	// SearchIndex is used by FullTextSearch and kept up to date with saved samples. FullTextSearch is unsupported if it is nil.
+	SearchIndex SearchIndex
+	// Policy authorizes operations of the caller. All operations are allowed if it is nil.
+	Policy Policy
+	// History records mutations of samples in the transactions of them. They are not recorded if it is nil.
+	History HistoryRepository
+	// Now returns the current time recorded in History. time.Now is used if it is nil.
+	Now func() time.Time
+}
+
+func (u *Usecase) transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if u.Transaction == nil {
+		return transaction.Nop{}.Do(ctx, fn)
+	}
+	return u.Transaction.Do(ctx, fn)
 }
 
 func (u *Usecase) Get(ctx context.Context, id uuid.UUID) (*model.Sample, error) {
+	ctx, err := u.authorize(ctx, OperationRead)
+	if err != nil {
+		return nil, err
+	}
 	return u.Repository.FindByID(ctx, id)
 }
 
-func (u *Usecase) Search(ctx context.Context, name string, limit, offset *int) (*model.PagedSamples, error) {
+// Search returns samples matching filter in order of sort. Fields out of fields can be zero.
+// It returns ErrInvalid if filter is invalid, and ErrForbidden if filter includes deleted samples without permission.
+func (u *Usecase) Search(ctx context.Context, filter SampleFilter, sort []SortKey, fields Fields, limit, offset *int) (*model.PagedSamples, error) {
+	ctx, err := u.validateFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
 	l := DefaultLimit
 	if limit != nil {
 		l = *limit
@@ -50,7 +139,143 @@ func (u *Usecase) Search(ctx context.Context, name string, limit, offset *int) (
 	if offset != nil {
 		o = *offset
 	}
-	return u.Repository.FindByNameLike(ctx, name, o, l)
+	return u.Repository.FindByFilter(ctx, filter, sort, fields, o, l)
+}
+
+// SearchByCursor returns a page of samples matching filter in order of sort next to cursor.
+// Fields out of fields can be zero. It returns the first page if cursor is empty,
+// and ErrInvalid if cursor is invalid or made with another sort.
+func (u *Usecase) SearchByCursor(ctx context.Context, filter SampleFilter, sort []SortKey, fields Fields, cursor string, limit *int, includeTotal bool) (*model.CursorPagedSamples, error) {
+	ctx, err := u.validateFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	q := KeysetQuery{
+		Filter:       filter,
+		Sort:         sort,
+		Fields:       fields,
+		Limit:        DefaultLimit,
+		IncludeTotal: includeTotal,
+	}
+	if limit != nil {
+		q.Limit = *limit
+	}
+	if cursor != "" {
+		c, err := u.Cursor.Decode(cursor)
+		if err != nil {
+			return nil, err
+		}
+		if c.Sort != FormatSort(sort) {
+			return nil, fmt.Errorf("%w: cursor is made with sort %q", ErrInvalid, c.Sort)
+		}
+		q.After = &Keyset{Values: c.Values, ID: c.ID}
+		q.Backward = c.Backward
+	}
+	// find one more sample to know whether there are more samples.
+	q.Limit++
+	page, err := u.Repository.FindByKeyset(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	q.Limit--
+
+	samples, keysets := page.Samples, page.Keysets
+	hasMore := len(samples) > q.Limit
+	if hasMore && q.Backward {
+		samples, keysets = samples[1:], keysets[1:]
+	} else if hasMore {
+		samples, keysets = samples[:q.Limit], keysets[:q.Limit]
+	}
+	result := &model.CursorPagedSamples{Total: page.Total, Samples: samples}
+	if len(samples) == 0 {
+		return result, nil
+	}
+	// the page next to the last sample exists if there are more samples in the forward direction or the page is found backward.
+	if (hasMore && !q.Backward) || (q.After != nil && q.Backward) {
+		if result.Next, err = u.Cursor.Encode(Cursor{Sort: FormatSort(sort), Values: keysets[len(keysets)-1].Values, ID: keysets[len(keysets)-1].ID}); err != nil {
+			return nil, err
+		}
+	}
+	if (hasMore && q.Backward) || (q.After != nil && !q.Backward) {
+		if result.Prev, err = u.Cursor.Encode(Cursor{Sort: FormatSort(sort), Values: keysets[0].Values, ID: keysets[0].ID, Backward: true}); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// validateFilter validates filter and authorizes OperationRead,
+// and OperationIncludeDeleted if filter includes deleted samples. It returns ctx with the access of the caller.
+func (u *Usecase) validateFilter(ctx context.Context, filter SampleFilter) (context.Context, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	ctx, err := u.authorize(ctx, OperationRead)
+	if err != nil {
+		return nil, err
+	}
+	if filter.IncludeDeleted {
+		if _, err := u.authorize(ctx, OperationIncludeDeleted); err != nil {
+			return nil, err
+		}
+	}
+	return ctx, nil
+}
+
+// FullTextSearch returns samples whose names contain any of SearchTerms of q in order of relevance.
+// It returns ErrInvalid if q has no terms, and errors.ErrUnsupported if SearchIndex is not configured.
+func (u *Usecase) FullTextSearch(ctx context.Context, q string, limit, offset *int) (*model.ScoredSamples, error) {
+	if u.SearchIndex == nil {
+		return nil, fmt.Errorf("full-text search: %w", errors.ErrUnsupported)
+	}
+	ctx, err := u.authorize(ctx, OperationRead)
+	if err != nil {
+		return nil, err
+	}
+	terms := SearchTerms(q)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("%w: search query is empty", ErrInvalid)
+	}
+	l := DefaultLimit
+	if limit != nil {
+		l = *limit
+	}
+	o := DefaultOffset
+	if offset != nil {
+		o = *offset
+	}
+	result, err := u.SearchIndex.Search(ctx, q, o, l)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result.Hits {
+		result.Hits[i].Highlight = highlight(result.Hits[i].Sample.Name, terms)
+	}
+	return result, nil
+}
+
+// rebuildBatchSize is the number of samples loaded at once by RebuildIndex.
+const rebuildBatchSize = 1000
+
+// RebuildIndex puts all of non-deleted samples to SearchIndex. It does nothing if SearchIndex is nil.
+func (u *Usecase) RebuildIndex(ctx context.Context) (int, error) {
+	if u.SearchIndex == nil {
+		return 0, nil
+	}
+	for offset := 0; ; offset += rebuildBatchSize {
+		page, err := u.Repository.FindByFilter(ctx, SampleFilter{}, nil, nil, offset, rebuildBatchSize)
+		if err != nil {
+			return offset, err
+		}
+		for i := range page.Samples {
+			if err := u.SearchIndex.Put(ctx, &page.Samples[i]); err != nil {
+				return offset + i, err
+			}
+		}
+		if len(page.Samples) < rebuildBatchSize {
+			return offset + len(page.Samples), nil
+		}
+	}
 }
 
 type AddQuery struct {
@@ -60,23 +285,199 @@ type AddQuery struct {
 }
 
 func (u *Usecase) Add(ctx context.Context, q AddQuery) (uuid.UUID, error) {
+	ctx, err := u.authorize(ctx, OperationAdd)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
 	sample := model.NewSample(q.Name, q.Birthday, q.IsJapanese)
-	err := u.Repository.Insert(ctx, sample)
+	err = u.transaction(ctx, func(ctx context.Context) error {
+		if err := u.Repository.Insert(ctx, sample); err != nil {
+			return err
+		}
+		return u.record(ctx, sample.ID, model.ActionCreate, nil, sample)
+	})
 	if err != nil {
 		return uuid.UUID{}, err
 	}
+	if err := u.putIndex(ctx, sample); err != nil {
+		return uuid.UUID{}, err
+	}
 	return sample.ID, nil
 }
 
-func (u *Usecase) Edit(ctx context.Context, q UpdateQuery) error {
-	return u.Repository.Update(ctx, UpdateQuery{
-		ID:         q.ID,
-		Name:       q.Name,
-		Birthday:   q.Birthday,
-		IsJapanese: q.IsJapanese,
+// Edit loads the sample, applies q to it and saves it with the version check.
+// It returns ErrNotFound if the sample does not exist or is deleted,
+// and ErrConflict if q.IfMatch does not hold or another writer updates the sample first.
+func (u *Usecase) Edit(ctx context.Context, q UpdateQuery) (*model.Sample, error) {
+	ctx, err := u.authorize(ctx, OperationEdit)
+	if err != nil {
+		return nil, err
+	}
+	var updated *model.Sample
+	err = u.transaction(ctx, func(ctx context.Context) error {
+		old, err := u.findMatching(ctx, q.ID, q.IfMatch, u.Repository.FindByID)
+		if err != nil {
+			return err
+		}
+		updated = service.UpdateSample(old, q.Name, q.Birthday, q.IsJapanese)
+		if err := u.Repository.Update(ctx, updated); err != nil {
+			return err
+		}
+		return u.record(ctx, updated.ID, model.ActionEdit, old, updated)
 	})
+	if err != nil {
+		return nil, err
+	}
+	if err := u.putIndex(ctx, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
 }
 
-func (u *Usecase) Delete(ctx context.Context, id uuid.UUID) error {
-	return u.Repository.DeleteByID(ctx, id)
+// Patch loads the sample, applies apply to a copy of it and saves the result with the version check.
+// apply must not change the ID. It returns errors as well as Edit, and ErrInvalid if the result is invalid,
+// that is its name is empty or its birthday is zero. Unlike Add, the name is required so that patches cannot clear it by mistake.
+func (u *Usecase) Patch(ctx context.Context, id uuid.UUID, ifMatch *IfMatch, apply func(model.Sample) (*model.Sample, error)) (*model.Sample, error) {
+	ctx, err := u.authorize(ctx, OperationEdit)
+	if err != nil {
+		return nil, err
+	}
+	var patched *model.Sample
+	err = u.transaction(ctx, func(ctx context.Context) error {
+		old, err := u.findMatching(ctx, id, ifMatch, u.Repository.FindByID)
+		if err != nil {
+			return err
+		}
+		if patched, err = apply(*old); err != nil {
+			return err
+		}
+		if patched.ID != old.ID {
+			return fmt.Errorf("%w: ID cannot be changed", ErrInvalid)
+		}
+		if patched.Name == "" {
+			return fmt.Errorf("%w: Name is required", ErrInvalid)
+		}
+		if patched.Birthday.IsZero() {
+			return fmt.Errorf("%w: Birthday is required", ErrInvalid)
+		}
+		patched.Version, patched.OwnerID, patched.CreatedBy, patched.UpdatedBy = old.Version, old.OwnerID, old.CreatedBy, old.UpdatedBy
+		if err := u.Repository.Update(ctx, patched); err != nil {
+			return err
+		}
+		return u.record(ctx, patched.ID, model.ActionEdit, old, patched)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := u.putIndex(ctx, patched); err != nil {
+		return nil, err
+	}
+	return patched, nil
+}
+
+// Delete soft-deletes the sample. It returns ErrNotFound if the sample does not exist or is already deleted,
+// and ErrConflict if ifMatch does not hold.
+func (u *Usecase) Delete(ctx context.Context, id uuid.UUID, ifMatch *IfMatch) error {
+	ctx, err := u.authorize(ctx, OperationDelete)
+	if err != nil {
+		return err
+	}
+	if err := u.delete(ctx, id, ifMatch, model.ActionDelete, u.Repository.FindByID, u.Repository.DeleteByID); err != nil {
+		return err
+	}
+	return u.removeIndex(ctx, id)
+}
+
+// HardDelete deletes the sample permanently even if it is soft-deleted.
+// It returns ErrForbidden if Policy does not allow OperationHardDelete, and ErrNotFound if the sample does not exist.
+// It returns ErrConflict if ifMatch does not hold, which never holds for soft-deleted samples.
+func (u *Usecase) HardDelete(ctx context.Context, id uuid.UUID, ifMatch *IfMatch) error {
+	ctx, err := u.authorize(ctx, OperationHardDelete)
+	if err != nil {
+		return err
+	}
+	find := u.Repository.FindByID
+	if ifMatch == nil {
+		// soft-deleted samples are hard-deleted as well.
+		find = u.Repository.FindByIDIncludeDeleted
+	}
+	if err := u.delete(ctx, id, ifMatch, model.ActionHardDelete, find, u.Repository.HardDeleteByID); err != nil {
+		return err
+	}
+	return u.removeIndex(ctx, id)
+}
+
+// delete loads the sample by find, deletes it by deleteByID and records action with the loaded sample in a transaction.
+func (u *Usecase) delete(ctx context.Context, id uuid.UUID, ifMatch *IfMatch, action model.Action, find func(ctx context.Context, id uuid.UUID) (*model.Sample, error), deleteByID func(ctx context.Context, id uuid.UUID) error) error {
+	return u.transaction(ctx, func(ctx context.Context) error {
+		old, err := u.findMatching(ctx, id, ifMatch, find)
+		if err != nil {
+			return err
+		}
+		if err := deleteByID(ctx, id); err != nil {
+			return err
+		}
+		return u.record(ctx, id, action, old, nil)
+	})
+}
+
+// findMatching finds the sample by find and returns ErrConflict if ifMatch does not hold,
+// including when the sample is not found because preconditions need an existing sample.
+func (u *Usecase) findMatching(ctx context.Context, id uuid.UUID, ifMatch *IfMatch, find func(ctx context.Context, id uuid.UUID) (*model.Sample, error)) (*model.Sample, error) {
+	found, err := find(ctx, id)
+	if errors.Is(err, ErrNotFound) && ifMatch != nil {
+		return nil, ErrConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ifMatch.Matches(found.Version) {
+		return nil, ErrConflict
+	}
+	return found, nil
+}
+
+// ListDeleted returns soft-deleted samples in descending order of the time when they are deleted.
+// It returns ErrForbidden if Policy does not allow OperationListDeleted.
+func (u *Usecase) ListDeleted(ctx context.Context, limit, offset *int) (*model.PagedDeletedSamples, error) {
+	ctx, err := u.authorize(ctx, OperationListDeleted)
+	if err != nil {
+		return nil, err
+	}
+	l := DefaultLimit
+	if limit != nil {
+		l = *limit
+	}
+	o := DefaultOffset
+	if offset != nil {
+		o = *offset
+	}
+	return u.Repository.FindDeleted(ctx, o, l)
+}
+
+// Restore restores the soft-deleted sample and returns it.
+// It returns ErrForbidden if Policy does not allow OperationRestore, and ErrNotFound if the sample is not deleted.
+func (u *Usecase) Restore(ctx context.Context, id uuid.UUID) (*model.Sample, error) {
+	ctx, err := u.authorize(ctx, OperationRestore)
+	if err != nil {
+		return nil, err
+	}
+	var restored *model.Sample
+	err = u.transaction(ctx, func(ctx context.Context) error {
+		if err := u.Repository.Restore(ctx, id); err != nil {
+			return err
+		}
+		var err error
+		if restored, err = u.Repository.FindByID(ctx, id); err != nil {
+			return err
+		}
+		return u.record(ctx, id, model.ActionRestore, nil, restored)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := u.putIndex(ctx, restored); err != nil {
+		return nil, err
+	}
+	return restored, nil
 }
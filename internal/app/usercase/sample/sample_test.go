@@ -3,39 +3,483 @@ package sample
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/Accel-Hack/go-api/internal/domain/sample/model"
 	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestUsecase_Get(t *testing.T) {
+	var (
+		id    = uuid.MustParse("00000000-0000-0000-0000-000000000000")
+		owner = uuid.MustParse("00000000-0000-0000-0000-000000000101")
+	)
+	tests := map[string]struct {
+		policy     Policy
+		wantAccess Access
+		wantErr    error
+	}{
+		"find all samples without policy": {},
+		"find samples which the caller can access": {
+			policy:     accessPolicy{UserID: &owner, OwnedOnly: true},
+			wantAccess: Access{UserID: &owner, OwnedOnly: true},
+		},
+		"return ErrForbidden when the caller cannot own samples": {
+			policy:  accessPolicy{OwnedOnly: true},
+			wantErr: ErrForbidden,
+		},
+		"return ErrForbidden when read is not allowed": {
+			policy:  denyPolicy{},
+			wantErr: ErrForbidden,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			repo := &stubRepository{
+				findByID: func(ctx context.Context, id uuid.UUID) (*model.Sample, error) {
+					assert.Equal(t, tt.wantAccess, AccessFrom(ctx))
+					return &model.Sample{ID: id, Name: "test-japanese", Version: 1}, nil
+				},
+			}
+			u := Usecase{Repository: repo, Policy: tt.policy}
+			got, err := u.Get(context.Background(), id)
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr == nil {
+				assert.Equal(t, id, got.ID)
+			}
+		})
+	}
 }
 
-type stubRepository struct{}
+func TestUsecase_Search(t *testing.T) {
+	month, day := 13, 0
+	tests := map[string]struct {
+		filter  SampleFilter
+		wantErr error
+	}{
+		"return ErrInvalid when birth month is out of range": {
+			filter:  SampleFilter{BirthMonth: &month},
+			wantErr: ErrInvalid,
+		},
+		"return ErrInvalid when birth day is out of range": {
+			filter:  SampleFilter{BirthDay: &day},
+			wantErr: ErrInvalid,
+		},
+		"return ErrInvalid when match mode is unknown": {
+			filter:  SampleFilter{NameMatch: "regexp"},
+			wantErr: ErrInvalid,
+		},
+		"return ErrForbidden when including deleted samples is not allowed": {
+			filter:  SampleFilter{IncludeDeleted: true},
+			wantErr: ErrForbidden,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			u := Usecase{Repository: &stubRepository{}, Policy: denyPolicy{}}
+			_, err := u.Search(context.Background(), tt.filter, nil, nil, nil, nil)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestUsecase_Edit(t *testing.T) {
+	var (
+		id       = uuid.MustParse("00000000-0000-0000-0000-000000000000")
+		birthday = time.Date(1994, 9, 14, 0, 0, 0, 0, time.Local)
+		name     = "edited"
+		v1, v2   = 1, 2
+		ifMatch  = func(versions ...int) *IfMatch { return &IfMatch{Versions: versions} }
+	)
+	stored := func() *model.Sample {
+		return &model.Sample{ID: id, Name: "test-japanese", Birthday: birthday, IsJapanese: true, Version: v1}
+	}
+	tests := map[string]struct {
+		query    UpdateQuery
+		findByID func(ctx context.Context, id uuid.UUID) (*model.Sample, error)
+		update   func(ctx context.Context, sample *model.Sample) error
+		want     *model.Sample
+		wantErr  error
+	}{
+		"update only given fields": {
+			query: UpdateQuery{ID: id, Name: &name},
+			findByID: func(ctx context.Context, id uuid.UUID) (*model.Sample, error) {
+				return stored(), nil
+			},
+			update: func(ctx context.Context, sample *model.Sample) error {
+				sample.Version++
+				return nil
+			},
+			want: &model.Sample{ID: id, Name: name, Birthday: birthday, IsJapanese: true, Version: v2},
+		},
+		"return ErrNotFound when sample does not exist": {
+			query: UpdateQuery{ID: id, Name: &name},
+			findByID: func(ctx context.Context, id uuid.UUID) (*model.Sample, error) {
+				return nil, ErrNotFound
+			},
+			wantErr: ErrNotFound,
+		},
+		"update sample matching one of versions": {
+			query: UpdateQuery{ID: id, Name: &name, IfMatch: ifMatch(v2, v1)},
+			findByID: func(ctx context.Context, id uuid.UUID) (*model.Sample, error) {
+				return stored(), nil
+			},
+			update: func(ctx context.Context, sample *model.Sample) error {
+				sample.Version++
+				return nil
+			},
+			want: &model.Sample{ID: id, Name: name, Birthday: birthday, IsJapanese: true, Version: v2},
+		},
+		"return ErrConflict when version does not match": {
+			query: UpdateQuery{ID: id, Name: &name, IfMatch: ifMatch(v2)},
+			findByID: func(ctx context.Context, id uuid.UUID) (*model.Sample, error) {
+				return stored(), nil
+			},
+			wantErr: ErrConflict,
+		},
+		"return ErrConflict when no version matches": {
+			query: UpdateQuery{ID: id, Name: &name, IfMatch: ifMatch()},
+			findByID: func(ctx context.Context, id uuid.UUID) (*model.Sample, error) {
+				return stored(), nil
+			},
+			wantErr: ErrConflict,
+		},
+		"return ErrConflict when any version is expected and sample does not exist": {
+			query: UpdateQuery{ID: id, Name: &name, IfMatch: &IfMatch{Any: true}},
+			findByID: func(ctx context.Context, id uuid.UUID) (*model.Sample, error) {
+				return nil, ErrNotFound
+			},
+			wantErr: ErrConflict,
+		},
+		"return ErrConflict when another writer updates first": {
+			query: UpdateQuery{ID: id, Name: &name, IfMatch: ifMatch(v1)},
+			findByID: func(ctx context.Context, id uuid.UUID) (*model.Sample, error) {
+				return stored(), nil
+			},
+			update: func(ctx context.Context, sample *model.Sample) error {
+				return ErrConflict
+			},
+			wantErr: ErrConflict,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			u := Usecase{Repository: &stubRepository{findByID: tt.findByID, update: tt.update}}
+			got, err := u.Edit(context.Background(), tt.query)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestUsecase_Patch(t *testing.T) {
+	var (
+		id       = uuid.MustParse("00000000-0000-0000-0000-000000000000")
+		birthday = time.Date(1994, 9, 14, 0, 0, 0, 0, time.Local)
+	)
+	repo := &stubRepository{
+		findByID: func(ctx context.Context, id uuid.UUID) (*model.Sample, error) {
+			return &model.Sample{ID: id, Name: "test-japanese", Birthday: birthday, IsJapanese: true, Version: 1}, nil
+		},
+		update: func(ctx context.Context, sample *model.Sample) error {
+			sample.Version++
+			return nil
+		},
+	}
+	tests := map[string]struct {
+		apply   func(model.Sample) (*model.Sample, error)
+		want    *model.Sample
+		wantErr error
+	}{
+		"save patched sample": {
+			apply: func(s model.Sample) (*model.Sample, error) {
+				s.Name = "patched"
+				return &s, nil
+			},
+			want: &model.Sample{ID: id, Name: "patched", Birthday: birthday, IsJapanese: true, Version: 2},
+		},
+		"return ErrInvalid when Name is empty": {
+			apply: func(s model.Sample) (*model.Sample, error) {
+				s.Name = ""
+				return &s, nil
+			},
+			wantErr: ErrInvalid,
+		},
+		"return ErrInvalid when ID is changed": {
+			apply: func(s model.Sample) (*model.Sample, error) {
+				s.ID = uuid.New()
+				return &s, nil
+			},
+			wantErr: ErrInvalid,
+		},
+		"return ErrInvalid when Birthday is zero": {
+			apply: func(s model.Sample) (*model.Sample, error) {
+				s.Birthday = time.Time{}
+				return &s, nil
+			},
+			wantErr: ErrInvalid,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			u := Usecase{Repository: repo}
+			got, err := u.Patch(context.Background(), id, nil, tt.apply)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestUsecase_Restore(t *testing.T) {
+	var (
+		id       = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+		birthday = time.Date(1994, 10, 12, 0, 0, 0, 0, time.Local)
+	)
+	tests := map[string]struct {
+		policy  Policy
+		restore func(ctx context.Context, id uuid.UUID) error
+		want    *model.Sample
+		wantErr error
+	}{
+		"return restored sample": {
+			restore: func(ctx context.Context, id uuid.UUID) error {
+				return nil
+			},
+			want: &model.Sample{ID: id, Name: "test-deleted-japanese", Birthday: birthday, IsJapanese: true, Version: 1},
+		},
+		"return ErrNotFound when sample is not deleted": {
+			restore: func(ctx context.Context, id uuid.UUID) error {
+				return ErrNotFound
+			},
+			wantErr: ErrNotFound,
+		},
+		"return ErrForbidden when restore is not allowed": {
+			policy:  denyPolicy{},
+			wantErr: ErrForbidden,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			repo := &stubRepository{
+				findByID: func(ctx context.Context, id uuid.UUID) (*model.Sample, error) {
+					return &model.Sample{ID: id, Name: "test-deleted-japanese", Birthday: birthday, IsJapanese: true, Version: 1}, nil
+				},
+				restore: tt.restore,
+			}
+			u := Usecase{Repository: repo, Policy: tt.policy}
+			got, err := u.Restore(context.Background(), id)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestUsecase_Delete(t *testing.T) {
+	var (
+		id      = uuid.MustParse("00000000-0000-0000-0000-000000000004")
+		v1      = &IfMatch{Versions: []int{1}}
+		v2      = &IfMatch{Versions: []int{2}}
+		anyOf   = &IfMatch{Any: true}
+		missing = func(ctx context.Context, id uuid.UUID) (*model.Sample, error) { return nil, ErrNotFound }
+	)
+	tests := map[string]struct {
+		hard bool
+		// deleted means the sample is soft-deleted.
+		deleted  bool
+		policy   Policy
+		ifMatch  *IfMatch
+		findByID func(ctx context.Context, id uuid.UUID) (*model.Sample, error)
+		deleteFn func(ctx context.Context, id uuid.UUID) error
+		wantErr  error
+	}{
+		"soft delete sample": {
+			deleteFn: func(ctx context.Context, id uuid.UUID) error { return nil },
+		},
+		"return ErrNotFound when sample is missing or already deleted": {
+			deleteFn: func(ctx context.Context, id uuid.UUID) error { return ErrNotFound },
+			wantErr:  ErrNotFound,
+		},
+		"return ErrConflict when version is stale": {
+			ifMatch: v2,
+			wantErr: ErrConflict,
+		},
+		"soft delete sample of any version": {
+			ifMatch:  anyOf,
+			deleteFn: func(ctx context.Context, id uuid.UUID) error { return nil },
+		},
+		"return ErrConflict when any version is expected and sample does not exist": {
+			ifMatch:  anyOf,
+			findByID: missing,
+			wantErr:  ErrConflict,
+		},
+		"hard delete sample": {
+			hard:     true,
+			deleteFn: func(ctx context.Context, id uuid.UUID) error { return nil },
+		},
+		"hard delete sample with version": {
+			hard:     true,
+			ifMatch:  v1,
+			deleteFn: func(ctx context.Context, id uuid.UUID) error { return nil },
+		},
+		"hard delete soft-deleted sample": {
+			hard:     true,
+			deleted:  true,
+			deleteFn: func(ctx context.Context, id uuid.UUID) error { return nil },
+		},
+		"return ErrConflict when hard delete soft-deleted sample with version": {
+			hard:    true,
+			deleted: true,
+			ifMatch: v1,
+			wantErr: ErrConflict,
+		},
+		"return ErrForbidden when hard delete is not allowed": {
+			hard:    true,
+			policy:  denyPolicy{},
+			wantErr: ErrForbidden,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			found := func(ctx context.Context, id uuid.UUID) (*model.Sample, error) {
+				return &model.Sample{ID: id, Name: "test", Version: 1}, nil
+			}
+			repo := &stubRepository{findByID: found}
+			if tt.findByID != nil {
+				repo.findByID = tt.findByID
+			}
+			if tt.deleted {
+				repo.findByID = missing
+				repo.findByIDIncludeDeleted = found
+			}
+			if tt.hard {
+				repo.hardDeleteByID = tt.deleteFn
+			} else {
+				repo.deleteByID = tt.deleteFn
+			}
+			u := Usecase{Repository: repo, Policy: tt.policy}
+			var err error
+			if tt.hard {
+				err = u.HardDelete(context.Background(), id, tt.ifMatch)
+			} else {
+				err = u.Delete(context.Background(), id, tt.ifMatch)
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+// stubRepository calls the function fields. Methods without function panic.
+type stubRepository struct {
+	findByID func(ctx context.Context, id uuid.UUID) (*model.Sample, error)
+	// findByIDIncludeDeleted falls back to findByID if it is nil.
+	findByIDIncludeDeleted func(ctx context.Context, id uuid.UUID) (*model.Sample, error)
+	findByKeyset           func(ctx context.Context, query KeysetQuery) (*KeysetPage, error)
+	insert                 func(ctx context.Context, sample *model.Sample) error
+	update                 func(ctx context.Context, sample *model.Sample) error
+	restore                func(ctx context.Context, id uuid.UUID) error
+	deleteByID             func(ctx context.Context, id uuid.UUID) error
+	hardDeleteByID         func(ctx context.Context, id uuid.UUID) error
+}
 
 // DeleteByID implements SampleRepository.
-func (*stubRepository) DeleteByID(ctx context.Context, id uuid.UUID) error {
-	panic("unimplemented")
+func (r *stubRepository) DeleteByID(ctx context.Context, id uuid.UUID) error {
+	if r.deleteByID == nil {
+		panic("unimplemented")
+	}
+	return r.deleteByID(ctx, id)
+}
+
+// HardDeleteByID implements SampleRepository.
+func (r *stubRepository) HardDeleteByID(ctx context.Context, id uuid.UUID) error {
+	if r.hardDeleteByID == nil {
+		panic("unimplemented")
+	}
+	return r.hardDeleteByID(ctx, id)
 }
 
 // FindByID implements SampleRepository.
-func (*stubRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Sample, error) {
-	panic("unimplemented")
+func (r *stubRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Sample, error) {
+	if r.findByID == nil {
+		panic("unimplemented")
+	}
+	return r.findByID(ctx, id)
+}
+
+// FindByIDIncludeDeleted implements SampleRepository.
+func (r *stubRepository) FindByIDIncludeDeleted(ctx context.Context, id uuid.UUID) (*model.Sample, error) {
+	if r.findByIDIncludeDeleted == nil {
+		return r.FindByID(ctx, id)
+	}
+	return r.findByIDIncludeDeleted(ctx, id)
 }
 
-// FindByNameLike implements SampleRepository.
-func (*stubRepository) FindByNameLike(ctx context.Context, name string, offset int, limit int) (*model.PagedSamples, error) {
+// FindByFilter implements SampleRepository.
+func (*stubRepository) FindByFilter(ctx context.Context, filter SampleFilter, sort []SortKey, fields Fields, offset int, limit int) (*model.PagedSamples, error) {
 	panic("unimplemented")
 }
 
+// FindByKeyset implements SampleRepository.
+func (r *stubRepository) FindByKeyset(ctx context.Context, query KeysetQuery) (*KeysetPage, error) {
+	if r.findByKeyset == nil {
+		panic("unimplemented")
+	}
+	return r.findByKeyset(ctx, query)
+}
+
 // Insert implements SampleRepository.
-func (*stubRepository) Insert(ctx context.Context, sample *model.Sample) error {
-	panic("unimplemented")
+func (r *stubRepository) Insert(ctx context.Context, sample *model.Sample) error {
+	if r.insert == nil {
+		panic("unimplemented")
+	}
+	return r.insert(ctx, sample)
 }
 
 // Update implements SampleRepository.
-func (*stubRepository) Update(ctx context.Context, sample UpdateQuery) error {
+func (r *stubRepository) Update(ctx context.Context, sample *model.Sample) error {
+	if r.update == nil {
+		panic("unimplemented")
+	}
+	return r.update(ctx, sample)
+}
+
+// FindDeleted implements SampleRepository.
+func (*stubRepository) FindDeleted(ctx context.Context, offset int, limit int) (*model.PagedDeletedSamples, error) {
 	panic("unimplemented")
 }
 
+// Restore implements SampleRepository.
+func (r *stubRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	if r.restore == nil {
+		panic("unimplemented")
+	}
+	return r.restore(ctx, id)
+}
+
 var _ SampleRepository = (*stubRepository)(nil)
+
+// denyPolicy denies all operations.
+type denyPolicy struct{}
+
+// Authorize implements Policy.
+func (denyPolicy) Authorize(ctx context.Context, op Operation) error {
+	return ErrForbidden
+}
+
+// Access implements Policy.
+func (denyPolicy) Access(ctx context.Context) (Access, error) {
+	return Access{}, ErrForbidden
+}
+
+// accessPolicy allows all operations on the samples of Access.
+type accessPolicy Access
+
+// Authorize implements Policy.
+func (accessPolicy) Authorize(ctx context.Context, op Operation) error {
+	return nil
+}
+
+// Access implements Policy.
+func (p accessPolicy) Access(ctx context.Context) (Access, error) {
+	return Access(p), nil
+}
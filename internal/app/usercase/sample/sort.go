@@ -0,0 +1,69 @@
+package sample
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SortField is a field of samples which can be sorted by.
+type SortField string
+
+const (
+	SortByID         SortField = "id"
+	SortByName       SortField = "name"
+	SortByBirthday   SortField = "birthday"
+	SortByIsJapanese SortField = "is_japanese"
+	SortByCreatedAt  SortField = "created_at"
+	SortByUpdatedAt  SortField = "updated_at"
+)
+
+var sortFields = map[SortField]bool{
+	SortByID:         true,
+	SortByName:       true,
+	SortByBirthday:   true,
+	SortByIsJapanese: true,
+	SortByCreatedAt:  true,
+	SortByUpdatedAt:  true,
+}
+
+type SortKey struct {
+	Field SortField
+	Desc  bool
+}
+
+// ParseSort parses comma separated sort fields such as "-birthday,name".
+// A field prefixed with "-" is sorted in descending order. An empty string means no sort keys.
+// It returns ErrInvalid if a field is not sortable or duplicated.
+func ParseSort(s string) ([]SortKey, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var (
+		keys = []SortKey{}
+		seen = map[SortField]bool{}
+	)
+	for _, f := range strings.Split(s, ",") {
+		key := SortKey{Field: SortField(strings.TrimPrefix(f, "-")), Desc: strings.HasPrefix(f, "-")}
+		if !sortFields[key.Field] {
+			return nil, fmt.Errorf("%w: %q is not sortable", ErrInvalid, key.Field)
+		}
+		if seen[key.Field] {
+			return nil, fmt.Errorf("%w: %q is duplicated", ErrInvalid, key.Field)
+		}
+		seen[key.Field] = true
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// FormatSort formats keys as ParseSort parses.
+func FormatSort(keys []SortKey) string {
+	fields := make([]string, len(keys))
+	for i, key := range keys {
+		fields[i] = string(key.Field)
+		if key.Desc {
+			fields[i] = "-" + fields[i]
+		}
+	}
+	return strings.Join(fields, ",")
+}
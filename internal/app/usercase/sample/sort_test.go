@@ -0,0 +1,43 @@
+package sample
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := map[string]struct {
+		s       string
+		want    []SortKey
+		wantErr error
+	}{
+		"empty": {
+			s:    "",
+			want: nil,
+		},
+		"ascending and descending": {
+			s:    "-birthday,name",
+			want: []SortKey{{Field: SortByBirthday, Desc: true}, {Field: SortByName}},
+		},
+		"not sortable": {
+			s:       "password",
+			wantErr: ErrInvalid,
+		},
+		"duplicated": {
+			s:       "name,-name",
+			wantErr: ErrInvalid,
+		},
+		"empty field": {
+			s:       "name,",
+			wantErr: ErrInvalid,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseSort(tt.s)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package transaction
+
+import "context"
+
+// Manager runs a unit of work spanning multiple repository calls in a transaction.
+type Manager interface {
+	// Do calls fn in a transaction and commits it if fn returns nil.
+	// The transaction is rolled back if fn returns an error or panics.
+	//
+	// Repositories pick up the transaction from the context passed to fn.
+	// Do called with a context which already has a transaction joins that transaction.
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Nop is a Manager which calls fn without any transaction.
+type Nop struct{}
+
+// Do implements Manager.
+func (Nop) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+var _ Manager = Nop{}
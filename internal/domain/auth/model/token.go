@@ -12,3 +12,30 @@ type Token struct {
 	encryptRefreshToken string
 	expiresAt           time.Time
 }
+
+func NewToken(id uuid.UUID, accessToken, encryptRefreshToken string, expiresAt time.Time) *Token {
+	return &Token{
+		id:                  id,
+		accessToken:         accessToken,
+		encryptRefreshToken: encryptRefreshToken,
+		expiresAt:           expiresAt,
+	}
+}
+
+func (t *Token) ID() uuid.UUID {
+	return t.id
+}
+
+func (t *Token) AccessToken() string {
+	return t.accessToken
+}
+
+// EncryptRefreshToken returns the hash of the refresh token.
+func (t *Token) EncryptRefreshToken() string {
+	return t.encryptRefreshToken
+}
+
+// ExpiresAt returns the time when the access token expires.
+func (t *Token) ExpiresAt() time.Time {
+	return t.expiresAt
+}
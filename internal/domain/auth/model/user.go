@@ -1,13 +1,15 @@
 package model
 
 import (
+	"crypto/subtle"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-//go:generate stringer -type=Actor
+//go:generate stringer -type=Actor -linecomment
 type Actor int
 
 const (
@@ -17,6 +19,37 @@ const (
 	ActorUser                 // USER
 )
 
+// ErrInvalidActor is returned when the name of an actor is unknown.
+var ErrInvalidActor = errors.New("invalid actor")
+
+// ParseActor returns the actor of name, which is the name returned by String.
+func ParseActor(name string) (Actor, error) {
+	for a := ActorUnknown; a <= ActorUser; a++ {
+		if a.String() == name {
+			return a, nil
+		}
+	}
+	return ActorUnknown, fmt.Errorf("%w: %q", ErrInvalidActor, name)
+}
+
+// MarshalText implements encoding.TextMarshaler so that actors are encoded by the names in JSON.
+func (a Actor) MarshalText() ([]byte, error) {
+	if a < ActorUnknown || a > ActorUser {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidActor, a)
+	}
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (a *Actor) UnmarshalText(text []byte) error {
+	parsed, err := ParseActor(string(text))
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
 type User struct {
 	id              uuid.UUID
 	userName        string
@@ -24,12 +57,17 @@ type User struct {
 	actor           Actor
 	resetCode       *string
 	resetUntil      *time.Time
+	external        bool
 	tokens          []Token
 }
 
 var (
 	ErrEmptyUserNameEmpty       = errors.New("user name is empty")
 	ErrEmptyEncryptRefreshToken = errors.New("encrypt password is empty")
+	// ErrEmptyToken is returned when the access token or the hash of the refresh token is empty.
+	ErrEmptyToken = errors.New("token is empty")
+	// ErrInvalidResetCode is returned when the reset code is wrong, expired or not requested.
+	ErrInvalidResetCode = errors.New("reset code is invalid")
 )
 
 func NewUser(id uuid.UUID, userName, encryptPassword string, actor Actor, resetCode *string, resetUntil *time.Time, tokens []Token) (*User, error) {
@@ -52,3 +90,95 @@ func NewUser(id uuid.UUID, userName, encryptPassword string, actor Actor, resetC
 		tokens:          tokens,
 	}, nil
 }
+
+// NewExternalUser returns a user authenticated by an external identity provider, who has no password in go-api.
+func NewExternalUser(id uuid.UUID, userName string, actor Actor) (*User, error) {
+	if userName == "" {
+		return nil, ErrEmptyUserNameEmpty
+	}
+	return &User{
+		id:       id,
+		userName: userName,
+		actor:    actor,
+		external: true,
+		tokens:   []Token{},
+	}, nil
+}
+
+// External reports whether the user is authenticated by an external identity provider and not stored in go-api.
+func (u *User) External() bool {
+	return u.external
+}
+
+func (u *User) ID() uuid.UUID {
+	return u.id
+}
+
+func (u *User) UserName() string {
+	return u.userName
+}
+
+// EncryptPassword returns the hash of the password.
+func (u *User) EncryptPassword() string {
+	return u.encryptPassword
+}
+
+func (u *User) Actor() Actor {
+	return u.actor
+}
+
+// ResetCode returns the hash of the reset code, which is nil unless the password reset is requested.
+func (u *User) ResetCode() *string {
+	return u.resetCode
+}
+
+// ResetUntil returns the time when the reset code expires.
+func (u *User) ResetUntil() *time.Time {
+	return u.resetUntil
+}
+
+// RequestReset sets the hash of a new reset code which is valid until until. The previous code is invalidated.
+func (u *User) RequestReset(encryptResetCode string, until time.Time) {
+	u.resetCode = &encryptResetCode
+	u.resetUntil = &until
+}
+
+// VerifyResetCode returns ErrInvalidResetCode unless encryptResetCode is the hash of the reset code valid at now.
+func (u *User) VerifyResetCode(encryptResetCode string, now time.Time) error {
+	if u.resetCode == nil || u.resetUntil == nil {
+		return ErrInvalidResetCode
+	}
+	if subtle.ConstantTimeCompare([]byte(*u.resetCode), []byte(encryptResetCode)) != 1 {
+		return ErrInvalidResetCode
+	}
+	if !now.Before(*u.resetUntil) {
+		return ErrInvalidResetCode
+	}
+	return nil
+}
+
+// ChangePassword sets the hash of the new password and clears the reset code so that it cannot be used again.
+func (u *User) ChangePassword(encryptPassword string) error {
+	if encryptPassword == "" {
+		return ErrEmptyEncryptRefreshToken
+	}
+	u.encryptPassword = encryptPassword
+	u.resetCode = nil
+	u.resetUntil = nil
+	return nil
+}
+
+// Tokens returns the tokens issued to the user while it is loaded.
+func (u *User) Tokens() []Token {
+	return u.tokens
+}
+
+// IssueToken issues a token to the user, whose refresh token is stored as the hash encryptRefreshToken.
+func (u *User) IssueToken(id uuid.UUID, accessToken, encryptRefreshToken string, expiresAt time.Time) (*Token, error) {
+	if accessToken == "" || encryptRefreshToken == "" {
+		return nil, ErrEmptyToken
+	}
+	t := NewToken(id, accessToken, encryptRefreshToken, expiresAt)
+	u.tokens = append(u.tokens, *t)
+	return t, nil
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActor_JSON(t *testing.T) {
+	for _, a := range []Actor{ActorUnknown, ActorSystem, ActorManager, ActorUser} {
+		b, err := json.Marshal(a)
+		assert.NoError(t, err)
+		assert.Equal(t, `"`+a.String()+`"`, string(b))
+		var got Actor
+		assert.NoError(t, json.Unmarshal(b, &got))
+		assert.Equal(t, a, got)
+	}
+	_, err := json.Marshal(Actor(4))
+	assert.ErrorIs(t, err, ErrInvalidActor)
+	var got Actor
+	assert.ErrorIs(t, json.Unmarshal([]byte(`"ADMIN"`), &got), ErrInvalidActor)
+}
+
+func TestUser_ResetCode(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	user, err := NewUser(uuid.New(), "kawamura", "hash", ActorUser, nil, nil, nil)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, user.VerifyResetCode("code", now), ErrInvalidResetCode)
+
+	user.RequestReset("code", now.Add(time.Minute))
+	assert.ErrorIs(t, user.VerifyResetCode("wrong", now), ErrInvalidResetCode)
+	assert.ErrorIs(t, user.VerifyResetCode("code", now.Add(time.Minute)), ErrInvalidResetCode)
+	assert.NoError(t, user.VerifyResetCode("code", now))
+
+	assert.NoError(t, user.ChangePassword("new-hash"))
+	assert.Equal(t, "new-hash", user.EncryptPassword())
+	assert.ErrorIs(t, user.VerifyResetCode("code", now), ErrInvalidResetCode)
+}
+
+func TestUser_IssueToken(t *testing.T) {
+	user, err := NewUser(uuid.New(), "kawamura", "hash", ActorUser, nil, nil, nil)
+	assert.NoError(t, err)
+	expiresAt := time.Date(2024, 1, 1, 0, 15, 0, 0, time.UTC)
+	token, err := user.IssueToken(uuid.New(), "access", "refresh-hash", expiresAt)
+	assert.NoError(t, err)
+	assert.Equal(t, "access", token.AccessToken())
+	assert.Equal(t, "refresh-hash", token.EncryptRefreshToken())
+	assert.Equal(t, expiresAt, token.ExpiresAt())
+	assert.Equal(t, []Token{*token}, user.Tokens())
+
+	_, err = user.IssueToken(uuid.New(), "", "refresh-hash", expiresAt)
+	assert.ErrorIs(t, err, ErrEmptyToken)
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Action is a kind of mutations of samples recorded in the history.
+type Action string
+
+const (
+	ActionCreate     Action = "create"
+	ActionEdit       Action = "edit"
+	ActionDelete     Action = "delete"
+	ActionHardDelete Action = "hard_delete"
+	ActionRestore    Action = "restore"
+)
+
+// HistoryEvent is a mutation of a sample by an actor.
+type HistoryEvent struct {
+	ID       uuid.UUID
+	SampleID uuid.UUID
+	Action   Action
+	// ActorID is the user who caused the event, or nil if the actor is not a user such as an API key.
+	ActorID *uuid.UUID `json:",omitempty"`
+	// Actor describes the actor such as a user name, or is empty if the actor is anonymous.
+	Actor string
+	// RequestID is the ID of the request which caused the event, or empty if it is not caused by a request.
+	RequestID string
+	At        time.Time
+	// Changes are the fields changed by the action. Deletions change all fields to nothing, and creation and restore
+	// change all fields from nothing.
+	Changes []Change
+}
+
+// Change is a field of a sample changed from Before to After, which are JSON values of the field.
+// Before is empty when the sample is created or restored, and After is empty when it is deleted.
+type Change struct {
+	Field  string
+	Before json.RawMessage `json:",omitempty"`
+	After  json.RawMessage `json:",omitempty"`
+}
+
+// PagedHistory is a page of history events.
+type PagedHistory struct {
+	Total  int
+	Events []HistoryEvent
+}
@@ -1 +1,30 @@
 package model
+
+// CursorPagedSamples is a page of samples with cursors of the next and previous pages.
+// Next and Prev are empty if there is no such page, and Total is nil unless it is requested.
+type CursorPagedSamples struct {
+	Total   *int `json:",omitempty"`
+	Samples []Sample
+	Next    string `json:",omitempty"`
+	Prev    string `json:",omitempty"`
+}
+
+// ScoredSamples is a page of samples found by full-text search in order of relevance.
+type ScoredSamples struct {
+	Total int
+	Hits  []ScoredSample
+}
+
+// ScoredSample is a sample with the relevance to the search query.
+// Highlight is the HTML escaped name whose matched parts are enclosed in <em> tags.
+type ScoredSample struct {
+	Sample    Sample
+	Score     float64
+	Highlight string
+}
+
+// PagedDeletedSamples is a page of soft-deleted samples.
+type PagedDeletedSamples struct {
+	Total   int
+	Samples []DeletedSample
+}
@@ -12,6 +12,13 @@ type Sample struct {
 	Name       string
 	Birthday   time.Time
 	IsJapanese bool
+	// Version is incremented every time the sample is updated.
+	Version int `json:"-"`
+	// OwnerID is the user who owns the sample, or nil if the sample is added by a caller other than a user.
+	OwnerID *uuid.UUID `json:"-"`
+	// CreatedBy and UpdatedBy are the users who added and last saved the sample, or nil if they are not users.
+	CreatedBy *uuid.UUID `json:"-"`
+	UpdatedBy *uuid.UUID `json:"-"`
 }
 
 func NewSample(name string, birthday time.Time, isJapanese bool) *Sample {
@@ -23,6 +30,12 @@ func NewSample(name string, birthday time.Time, isJapanese bool) *Sample {
 	}
 }
 
+// DeletedSample is a soft-deleted sample with the time when it is deleted.
+type DeletedSample struct {
+	Sample
+	DeletedAt time.Time
+}
+
 type PagedSamples struct {
 	Total   int
 	Samples []Sample
@@ -24,5 +24,9 @@ func UpdateSample(old *model.Sample, name *string, birthday *time.Time, isJapane
 		Name:       newName,
 		Birthday:   newBirthday,
 		IsJapanese: newIsJapanese,
+		Version:    old.Version,
+		OwnerID:    old.OwnerID,
+		CreatedBy:  old.CreatedBy,
+		UpdatedBy:  old.UpdatedBy,
 	}
 }
@@ -0,0 +1,2 @@
+ALTER TABLE `SAMPLE`
+    ADD COLUMN `VERSION` INT NOT NULL DEFAULT 1 AFTER `IS_JAPANESE`;
@@ -0,0 +1,3 @@
+-- NAME_NORMALIZED is backfilled by go-api on start up because normalization is not available in SQL.
+ALTER TABLE `SAMPLE`
+    ADD COLUMN `NAME_NORMALIZED` VARCHAR(400) NULL AFTER `NAME`;
@@ -0,0 +1,5 @@
+-- The index is used with -search.index=fulltext.
+-- Bigrams containing stopwords such as "a" are not indexed unless stopwords are disabled on creating the index.
+SET SESSION innodb_ft_enable_stopword = OFF;
+ALTER TABLE `SAMPLE`
+    ADD FULLTEXT INDEX `FT_SAMPLE_NAME_NORMALIZED` (`NAME_NORMALIZED`) WITH PARSER ngram;
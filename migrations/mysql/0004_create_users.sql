@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS `USERS`
+(
+    `ID`               CHAR(36)     NOT NULL PRIMARY KEY,
+    `USER_NAME`        VARCHAR(100) NOT NULL UNIQUE,
+    `ENCRYPT_PASSWORD` VARCHAR(255) NOT NULL,
+    `ACTOR`            INT          NOT NULL,
+    `CREATED_AT`       TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `UPDATED_AT`       TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
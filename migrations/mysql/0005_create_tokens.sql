@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS `TOKENS`
+(
+    `ID`                    CHAR(36)  NOT NULL PRIMARY KEY,
+    `FAMILY_ID`             CHAR(36)  NOT NULL,
+    `USER_ID`               CHAR(36)  NOT NULL,
+    `ENCRYPT_REFRESH_TOKEN` CHAR(64)  NOT NULL UNIQUE,
+    `EXPIRES_AT`            TIMESTAMP NOT NULL,
+    `ROTATED_AT`            TIMESTAMP NULL,
+    `REVOKED_AT`            TIMESTAMP NULL,
+    `CREATED_AT`            TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX `IDX_TOKENS_FAMILY_ID` (`FAMILY_ID`),
+    INDEX `IDX_TOKENS_EXPIRES_AT` (`EXPIRES_AT`)
+);
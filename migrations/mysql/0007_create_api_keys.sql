@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS `API_KEYS`
+(
+    `ID`          CHAR(36)     NOT NULL PRIMARY KEY,
+    `NAME`        VARCHAR(100) NOT NULL,
+    `PREFIX`      CHAR(12)     NOT NULL UNIQUE,
+    `ENCRYPT_KEY` CHAR(64)     NOT NULL,
+    `ACTOR`       INT          NOT NULL,
+    `SCOPES`      VARCHAR(255) NOT NULL,
+    `CREATED_AT`  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `REVOKED_AT`  TIMESTAMP    NULL
+);
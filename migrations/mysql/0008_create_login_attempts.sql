@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS `LOGIN_ATTEMPTS`
+(
+    `SUBJECT`      VARCHAR(128) NOT NULL PRIMARY KEY,
+    `FAILURES`     INT          NOT NULL,
+    `LOCKOUTS`     INT          NOT NULL,
+    `LOCKED_UNTIL` TIMESTAMP    NULL,
+    `UPDATED_AT`   TIMESTAMP    NOT NULL,
+    INDEX `IDX_LOGIN_ATTEMPTS_UPDATED_AT` (`UPDATED_AT`)
+);
@@ -0,0 +1,7 @@
+-- samples added before owners are recorded have no owner so that only managers can access them.
+-- owners are not foreign keys of USERS because external users are not stored.
+ALTER TABLE `SAMPLE`
+    ADD COLUMN `OWNER_ID`   CHAR(36) NULL,
+    ADD COLUMN `CREATED_BY` CHAR(36) NULL,
+    ADD COLUMN `UPDATED_BY` CHAR(36) NULL,
+    ADD INDEX `IDX_SAMPLE_OWNER_ID` (`OWNER_ID`);
@@ -0,0 +1,14 @@
+-- events are kept after samples are hard deleted, so SAMPLE_ID is not a foreign key of SAMPLE.
+CREATE TABLE IF NOT EXISTS `SAMPLE_HISTORY`
+(
+    `ID`         CHAR(36)     NOT NULL PRIMARY KEY,
+    `SAMPLE_ID`  CHAR(36)     NOT NULL,
+    `ACTION`     VARCHAR(32)  NOT NULL,
+    `ACTOR_ID`   CHAR(36)     NULL,
+    `ACTOR`      VARCHAR(128) NOT NULL,
+    `REQUEST_ID` VARCHAR(128) NOT NULL,
+    `AT`         TIMESTAMP(6) NOT NULL,
+    `CHANGES`    TEXT         NOT NULL,
+    INDEX `IDX_SAMPLE_HISTORY_SAMPLE_ID` (`SAMPLE_ID`, `AT`),
+    INDEX `IDX_SAMPLE_HISTORY_AT` (`AT`)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE "SAMPLE"
+    ADD COLUMN "VERSION" INTEGER NOT NULL DEFAULT 1;
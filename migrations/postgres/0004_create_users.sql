@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS "USERS"
+(
+    "ID"               UUID         NOT NULL PRIMARY KEY,
+    "USER_NAME"        VARCHAR(100) NOT NULL UNIQUE,
+    "ENCRYPT_PASSWORD" VARCHAR(255) NOT NULL,
+    "ACTOR"            INTEGER      NOT NULL,
+    "CREATED_AT"       TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "UPDATED_AT"       TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
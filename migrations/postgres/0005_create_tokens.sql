@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS "TOKENS"
+(
+    "ID"                    UUID        NOT NULL PRIMARY KEY,
+    "FAMILY_ID"             UUID        NOT NULL,
+    "USER_ID"               UUID        NOT NULL,
+    "ENCRYPT_REFRESH_TOKEN" CHAR(64)    NOT NULL UNIQUE,
+    "EXPIRES_AT"            TIMESTAMPTZ NOT NULL,
+    "ROTATED_AT"            TIMESTAMPTZ NULL,
+    "REVOKED_AT"            TIMESTAMPTZ NULL,
+    "CREATED_AT"            TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX "IDX_TOKENS_FAMILY_ID" ON "TOKENS" ("FAMILY_ID");
+CREATE INDEX "IDX_TOKENS_EXPIRES_AT" ON "TOKENS" ("EXPIRES_AT");
@@ -0,0 +1,4 @@
+-- RESET_CODE is the SHA-256 hash of the reset code, which is NULL unless the password reset is requested.
+ALTER TABLE "USERS"
+    ADD COLUMN "RESET_CODE"  CHAR(64)    NULL,
+    ADD COLUMN "RESET_UNTIL" TIMESTAMPTZ NULL;
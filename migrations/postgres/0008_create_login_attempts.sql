@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS "LOGIN_ATTEMPTS"
+(
+    "SUBJECT"      VARCHAR(128) NOT NULL PRIMARY KEY,
+    "FAILURES"     INT          NOT NULL,
+    "LOCKOUTS"     INT          NOT NULL,
+    "LOCKED_UNTIL" TIMESTAMPTZ  NULL,
+    "UPDATED_AT"   TIMESTAMPTZ  NOT NULL
+);
+CREATE INDEX "IDX_LOGIN_ATTEMPTS_UPDATED_AT" ON "LOGIN_ATTEMPTS" ("UPDATED_AT");
@@ -0,0 +1,7 @@
+-- samples added before owners are recorded have no owner so that only managers can access them.
+-- owners are not foreign keys of USERS because external users are not stored.
+ALTER TABLE "SAMPLE"
+    ADD COLUMN "OWNER_ID"   UUID NULL,
+    ADD COLUMN "CREATED_BY" UUID NULL,
+    ADD COLUMN "UPDATED_BY" UUID NULL;
+CREATE INDEX "IDX_SAMPLE_OWNER_ID" ON "SAMPLE" ("OWNER_ID");
@@ -0,0 +1,14 @@
+-- events are kept after samples are hard deleted, so SAMPLE_ID is not a foreign key of SAMPLE.
+CREATE TABLE IF NOT EXISTS "SAMPLE_HISTORY"
+(
+    "ID"         UUID         NOT NULL PRIMARY KEY,
+    "SAMPLE_ID"  UUID         NOT NULL,
+    "ACTION"     VARCHAR(32)  NOT NULL,
+    "ACTOR_ID"   UUID         NULL,
+    "ACTOR"      VARCHAR(128) NOT NULL,
+    "REQUEST_ID" VARCHAR(128) NOT NULL,
+    "AT"         TIMESTAMPTZ  NOT NULL,
+    "CHANGES"    TEXT         NOT NULL
+);
+CREATE INDEX "IDX_SAMPLE_HISTORY_SAMPLE_ID" ON "SAMPLE_HISTORY" ("SAMPLE_ID", "AT");
+CREATE INDEX "IDX_SAMPLE_HISTORY_AT" ON "SAMPLE_HISTORY" ("AT");
@@ -0,0 +1,92 @@
+DROP TABLE IF EXISTS "SAMPLE";
+CREATE TABLE IF NOT EXISTS "SAMPLE"
+(
+    "ID"              UUID         NOT NULL PRIMARY KEY,
+    "NAME"            VARCHAR(400) NOT NULL,
+    "NAME_NORMALIZED" VARCHAR(400) NULL,
+    "BIRTHDAY"        DATE         NOT NULL,
+    "IS_JAPANESE"     BOOLEAN      NOT NULL,
+    "VERSION"         INTEGER      NOT NULL DEFAULT 1,
+    "CREATED_AT"      TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "UPDATED_AT"      TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "IS_DELETED"      BOOLEAN      NOT NULL DEFAULT FALSE,
+    "DELETED_AT"      TIMESTAMPTZ  NULL,
+    "OWNER_ID"        UUID         NULL,
+    "CREATED_BY"      UUID         NULL,
+    "UPDATED_BY"      UUID         NULL
+);
+CREATE INDEX "IDX_SAMPLE_OWNER_ID" ON "SAMPLE" ("OWNER_ID");
+
+INSERT INTO "SAMPLE"("ID", "NAME", "BIRTHDAY", "IS_JAPANESE")
+VALUES ('2e40b651-c32e-4dab-85bd-5a2a81f58c58', 'kawamura1', '1994-09-14', true),
+       ('7d937a5e-7fa3-4676-949c-6366e988d830', 'kawamura2', '1994-10-12', true),
+       ('ee4d8f69-7b37-45b2-ba55-08a23e429ec3', 'kawamura3', '1994-11-08', true),
+       ('ffda86bf-ee4d-443b-9dcd-5ec9881209b3', 'kawamura4', '1994-11-08', true),
+       ('f32b76d3-6972-4b62-b19c-1d31bfc88e54', 'kawamura5', '1994-12-12', true);
+
+DROP TABLE IF EXISTS "USERS";
+CREATE TABLE IF NOT EXISTS "USERS"
+(
+    "ID"               UUID         NOT NULL PRIMARY KEY,
+    "USER_NAME"        VARCHAR(100) NOT NULL UNIQUE,
+    "ENCRYPT_PASSWORD" VARCHAR(255) NOT NULL,
+    "ACTOR"            INTEGER      NOT NULL,
+    "RESET_CODE"       CHAR(64)     NULL,
+    "RESET_UNTIL"      TIMESTAMPTZ  NULL,
+    "CREATED_AT"       TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "UPDATED_AT"       TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+DROP TABLE IF EXISTS "TOKENS";
+CREATE TABLE IF NOT EXISTS "TOKENS"
+(
+    "ID"                    UUID        NOT NULL PRIMARY KEY,
+    "FAMILY_ID"             UUID        NOT NULL,
+    "USER_ID"               UUID        NOT NULL,
+    "ENCRYPT_REFRESH_TOKEN" CHAR(64)    NOT NULL UNIQUE,
+    "EXPIRES_AT"            TIMESTAMPTZ NOT NULL,
+    "ROTATED_AT"            TIMESTAMPTZ NULL,
+    "REVOKED_AT"            TIMESTAMPTZ NULL,
+    "CREATED_AT"            TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX "IDX_TOKENS_FAMILY_ID" ON "TOKENS" ("FAMILY_ID");
+CREATE INDEX "IDX_TOKENS_EXPIRES_AT" ON "TOKENS" ("EXPIRES_AT");
+
+DROP TABLE IF EXISTS "API_KEYS";
+CREATE TABLE IF NOT EXISTS "API_KEYS"
+(
+    "ID"          UUID         NOT NULL PRIMARY KEY,
+    "NAME"        VARCHAR(100) NOT NULL,
+    "PREFIX"      CHAR(12)     NOT NULL UNIQUE,
+    "ENCRYPT_KEY" CHAR(64)     NOT NULL,
+    "ACTOR"       INT          NOT NULL,
+    "SCOPES"      VARCHAR(255) NOT NULL,
+    "CREATED_AT"  TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "REVOKED_AT"  TIMESTAMPTZ  NULL
+);
+
+DROP TABLE IF EXISTS "LOGIN_ATTEMPTS";
+CREATE TABLE IF NOT EXISTS "LOGIN_ATTEMPTS"
+(
+    "SUBJECT"      VARCHAR(128) NOT NULL PRIMARY KEY,
+    "FAILURES"     INT          NOT NULL,
+    "LOCKOUTS"     INT          NOT NULL,
+    "LOCKED_UNTIL" TIMESTAMPTZ  NULL,
+    "UPDATED_AT"   TIMESTAMPTZ  NOT NULL
+);
+CREATE INDEX "IDX_LOGIN_ATTEMPTS_UPDATED_AT" ON "LOGIN_ATTEMPTS" ("UPDATED_AT");
+
+DROP TABLE IF EXISTS "SAMPLE_HISTORY";
+CREATE TABLE IF NOT EXISTS "SAMPLE_HISTORY"
+(
+    "ID"         UUID         NOT NULL PRIMARY KEY,
+    "SAMPLE_ID"  UUID         NOT NULL,
+    "ACTION"     VARCHAR(32)  NOT NULL,
+    "ACTOR_ID"   UUID         NULL,
+    "ACTOR"      VARCHAR(128) NOT NULL,
+    "REQUEST_ID" VARCHAR(128) NOT NULL,
+    "AT"         TIMESTAMPTZ  NOT NULL,
+    "CHANGES"    TEXT         NOT NULL
+);
+CREATE INDEX "IDX_SAMPLE_HISTORY_SAMPLE_ID" ON "SAMPLE_HISTORY" ("SAMPLE_ID", "AT");
+CREATE INDEX "IDX_SAMPLE_HISTORY_AT" ON "SAMPLE_HISTORY" ("AT");